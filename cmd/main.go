@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/0xB1a60/runapp/internal/cli"
@@ -10,6 +11,10 @@ var version = "main"
 
 func main() {
 	if err := cli.Start(version); err != nil {
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
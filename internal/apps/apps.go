@@ -1,7 +1,6 @@
 package apps
 
 import (
-	"encoding/json"
 	"os"
 	"path"
 	"time"
@@ -11,6 +10,9 @@ import (
 )
 
 type App struct {
+	// SchemaVersion is the version of the stored config.json, older records are upgraded when they are read
+	SchemaVersion int `json:"schema_version" yaml:"schema_version"`
+
 	Name   string           `json:"name" yaml:"name"`
 	Mode   common.RunMode   `json:"mode" yaml:"mode"`
 	Status common.AppStatus `json:"status" yaml:"status"`
@@ -19,46 +21,178 @@ type App struct {
 	PID     int      `json:"pid" yaml:"pid"`
 	CWD     string   `json:"cwd" yaml:"cwd"`
 	Env     []string `json:"env" yaml:"env"`
+	// ManifestEnv are the names of the env variables set by runapp apply, removing one from the manifest restarts the app
+	ManifestEnv []string `json:"manifest_env" yaml:"manifest_env"`
+	// Labels are used to select groups of apps, e.g. runapp logs -l tier=web
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	// DependsOn are the apps that are started (and ready) before this app, they are stopped after it
+	DependsOn []string `json:"depends_on" yaml:"depends_on"`
 
+	// ConfigPath is the directory with config.json, StatePath the directory with the logs and run history,
+	// StatePath is empty for apps created before the state directory existed, both lived in ConfigPath
 	ConfigPath string `json:"config_path" yaml:"config_path"`
+	StatePath  string `json:"state_path" yaml:"state_path"`
 	StdoutPath string `json:"stdout_path" yaml:"stdout_path"`
 	StderrPath string `json:"stderr_path" yaml:"stderr_path"`
+	// CombinedPath is empty for apps created before the combined log existed
+	CombinedPath string `json:"combined_path" yaml:"combined_path"`
+
+	LogRotation LogRotation `json:"log_rotation" yaml:"log_rotation"`
+	History     History     `json:"history" yaml:"history"`
+	// Readiness is nil for apps that are ready as soon as they are started
+	Readiness *Readiness `json:"readiness" yaml:"readiness"`
+	// HealthCheck is nil for apps without health checks
+	HealthCheck *HealthCheck `json:"health_check" yaml:"health_check"`
+	// HealthError is the last failed health check of an unhealthy app
+	HealthError string `json:"health_error" yaml:"health_error"`
+	// Watch is nil for apps that are not restarted on file changes
+	Watch *Watch `json:"watch" yaml:"watch"`
+	// Limits is nil for apps without resource limits
+	Limits *Limits `json:"limits" yaml:"limits"`
 
-	StartedAt  *time.Time `json:"started_at" yaml:"started_at"`
+	// TriggeredBy is what started the current (or last) run
+	TriggeredBy common.Trigger `json:"triggered_by" yaml:"triggered_by"`
+	StartedAt   *time.Time     `json:"started_at" yaml:"started_at"`
+	// ReadyAt is when the readiness probes of the current run passed
+	ReadyAt    *time.Time `json:"ready_at" yaml:"ready_at"`
 	ExitCode   *int       `json:"exit_code" yaml:"exit_code"`
 	FinishedAt *time.Time `json:"finished_at" yaml:"finished_at"`
+	// OOMKilled is set when the kernel killed the last run for going over its memory limit
+	OOMKilled bool `json:"oom_killed" yaml:"oom_killed"`
+
+	// WrapperPID is the PID of the background process supervising the app, 0 when the daemon supervises it
+	WrapperPID int `json:"wrapper_pid" yaml:"wrapper_pid"`
+	// DaemonPID is the PID of the daemon supervising the app, it is never killed to stop the app
+	DaemonPID     int        `json:"daemon_pid" yaml:"daemon_pid"`
+	Restart       Restart    `json:"restart" yaml:"restart"`
+	RestartCount  int        `json:"restart_count" yaml:"restart_count"`
+	NextRestartAt *time.Time `json:"next_restart_at" yaml:"next_restart_at"`
+
+	// Schedule is set for apps with the scheduled mode, StartedAt, FinishedAt and ExitCode describe the last run
+	Schedule  *Schedule  `json:"schedule" yaml:"schedule"`
+	NextRunAt *time.Time `json:"next_run_at" yaml:"next_run_at"`
 }
 
+// StateDir returns the directory with the logs and run history of the app
+func (app *App) StateDir() string {
+	if len(app.StatePath) == 0 {
+		return app.ConfigPath
+	}
+	return app.StatePath
+}
+
+// RemoveFiles removes the config, logs and run history of the app
+func (app *App) RemoveFiles() error {
+	if err := os.RemoveAll(app.StateDir()); err != nil {
+		return err
+	}
+	return os.RemoveAll(app.ConfigPath)
+}
+
+// SaveToFile replaces config.json atomically while holding the config lock
 func (app *App) SaveToFile() error {
 	homeDir, err := util.HomeDirPath()
 	if err != nil {
 		return err
 	}
 
-	b, err := json.Marshal(app)
+	appDir := path.Join(homeDir, app.Name)
+	return withLock(appDir, func() error {
+		return writeConfig(appDir, app)
+	})
+}
+
+// SaveStatus saves the state of the current run (status, PIDs, exit code, times, restart and schedule state) while holding
+// the config lock, the other fields keep their stored values, e.g. the labels runapp apply changed while the app is supervised
+func (app *App) SaveStatus() error {
+	_, err := Update(app.Name, func(stored *App) error {
+		stored.Status = app.Status
+		stored.PID = app.PID
+		stored.WrapperPID = app.WrapperPID
+		stored.DaemonPID = app.DaemonPID
+		stored.TriggeredBy = app.TriggeredBy
+		stored.StartedAt = app.StartedAt
+		stored.ReadyAt = app.ReadyAt
+		stored.ExitCode = app.ExitCode
+		stored.FinishedAt = app.FinishedAt
+		stored.OOMKilled = app.OOMKilled
+		stored.HealthError = app.HealthError
+		stored.RestartCount = app.RestartCount
+		stored.NextRestartAt = app.NextRestartAt
+		stored.NextRunAt = app.NextRunAt
+		return nil
+	})
+	return err
+}
+
+// because runapp is daemon-less sometimes processes will be killed from the outside and runapp will show them as running,
+// the config is read again under the lock, the supervisor may have saved a new status in the meantime
+func (app *App) checkAndCorrectStatus() {
+	stale := *app
+	if !stale.correctStatus() {
+		return
+	}
+
+	updated, err := Update(app.Name, func(current *App) error {
+		current.correctStatus()
+		return nil
+	})
 	if err != nil {
-		return err
+		util.DebugLog("error saving app to file: %v", err)
+		*app = stale
+		return
 	}
+	*app = *updated
+}
 
-	if err := os.WriteFile(path.Join(homeDir, app.Name, common.FileConfig), b, os.ModePerm); err != nil {
-		return err
+// HasSupervisor reports whether the background process or the daemon supervising the app is still running
+func (app *App) HasSupervisor() bool {
+	pid := app.WrapperPID
+	if pid == 0 {
+		pid = app.DaemonPID
 	}
-	return nil
+	return pid > 0 && util.PidExists(pid)
 }
 
-// because runapp is daemon-less sometimes processes will be killed from the outside and runapp will show them as running
-func (app *App) checkAndCorrectStatus() {
-	if app.Status == common.AppStatusRunning && !util.PidExists(app.PID) {
+// correctStatus fixes the status of an app whose process or background process is gone, returns whether it changed
+func (app *App) correctStatus() bool {
+	if app.Status == common.AppStatusRestarting && !app.HasSupervisor() {
+		app.Status = common.AppStatusFailed
+		app.NextRestartAt = nil
+		return true
+	}
+
+	// the scheduler is gone, the app keeps the status of its last run
+	if app.Status == common.AppStatusScheduled && !app.HasSupervisor() {
 		app.Status = common.AppStatusFailed
 		if app.ExitCode != nil && *app.ExitCode == 0 {
 			app.Status = common.AppStatusSuccess
 		}
-		if err := app.SaveToFile(); err != nil {
-			util.DebugLog("error saving app to file: %v", err)
+		app.NextRunAt = nil
+		return true
+	}
+
+	if app.HasProcess() && !util.PidExists(app.PID) {
+		app.Status = common.AppStatusFailed
+		if app.ExitCode != nil && *app.ExitCode == 0 {
+			app.Status = common.AppStatusSuccess
 		}
+		return true
 	}
+	return false
 }
 
 func (app *App) IsRunning() bool {
-	return app.Status == common.AppStatusRunning || app.Status == common.AppStatusStarting
+	return app.HasProcess() || app.Status == common.AppStatusStarting || app.Status == common.AppStatusRestarting || app.Status == common.AppStatusScheduled
+}
+
+// HasProcess reports whether the app process was started and did not exit yet,
+// starting apps have a process while their readiness probes are pending and unhealthy apps are still running
+func (app *App) HasProcess() bool {
+	return app.IsReady() || (app.Status == common.AppStatusStarting && app.PID > 0)
+}
+
+// IsReady reports whether the app process is running and its readiness probes passed
+func (app *App) IsReady() bool {
+	return app.Status == common.AppStatusRunning || app.Status == common.AppStatusUnhealthy
 }
@@ -0,0 +1,31 @@
+package apps
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasSupervisor(t *testing.T) {
+	// no process has this PID, it is above the pid_max limit of Linux
+	const gonePID = 1 << 30
+
+	tests := []struct {
+		name     string
+		app      App
+		expected bool
+	}{
+		{name: "no supervisor", app: App{}, expected: false},
+		{name: "background process", app: App{WrapperPID: os.Getpid()}, expected: true},
+		{name: "daemon", app: App{DaemonPID: os.Getpid()}, expected: true},
+		{name: "background process is gone", app: App{WrapperPID: gonePID}, expected: false},
+		{name: "daemon is gone", app: App{DaemonPID: gonePID}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.app.HasSupervisor())
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package apps
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// BootResult is the outcome of starting an app on boot
+type BootResult string
+
+const (
+	BootResultStarted        BootResult = "started"
+	BootResultAlreadyRunning BootResult = "already running"
+	BootResultFailed         BootResult = "failed"
+)
+
+// BootApp is an on-boot app that runapp onboot tried to start
+type BootApp struct {
+	Name   string     `json:"name" yaml:"name"`
+	Result BootResult `json:"result" yaml:"result"`
+	// Error is why the app failed to start
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// BootReport is the last run of runapp onboot
+type BootReport struct {
+	StartedAt  time.Time `json:"started_at" yaml:"started_at"`
+	FinishedAt time.Time `json:"finished_at" yaml:"finished_at"`
+	Apps       []BootApp `json:"apps" yaml:"apps"`
+	// Error is set when the on-boot apps could not be listed or ordered
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Failed returns the apps that failed to start
+func (r BootReport) Failed() []BootApp {
+	var failed []BootApp
+	for _, app := range r.Apps {
+		if app.Result == BootResultFailed {
+			failed = append(failed, app)
+		}
+	}
+	return failed
+}
+
+// ReadBootReport returns the report of the last runapp onboot, nil if it never ran
+func ReadBootReport() (*BootReport, error) {
+	stateDir, err := util.StateDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path.Join(stateDir, common.FileBootReport))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var report BootReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// WriteBootReport replaces the report of the last runapp onboot
+func WriteBootReport(report BootReport) error {
+	stateDir, err := util.StateDirPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stateDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(stateDir, common.FileBootReport), b, 0644)
+}
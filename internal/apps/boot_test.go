@@ -0,0 +1,36 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/testutil"
+)
+
+func TestBootReport(t *testing.T) {
+	testutil.SetupHome(t)
+
+	report, err := ReadBootReport()
+	require.NoError(t, err)
+	require.Nil(t, report, "expected no report before the first boot")
+
+	startedAt := time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, WriteBootReport(BootReport{
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(2 * time.Second),
+		Apps: []BootApp{
+			{Name: "db", Result: BootResultAlreadyRunning},
+			{Name: "api", Result: BootResultStarted},
+			{Name: "worker", Result: BootResultFailed, Error: "app does not exist"},
+		},
+	}))
+
+	report, err = ReadBootReport()
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	require.True(t, startedAt.Equal(report.StartedAt))
+	require.Len(t, report.Apps, 3)
+	require.Equal(t, []BootApp{{Name: "worker", Result: BootResultFailed, Error: "app does not exist"}}, report.Failed())
+}
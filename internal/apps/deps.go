@@ -0,0 +1,131 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrDependencyCycle   = errors.New("dependency cycle")
+	ErrMissingDependency = errors.New("missing dependency")
+)
+
+// StartOrder returns the apps that have to be started for the given apps, dependencies come before their dependents.
+// Every app is listed once, the given apps are included
+func StartOrder(list []App, names ...string) ([]App, error) {
+	idx := make(map[string]App, len(list))
+	for _, app := range list {
+		idx[app.Name] = app
+	}
+
+	res := make([]App, 0, len(names))
+	visited := make(map[string]bool, len(list))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done, ok := visited[name]; ok {
+			if !done {
+				// the app is still on the path, the path from its first occurrence is the cycle
+				cycle := append(path[slices.Index(path, name):], name)
+				return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+			}
+			return nil
+		}
+
+		app, ok := idx[name]
+		if !ok {
+			if len(path) == 0 {
+				return fmt.Errorf("app: %s: %w", name, ErrNotFound)
+			}
+			return fmt.Errorf("%w: %s depends on %s which does not exist", ErrMissingDependency, path[len(path)-1], name)
+		}
+
+		visited[name] = false
+		path = append(path, name)
+		for _, dep := range app.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		visited[name] = true
+
+		res = append(res, app)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// SortByDependencies orders all apps so that dependencies come before their dependents, independent apps are ordered by name
+func SortByDependencies(list []App) ([]App, error) {
+	names := make([]string, 0, len(list))
+	for _, app := range list {
+		names = append(names, app.Name)
+	}
+	sort.Strings(names)
+	return StartOrder(list, names...)
+}
+
+// Dependents returns the apps that depend on the given app directly or through other apps,
+// dependents come before their dependencies, the order in which they have to be stopped
+func Dependents(list []App, name string) []App {
+	dependents := make(map[string][]string, len(list))
+	for _, app := range list {
+		for _, dep := range app.DependsOn {
+			dependents[dep] = append(dependents[dep], app.Name)
+		}
+	}
+
+	idx := make(map[string]App, len(list))
+	for _, app := range list {
+		idx[app.Name] = app
+	}
+
+	// a dependent is stopped only after everything that depends on it
+	var res []App
+	visited := map[string]bool{name: true}
+	var visit func(name string)
+	visit = func(name string) {
+		children := dependents[name]
+		sort.Strings(children)
+		for _, child := range children {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			visit(child)
+			res = append(res, idx[child])
+		}
+	}
+	visit(name)
+	return res
+}
+
+// ValidateDependencies checks that the dependencies of the app exist and do not lead back to the app,
+// the app replaces an existing app with the same name in the list
+func ValidateDependencies(list []App, app App) error {
+	if len(app.DependsOn) == 0 {
+		return nil
+	}
+
+	res := make([]App, 0, len(list)+1)
+	for _, existing := range list {
+		if existing.Name != app.Name {
+			res = append(res, existing)
+		}
+	}
+	res = append(res, app)
+
+	_, err := StartOrder(res, app.Name)
+	return err
+}
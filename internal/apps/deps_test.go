@@ -0,0 +1,113 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func names(list []App) []string {
+	res := make([]string, 0, len(list))
+	for _, app := range list {
+		res = append(res, app.Name)
+	}
+	return res
+}
+
+func TestStartOrder(t *testing.T) {
+	list := []App{
+		{Name: "web", DependsOn: []string{"api", "cache"}},
+		{Name: "api", DependsOn: []string{"db", "cache"}},
+		{Name: "db"},
+		{Name: "cache"},
+		{Name: "worker", DependsOn: []string{"db"}},
+	}
+
+	tests := []struct {
+		name     string
+		start    []string
+		expected []string
+	}{
+		{name: "no dependencies", start: []string{"db"}, expected: []string{"db"}},
+		{name: "direct", start: []string{"worker"}, expected: []string{"db", "worker"}},
+		{name: "transitive", start: []string{"web"}, expected: []string{"db", "cache", "api", "web"}},
+		{name: "shared dependency once", start: []string{"web", "worker"}, expected: []string{"db", "cache", "api", "web", "worker"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := StartOrder(list, tt.start...)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, names(order))
+		})
+	}
+}
+
+func TestStartOrder_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     []App
+		target   error
+		expected string
+	}{
+		{
+			name:     "self",
+			list:     []App{{Name: "a", DependsOn: []string{"a"}}},
+			target:   ErrDependencyCycle,
+			expected: "dependency cycle: a -> a",
+		},
+		{
+			name:     "cycle",
+			list:     []App{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"c"}}, {Name: "c", DependsOn: []string{"b"}}},
+			target:   ErrDependencyCycle,
+			expected: "dependency cycle: b -> c -> b",
+		},
+		{
+			name:     "missing",
+			list:     []App{{Name: "a", DependsOn: []string{"db"}}},
+			target:   ErrMissingDependency,
+			expected: "missing dependency: a depends on db which does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StartOrder(tt.list, "a")
+			require.ErrorIs(t, err, tt.target)
+			require.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	sorted, err := SortByDependencies([]App{
+		{Name: "web", DependsOn: []string{"api"}},
+		{Name: "db"},
+		{Name: "api", DependsOn: []string{"db"}},
+		{Name: "cron"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"db", "api", "cron", "web"}, names(sorted))
+}
+
+func TestDependents(t *testing.T) {
+	list := []App{
+		{Name: "web", DependsOn: []string{"api"}},
+		{Name: "api", DependsOn: []string{"db"}},
+		{Name: "worker", DependsOn: []string{"db", "api"}},
+		{Name: "db"},
+	}
+
+	require.Equal(t, []string{"web", "worker", "api"}, names(Dependents(list, "db")))
+	require.Equal(t, []string{"web", "worker"}, names(Dependents(list, "api")))
+	require.Empty(t, Dependents(list, "web"))
+}
+
+func TestValidateDependencies(t *testing.T) {
+	list := []App{{Name: "db"}, {Name: "api", DependsOn: []string{"db"}}}
+
+	require.NoError(t, ValidateDependencies(list, App{Name: "web", DependsOn: []string{"api"}}))
+	require.ErrorIs(t, ValidateDependencies(list, App{Name: "web", DependsOn: []string{"cache"}}), ErrMissingDependency)
+	// replacing db with an app that depends on api closes a cycle
+	require.ErrorIs(t, ValidateDependencies(list, App{Name: "db", DependsOn: []string{"api"}}), ErrDependencyCycle)
+}
@@ -1,17 +1,16 @@
 package apps
 
 import (
-	"encoding/json"
 	"errors"
-	"os"
 	"path"
 
-	"github.com/0xB1a60/runapp/internal/common"
 	"github.com/0xB1a60/runapp/internal/util"
 )
 
 var (
 	ErrNotFound = errors.New("app does not exist")
+	// ErrCorrupt is returned for an app whose config.json cannot be parsed
+	ErrCorrupt = errors.New("app config is corrupt")
 )
 
 func Get(name string) (*App, error) {
@@ -20,18 +19,10 @@ func Get(name string) (*App, error) {
 		return nil, err
 	}
 
-	configContent, err := os.ReadFile(path.Join(homeDir, name, common.FileConfig))
+	app, err := readConfig(path.Join(homeDir, name))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-
-	var app App
-	if err := json.Unmarshal(configContent, &app); err != nil {
 		return nil, err
 	}
 	app.checkAndCorrectStatus()
-	return &app, nil
+	return app, nil
 }
@@ -0,0 +1,97 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultHealthInterval         = 10 * time.Second
+	DefaultHealthTimeout          = 5 * time.Second
+	DefaultHealthFailureThreshold = 3
+	DefaultHealthExpectStatus     = 200
+)
+
+// HealthCheck probes a running app, exactly one of HTTP, TCP and Command is set
+type HealthCheck struct {
+	// HTTP is the URL that is requested with GET
+	HTTP string `json:"http" yaml:"http"`
+	// ExpectStatus is the status code expected from HTTP, 200 by default
+	ExpectStatus int `json:"expect_status" yaml:"expect_status"`
+	// TCP is the host:port that must accept connections
+	TCP string `json:"tcp" yaml:"tcp"`
+	// Command is run with the shell in the app cwd and env, it must exit with 0
+	Command string `json:"command" yaml:"command"`
+
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	Timeout  time.Duration `json:"timeout" yaml:"timeout"`
+	// FailureThreshold is the amount of consecutive failed checks after which the app is unhealthy
+	FailureThreshold int `json:"failure_threshold" yaml:"failure_threshold"`
+	// RestartOnUnhealthy stops an unhealthy app as failed, so it is restarted according to its restart policy
+	RestartOnUnhealthy bool `json:"restart_on_unhealthy" yaml:"restart_on_unhealthy"`
+}
+
+// WithDefaults fills the unset interval, timeout, threshold and expected status
+func (h HealthCheck) WithDefaults() HealthCheck {
+	if h.Interval <= 0 {
+		h.Interval = DefaultHealthInterval
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = DefaultHealthTimeout
+	}
+	if h.FailureThreshold <= 0 {
+		h.FailureThreshold = DefaultHealthFailureThreshold
+	}
+	if len(h.HTTP) == 0 {
+		h.ExpectStatus = 0
+	} else if h.ExpectStatus == 0 {
+		h.ExpectStatus = DefaultHealthExpectStatus
+	}
+	return h
+}
+
+func (h HealthCheck) Validate() error {
+	set := 0
+	for _, value := range []string{h.HTTP, h.TCP, h.Command} {
+		if len(value) != 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("health check needs exactly one of http, tcp or command")
+	}
+
+	if len(h.HTTP) != 0 {
+		u, err := url.Parse(h.HTTP)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("health check http must be an http(s) URL: %s", h.HTTP)
+		}
+	}
+	if h.ExpectStatus != 0 && (h.ExpectStatus < 100 || h.ExpectStatus > 599) {
+		return fmt.Errorf("health check expect_status is not a status code: %d", h.ExpectStatus)
+	}
+	if len(h.TCP) != 0 {
+		if _, _, err := net.SplitHostPort(h.TCP); err != nil {
+			return fmt.Errorf("health check tcp must be host:port: %s", h.TCP)
+		}
+	}
+	if h.Interval < 0 || h.Timeout < 0 || h.FailureThreshold < 0 {
+		return errors.New("health check interval, timeout and failure_threshold must not be negative")
+	}
+	return nil
+}
+
+// String describes the probe, e.g. HTTP GET http://localhost:8080/health
+func (h HealthCheck) String() string {
+	switch {
+	case len(h.HTTP) != 0:
+		return "HTTP GET " + h.HTTP
+	case len(h.TCP) != 0:
+		return "TCP " + h.TCP
+	default:
+		return "Command " + h.Command
+	}
+}
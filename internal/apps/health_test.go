@@ -0,0 +1,51 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthCheckValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   HealthCheck
+		wantErr bool
+	}{
+		{name: "http", check: HealthCheck{HTTP: "http://localhost:8080/health"}},
+		{name: "https with status", check: HealthCheck{HTTP: "https://localhost/health", ExpectStatus: 204}},
+		{name: "tcp", check: HealthCheck{TCP: "localhost:5432"}},
+		{name: "command", check: HealthCheck{Command: "pg_isready"}},
+		{name: "none", check: HealthCheck{}, wantErr: true},
+		{name: "http and tcp", check: HealthCheck{HTTP: "http://localhost", TCP: "localhost:80"}, wantErr: true},
+		{name: "http without scheme", check: HealthCheck{HTTP: "localhost:8080"}, wantErr: true},
+		{name: "invalid status", check: HealthCheck{HTTP: "http://localhost", ExpectStatus: 1000}, wantErr: true},
+		{name: "tcp without port", check: HealthCheck{TCP: "localhost"}, wantErr: true},
+		{name: "negative interval", check: HealthCheck{TCP: "localhost:80", Interval: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHealthCheckWithDefaults(t *testing.T) {
+	check := HealthCheck{HTTP: "http://localhost"}.WithDefaults()
+	require.Equal(t, DefaultHealthInterval, check.Interval)
+	require.Equal(t, DefaultHealthTimeout, check.Timeout)
+	require.Equal(t, DefaultHealthFailureThreshold, check.FailureThreshold)
+	require.Equal(t, DefaultHealthExpectStatus, check.ExpectStatus)
+
+	check = HealthCheck{TCP: "localhost:80", Interval: time.Second, FailureThreshold: 1}.WithDefaults()
+	require.Equal(t, time.Second, check.Interval)
+	require.Equal(t, 1, check.FailureThreshold)
+	require.Zero(t, check.ExpectStatus)
+}
@@ -0,0 +1,93 @@
+package apps
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+const (
+	DefaultHistoryMaxRuns = 20
+)
+
+// History limits the finished runs that are kept for an app
+type History struct {
+	// MaxRuns is the amount of runs that are kept, the oldest runs and their logs are removed first
+	MaxRuns int `json:"max_runs" yaml:"max_runs"`
+	// ArchiveLogs keeps a copy of the logs of every run, so they can be read after a restart
+	ArchiveLogs bool `json:"archive_logs" yaml:"archive_logs"`
+}
+
+// Limit returns MaxRuns, apps created before the history existed keep the default amount
+func (h History) Limit() int {
+	if h.MaxRuns <= 0 {
+		return DefaultHistoryMaxRuns
+	}
+	return h.MaxRuns
+}
+
+// Run is a finished run of an app
+type Run struct {
+	// ID counts the runs of the app from 1, it is kept when the app is created again
+	ID         int           `json:"id" yaml:"id"`
+	StartedAt  time.Time     `json:"started_at" yaml:"started_at"`
+	FinishedAt time.Time     `json:"finished_at" yaml:"finished_at"`
+	Duration   time.Duration `json:"duration" yaml:"duration"`
+	ExitCode   int           `json:"exit_code" yaml:"exit_code"`
+	// Signal is the name of the signal that terminated the run, e.g. SIGTERM
+	Signal      string         `json:"signal,omitempty" yaml:"signal,omitempty"`
+	TriggeredBy common.Trigger `json:"triggered_by" yaml:"triggered_by"`
+	// LogsArchived is set when the logs of the run were copied into its run directory
+	LogsArchived bool `json:"logs_archived" yaml:"logs_archived"`
+	// OOMKilled is set when the kernel killed the run for going over its memory limit
+	OOMKilled bool `json:"oom_killed,omitempty" yaml:"oom_killed,omitempty"`
+}
+
+// ReadHistory returns the finished runs of the app from the oldest to the newest
+func ReadHistory(app App) ([]Run, error) {
+	b, err := os.ReadFile(path.Join(app.StateDir(), common.FileHistory))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var runs []Run
+	if err := json.Unmarshal(b, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// WriteHistory replaces the finished runs of the app
+func WriteHistory(app App, runs []Run) error {
+	b, err := json.Marshal(runs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(app.StateDir(), common.FileHistory), b, 0644)
+}
+
+// RunDir is the directory with the archived logs of the run
+func (app App) RunDir(id int) string {
+	return path.Join(app.StateDir(), common.DirRuns, strconv.Itoa(id))
+}
+
+// WithRunLogs returns the app with its log paths pointing to the archived logs of the run
+func (app App) WithRunLogs(run Run) App {
+	dir := app.RunDir(run.ID)
+	app.StdoutPath = path.Join(dir, common.FileStdOut)
+	app.StderrPath = path.Join(dir, common.FileStdErr)
+	app.CombinedPath = path.Join(dir, common.FileCombined)
+	// runs of apps created before the combined log existed only have the raw logs
+	if _, err := os.Stat(app.CombinedPath); err != nil {
+		app.CombinedPath = ""
+	}
+	return app
+}
@@ -0,0 +1,123 @@
+package apps
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var labelKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]*[a-zA-Z0-9])?$`)
+
+// ValidateLabel checks that the label key and value can be used in a selector
+func ValidateLabel(key string, value string) error {
+	if !labelKeyRegex.MatchString(key) {
+		return fmt.Errorf("invalid label key: %q, must be alphanumeric and may contain . _ / -", key)
+	}
+	if strings.ContainsAny(value, ",=! ") {
+		return fmt.Errorf("invalid label value: %q for key: %s, must not contain , = ! or spaces", value, key)
+	}
+	return nil
+}
+
+// ParseLabels parses key=value pairs, e.g. from repeated --label flags
+func ParseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[string]string, len(values))
+	for _, entry := range values {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label: %q, expected key=value", entry)
+		}
+		if err := ValidateLabel(key, value); err != nil {
+			return nil, err
+		}
+		res[key] = value
+	}
+	return res, nil
+}
+
+type selectorOp int
+
+const (
+	opEquals selectorOp = iota
+	opNotEquals
+	opExists
+)
+
+type requirement struct {
+	key   string
+	op    selectorOp
+	value string
+}
+
+// Selector filters apps by labels, all requirements must match
+type Selector struct {
+	requirements []requirement
+}
+
+// ParseSelector parses a comma separated selector like "tier=web,env!=prod,team",
+// key=value and key!=value compare the label value, a bare key only requires the label to be set
+func ParseSelector(value string) (Selector, error) {
+	var res Selector
+	for part := range strings.SplitSeq(value, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+
+		req := requirement{op: opExists, key: part}
+		if key, val, ok := strings.Cut(part, "!="); ok {
+			req = requirement{op: opNotEquals, key: key, value: val}
+		} else if key, val, ok := strings.Cut(part, "="); ok {
+			req = requirement{op: opEquals, key: key, value: val}
+		}
+
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+		if err := ValidateLabel(req.key, req.value); err != nil {
+			return Selector{}, fmt.Errorf("invalid selector: %q: %w", part, err)
+		}
+		res.requirements = append(res.requirements, req)
+	}
+
+	if len(res.requirements) == 0 {
+		return Selector{}, fmt.Errorf("invalid selector: %q, expected e.g. tier=web", value)
+	}
+	return res, nil
+}
+
+// Matches reports whether the labels satisfy every requirement of the selector
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s.requirements {
+		value, ok := labels[req.key]
+		switch req.op {
+		case opEquals:
+			if !ok || value != req.value {
+				return false
+			}
+		case opNotEquals:
+			if ok && value == req.value {
+				return false
+			}
+		case opExists:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FormatLabels returns the labels as sorted key=value pairs
+func FormatLabels(labels map[string]string) string {
+	res := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		res = append(res, key+"="+labels[key])
+	}
+	return strings.Join(res, ",")
+}
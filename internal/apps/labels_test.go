@@ -0,0 +1,54 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels([]string{"tier=web", "env=prod", "empty="})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"tier": "web", "env": "prod", "empty": ""}, labels)
+	require.Equal(t, "empty=,env=prod,tier=web", FormatLabels(labels))
+
+	labels, err = ParseLabels(nil)
+	require.NoError(t, err)
+	require.Nil(t, labels)
+
+	for _, value := range []string{"tier", "=web", "tier=a,b", "bad key=1"} {
+		_, err := ParseLabels([]string{value})
+		require.Error(t, err, value)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	labels := map[string]string{"tier": "web", "env": "prod"}
+
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{selector: "tier=web", expected: true},
+		{selector: "tier=web,env=prod", expected: true},
+		{selector: "tier=web, env=dev", expected: false},
+		{selector: "env!=dev", expected: true},
+		{selector: "env!=prod", expected: false},
+		{selector: "team!=core", expected: true},
+		{selector: "tier", expected: true},
+		{selector: "team", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, selector.Matches(labels))
+		})
+	}
+
+	for _, value := range []string{"", ",", "tier==web", "=web"} {
+		_, err := ParseSelector(value)
+		require.Error(t, err, value)
+	}
+}
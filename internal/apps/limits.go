@@ -0,0 +1,122 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// Unlimited is the LimitSize that removes the inherited limit
+const Unlimited LimitSize = -1
+
+// LimitSize is a size in bytes that can also be unlimited, in YAML it is written as 10MB, 512KB or unlimited
+type LimitSize int64
+
+// ParseLimitSize parses sizes like ParseSize and unlimited
+func ParseLimitSize(value string) (LimitSize, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "unlimited") {
+		return Unlimited, nil
+	}
+	size, err := util.ParseSize(value)
+	if err != nil {
+		return 0, err
+	}
+	return LimitSize(size), nil
+}
+
+func (s *LimitSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseLimitSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+func (s LimitSize) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+func (s LimitSize) String() string {
+	if s == Unlimited {
+		return "unlimited"
+	}
+	return util.FormatSize(int64(s))
+}
+
+// Limits are the resources an app may use, zero values are not limited
+type Limits struct {
+	// MemoryMax, CPUQuota and PidsMax are applied through a cgroup v2 when runapp runs in a delegated subtree
+	MemoryMax ByteSize `json:"memory_max" yaml:"memory_max"`
+	// CPUQuota is in percent of one CPU, 200 allows two full CPUs
+	CPUQuota int `json:"cpu_quota" yaml:"cpu_quota"`
+	PidsMax  int `json:"pids_max" yaml:"pids_max"`
+
+	// NoFile and Core are rlimits, the app process gets them as both its soft and hard limit
+	NoFile uint64 `json:"nofile" yaml:"nofile"`
+	// Core is the max size of core dumps, nil keeps the inherited limit and 0 disables them
+	Core *LimitSize `json:"core" yaml:"core"`
+}
+
+// IsEmpty reports whether no limit is set
+func (l Limits) IsEmpty() bool {
+	return !l.HasCgroup() && !l.HasRlimits()
+}
+
+// HasCgroup reports whether a limit needs a cgroup
+func (l Limits) HasCgroup() bool {
+	return l.MemoryMax != 0 || l.CPUQuota != 0 || l.PidsMax != 0
+}
+
+// HasRlimits reports whether a limit is set as an rlimit
+func (l Limits) HasRlimits() bool {
+	return l.NoFile != 0 || l.Core != nil
+}
+
+func (l Limits) Validate() error {
+	if l.MemoryMax < 0 {
+		return errors.New("memory max must not be negative")
+	}
+	if l.CPUQuota < 0 {
+		return errors.New("cpu quota must not be negative")
+	}
+	if l.PidsMax < 0 {
+		return errors.New("pids max must not be negative")
+	}
+	if l.Core != nil && *l.Core < 0 && *l.Core != Unlimited {
+		return errors.New("core must not be negative")
+	}
+	return nil
+}
+
+func (l Limits) Equal(other Limits) bool {
+	if (l.Core == nil) != (other.Core == nil) || (l.Core != nil && *l.Core != *other.Core) {
+		return false
+	}
+	return l.MemoryMax == other.MemoryMax && l.CPUQuota == other.CPUQuota && l.PidsMax == other.PidsMax && l.NoFile == other.NoFile
+}
+
+func (l Limits) String() string {
+	var parts []string
+	if l.MemoryMax != 0 {
+		parts = append(parts, "memory: "+l.MemoryMax.String())
+	}
+	if l.CPUQuota != 0 {
+		parts = append(parts, fmt.Sprintf("cpu: %d%%", l.CPUQuota))
+	}
+	if l.PidsMax != 0 {
+		parts = append(parts, "pids: "+strconv.Itoa(l.PidsMax))
+	}
+	if l.NoFile != 0 {
+		parts = append(parts, "nofile: "+strconv.FormatUint(l.NoFile, 10))
+	}
+	if l.Core != nil {
+		parts = append(parts, "core: "+l.Core.String())
+	}
+	return strings.Join(parts, ", ")
+}
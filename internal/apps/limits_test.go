@@ -0,0 +1,78 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseLimitSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected LimitSize
+		wantErr  bool
+	}{
+		{value: "0", expected: 0},
+		{value: "512MB", expected: 512 * 1024 * 1024},
+		{value: "unlimited", expected: Unlimited},
+		{value: " Unlimited ", expected: Unlimited},
+		{value: "-1", wantErr: true},
+		{value: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := ParseLimitSize(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, size)
+		})
+	}
+}
+
+func TestLimits_YAML(t *testing.T) {
+	var limits Limits
+	require.NoError(t, yaml.Unmarshal([]byte("memory_max: 256MB\ncpu_quota: 150\npids_max: 64\nnofile: 4096\ncore: unlimited\n"), &limits))
+	require.Equal(t, Limits{MemoryMax: 256 * 1024 * 1024, CPUQuota: 150, PidsMax: 64, NoFile: 4096, Core: new(Unlimited)}, limits)
+	require.Equal(t, "memory: 256MB, cpu: 150%, pids: 64, nofile: 4096, core: unlimited", limits.String())
+
+	b, err := yaml.Marshal(limits)
+	require.NoError(t, err)
+	var decoded Limits
+	require.NoError(t, yaml.Unmarshal(b, &decoded))
+	require.True(t, limits.Equal(decoded))
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     Limits
+		hasCgroup  bool
+		hasRlimits bool
+		wantErr    bool
+	}{
+		{name: "empty"},
+		{name: "memory", limits: Limits{MemoryMax: 1024}, hasCgroup: true},
+		{name: "core disabled", limits: Limits{Core: new(LimitSize(0))}, hasRlimits: true},
+		{name: "all", limits: Limits{CPUQuota: 50, NoFile: 1024}, hasCgroup: true, hasRlimits: true},
+		{name: "negative cpu quota", limits: Limits{CPUQuota: -1}, hasCgroup: true, wantErr: true},
+		{name: "negative pids max", limits: Limits{PidsMax: -1}, hasCgroup: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.hasCgroup, tt.limits.HasCgroup())
+			require.Equal(t, tt.hasRlimits, tt.limits.HasRlimits())
+			require.Equal(t, !tt.hasCgroup && !tt.hasRlimits, tt.limits.IsEmpty())
+			if tt.wantErr {
+				require.Error(t, tt.limits.Validate())
+			} else {
+				require.NoError(t, tt.limits.Validate())
+			}
+		})
+	}
+}
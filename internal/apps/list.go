@@ -1,7 +1,6 @@
 package apps
 
 import (
-	"encoding/json"
 	"os"
 	"path"
 	"sort"
@@ -9,10 +8,11 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
-	"github.com/0xB1a60/runapp/internal/common"
 	"github.com/0xB1a60/runapp/internal/util"
 )
 
+// List returns all apps, the newest started first.
+// An app whose config cannot be read (e.g. a corrupt or missing config.json) is skipped instead of failing the listing
 func List() ([]App, error) {
 	homeDir, err := util.HomeDirPath()
 	if err != nil {
@@ -36,20 +36,16 @@ func List() ([]App, error) {
 	for _, f := range files {
 		if f.IsDir() {
 			g.Go(func() error {
-				configContent, err := os.ReadFile(path.Join(homeDir, f.Name(), common.FileConfig))
+				app, err := readConfig(path.Join(homeDir, f.Name()))
 				if err != nil {
-					return err
-				}
-
-				var app App
-				if err := json.Unmarshal(configContent, &app); err != nil {
-					return err
+					util.DebugLog("skipping app %s: %v", f.Name(), err)
+					return nil
 				}
 				app.checkAndCorrectStatus()
 
 				mu.Lock()
-				res = append(res, app)
-				idx[app.Name] = app
+				res = append(res, *app)
+				idx[app.Name] = *app
 				mu.Unlock()
 				return nil
 			})
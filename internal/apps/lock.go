@@ -0,0 +1,137 @@
+package apps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// withLock runs fn while holding an exclusive flock on the config lock of the app,
+// flock is not reentrant across open files so fn must not lock the same app again
+func withLock(appDir string, fn func() error) error {
+	lockFile, err := os.OpenFile(path.Join(appDir, common.FileConfigLock), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer func(lockFile *os.File) {
+		if err := lockFile.Close(); err != nil {
+			util.DebugLog("failed to close config lock: %v", err)
+		}
+	}(lockFile)
+
+	if err := flock(lockFile, unix.LOCK_EX); err != nil {
+		return err
+	}
+	defer func(lockFile *os.File) {
+		if err := flock(lockFile, unix.LOCK_UN); err != nil {
+			util.DebugLog("failed to unlock config: %v", err)
+		}
+	}(lockFile)
+
+	return fn()
+}
+
+func flock(file *os.File, how int) error {
+	for {
+		err := unix.Flock(int(file.Fd()), how)
+		if !errors.Is(err, unix.EINTR) {
+			return err
+		}
+	}
+}
+
+// Update reads the app, applies fn and saves it while holding the config lock,
+// so changes of other runapp processes made in the meantime are not overwritten
+func Update(name string, fn func(app *App) error) (*App, error) {
+	homeDir, err := util.HomeDirPath()
+	if err != nil {
+		return nil, err
+	}
+	appDir := path.Join(homeDir, name)
+	if _, err := os.Stat(path.Join(appDir, common.FileConfig)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var app *App
+	err = withLock(appDir, func() error {
+		app, err = readConfig(appDir)
+		if err != nil {
+			return err
+		}
+		if err := fn(app); err != nil {
+			return err
+		}
+		return writeConfig(appDir, app)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// readConfig parses config.json of the app directory and upgrades it to the current schema version, the status is not corrected
+func readConfig(appDir string) (*App, error) {
+	configContent, err := os.ReadFile(path.Join(appDir, common.FileConfig))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	app, err := decodeApp(configContent)
+	if err != nil {
+		if errors.Is(err, ErrNewerSchema) {
+			return nil, fmt.Errorf("%s: %w", path.Base(appDir), err)
+		}
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, path.Base(appDir), err)
+	}
+	return app, nil
+}
+
+// writeConfig replaces config.json atomically, readers see either the previous or the new config, never a partial one
+func writeConfig(appDir string, app *App) error {
+	app.SchemaVersion = SchemaVersion
+	b, err := json.Marshal(app)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(appDir, common.FileConfig+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// the temporary file is gone after a successful rename
+	defer func(name string) {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			util.DebugLog("failed to remove temporary config: %v", err)
+		}
+	}(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path.Join(appDir, common.FileConfig))
+}
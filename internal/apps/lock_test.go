@@ -0,0 +1,104 @@
+package apps
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/testutil"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+func createApp(t *testing.T, name string) App {
+	t.Helper()
+
+	homeDir, err := util.HomeDirPath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(path.Join(homeDir, name), os.ModePerm))
+
+	app := App{Name: name, Mode: common.RunModeOnce, Status: common.AppStatusSuccess, PID: -1, ConfigPath: path.Join(homeDir, name)}
+	require.NoError(t, app.SaveToFile())
+	return app
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	testutil.SetupHome(t)
+	createApp(t, "counter")
+
+	const updates = 50
+	var wg sync.WaitGroup
+	for range updates {
+		wg.Go(func() {
+			_, err := Update("counter", func(app *App) error {
+				app.RestartCount++
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	app, err := Get("counter")
+	require.NoError(t, err)
+	require.Equal(t, updates, app.RestartCount, "expected no update to be lost")
+
+	leftovers, err := filepath.Glob(path.Join(app.ConfigPath, common.FileConfig+".*"))
+	require.NoError(t, err)
+	require.Empty(t, leftovers, "expected no temporary config to be left behind")
+}
+
+func TestUpdate_NotFound(t *testing.T) {
+	testutil.SetupHome(t)
+
+	_, err := Update("missing", func(app *App) error {
+		return nil
+	})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestList_CorruptEntry(t *testing.T) {
+	testutil.SetupHome(t)
+	createApp(t, "healthy")
+	broken := createApp(t, "broken")
+	require.NoError(t, os.WriteFile(path.Join(broken.ConfigPath, common.FileConfig), []byte(`{"name": "bro`), 0644))
+
+	homeDir, err := util.HomeDirPath()
+	require.NoError(t, err)
+	// a directory without config, e.g. an app that is being created
+	require.NoError(t, os.MkdirAll(path.Join(homeDir, "empty"), os.ModePerm))
+
+	list, err := List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "healthy", list[0].Name)
+
+	_, err = Get("broken")
+	require.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestSaveStatus(t *testing.T) {
+	testutil.SetupHome(t)
+	supervised := createApp(t, "api")
+
+	// runapp apply changes the labels while the supervisor holds the app it read on start
+	_, err := Update("api", func(app *App) error {
+		app.Labels = map[string]string{"tier": "web"}
+		return nil
+	})
+	require.NoError(t, err)
+
+	supervised.Status = common.AppStatusRunning
+	supervised.PID = os.Getpid()
+	require.NoError(t, supervised.SaveStatus())
+
+	app, err := Get("api")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusRunning, app.Status)
+	require.Equal(t, os.Getpid(), app.PID)
+	require.Equal(t, map[string]string{"tier": "web"}, app.Labels, "expected the labels changed meanwhile to be kept")
+}
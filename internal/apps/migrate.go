@@ -0,0 +1,168 @@
+package apps
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// MigrateLegacy moves the apps created before the state directory existed, their config.json is moved to the
+// config directory and their logs and run history to the state directory, returns the names of the migrated apps.
+// The logs of an app are only moved once it is stopped, until then only its config is moved and its logs keep their path.
+// Nothing is migrated with RUNAPP_HOME or a project, both did not exist before
+func MigrateLegacy() ([]string, error) {
+	if len(os.Getenv(util.HomeEnv)) != 0 || len(util.Project()) != 0 {
+		return nil, nil
+	}
+
+	legacyDir, err := util.LegacyDirPath()
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := util.HomeDirPath()
+	if err != nil {
+		return nil, err
+	}
+	stateDir, err := util.StateDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// an app whose config was moved while it was running still has its logs in the legacy directory
+	roots := []string{legacyDir}
+	if homeDir != legacyDir {
+		roots = append(roots, homeDir)
+	}
+
+	var migrated []string
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return migrated, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			configFileDir := path.Join(root, entry.Name())
+			app, err := readConfig(configFileDir)
+			if err != nil {
+				continue
+			}
+			// the logs of an app that was running during the migration stay in the legacy directory
+			if len(app.StatePath) != 0 && (homeDir == legacyDir || !isUnder(app.StatePath, legacyDir)) {
+				continue
+			}
+
+			moved, err := migrateApp(app, configFileDir, path.Join(homeDir, app.Name), path.Join(stateDir, app.Name))
+			if err != nil {
+				return migrated, err
+			}
+			if moved {
+				migrated = append(migrated, app.Name)
+			}
+		}
+	}
+	return migrated, nil
+}
+
+// migrateApp moves the config of the app from configFileDir to configDir and, when it is stopped, its logs to stateDir
+func migrateApp(app *App, configFileDir string, configDir string, stateDir string) (bool, error) {
+	logDir := app.StateDir()
+
+	running := *app
+	running.correctStatus()
+	if running.IsRunning() {
+		if configFileDir == configDir {
+			return false, nil
+		}
+		util.DebugLog("%s is running, its logs are migrated once it is stopped", app.Name)
+		app.StatePath = logDir
+		return true, moveConfig(app, configFileDir, configDir)
+	}
+
+	if err := os.MkdirAll(stateDir, os.ModePerm); err != nil {
+		return false, err
+	}
+
+	entries, err := os.ReadDir(logDir)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	for _, entry := range entries {
+		if isConfigFile(entry.Name()) {
+			continue
+		}
+		if err := os.Rename(path.Join(logDir, entry.Name()), path.Join(stateDir, entry.Name())); err != nil {
+			return false, err
+		}
+	}
+
+	app.StatePath = stateDir
+	app.StdoutPath = rebase(app.StdoutPath, logDir, stateDir)
+	app.StderrPath = rebase(app.StderrPath, logDir, stateDir)
+	app.CombinedPath = rebase(app.CombinedPath, logDir, stateDir)
+	if err := moveConfig(app, configFileDir, configDir); err != nil {
+		return false, err
+	}
+
+	// the legacy directory only has config files left
+	if logDir != configDir {
+		if err := os.RemoveAll(logDir); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// moveConfig writes the config of the app into configDir and removes it from configFileDir
+func moveConfig(app *App, configFileDir string, configDir string) error {
+	if err := os.MkdirAll(configDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	app.ConfigPath = configDir
+	if err := withLock(configDir, func() error {
+		return writeConfig(configDir, app)
+	}); err != nil {
+		return err
+	}
+
+	if configFileDir == configDir {
+		return nil
+	}
+	for _, name := range []string{common.FileConfig, common.FileConfigLock} {
+		if err := os.Remove(path.Join(configFileDir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func isUnder(value string, dir string) bool {
+	rel, err := filepath.Rel(dir, value)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// isConfigFile reports whether the file belongs to the config directory, config.json, its lock and temporary files
+func isConfigFile(name string) bool {
+	return name == common.FileConfig || name == common.FileConfigLock || strings.HasPrefix(name, common.FileConfig+".")
+}
+
+// rebase moves a path inside oldDir to newDir, other paths are kept
+func rebase(value string, oldDir string, newDir string) string {
+	if len(value) == 0 || !isUnder(value, oldDir) {
+		return value
+	}
+	rel, _ := filepath.Rel(oldDir, value)
+	return path.Join(newDir, rel)
+}
@@ -0,0 +1,106 @@
+package apps
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/testutil"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// createLegacyApp creates an app the way it was stored before the state directory existed
+func createLegacyApp(t *testing.T, name string, status common.AppStatus, pid int) string {
+	t.Helper()
+
+	legacyDir, err := util.LegacyDirPath()
+	require.NoError(t, err)
+	dir := path.Join(legacyDir, name)
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+
+	app := App{
+		Name:       name,
+		Mode:       common.RunModeOnce,
+		Status:     status,
+		PID:        pid,
+		ConfigPath: dir,
+		StdoutPath: path.Join(dir, common.FileStdOut),
+		StderrPath: path.Join(dir, common.FileStdErr),
+	}
+	require.NoError(t, writeConfig(dir, &app))
+	require.NoError(t, os.WriteFile(app.StdoutPath, []byte("hello\n"), 0644))
+	require.NoError(t, os.WriteFile(app.StderrPath, nil, 0644))
+	require.NoError(t, WriteHistory(app, []Run{{ID: 1, ExitCode: 0}}))
+	return dir
+}
+
+func TestMigrateLegacy_StoppedApp(t *testing.T) {
+	home := testutil.SetupHome(t)
+	legacyDir := createLegacyApp(t, "web", common.AppStatusSuccess, -1)
+
+	migrated, err := MigrateLegacy()
+	require.NoError(t, err)
+	require.Equal(t, []string{"web"}, migrated)
+
+	stateDir := path.Join(home, ".local", "state", "runapp", "web")
+	app, err := Get("web")
+	require.NoError(t, err)
+	require.Equal(t, legacyDir, app.ConfigPath)
+	require.Equal(t, stateDir, app.StatePath)
+	require.Equal(t, path.Join(stateDir, common.FileStdOut), app.StdoutPath)
+	require.FileExists(t, app.StdoutPath)
+	require.NoFileExists(t, path.Join(legacyDir, common.FileStdOut))
+
+	runs, err := ReadHistory(*app)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+
+	migrated, err = MigrateLegacy()
+	require.NoError(t, err)
+	require.Empty(t, migrated, "expected a migrated app not to be migrated again")
+}
+
+func TestMigrateLegacy_ConfigHome(t *testing.T) {
+	home := testutil.SetupHome(t)
+	legacyDir := createLegacyApp(t, "web", common.AppStatusSuccess, -1)
+	t.Setenv("XDG_CONFIG_HOME", path.Join(home, "config"))
+
+	migrated, err := MigrateLegacy()
+	require.NoError(t, err)
+	require.Equal(t, []string{"web"}, migrated)
+
+	app, err := Get("web")
+	require.NoError(t, err)
+	require.Equal(t, path.Join(home, "config", "runapp", "web"), app.ConfigPath)
+	require.FileExists(t, path.Join(app.ConfigPath, common.FileConfig))
+	require.FileExists(t, app.StdoutPath)
+	require.NoDirExists(t, legacyDir)
+}
+
+func TestMigrateLegacy_RunningApp(t *testing.T) {
+	testutil.SetupHome(t)
+	legacyDir := createLegacyApp(t, "web", common.AppStatusRunning, os.Getpid())
+
+	migrated, err := MigrateLegacy()
+	require.NoError(t, err)
+	require.Empty(t, migrated)
+
+	app, err := Get("web")
+	require.NoError(t, err)
+	require.Empty(t, app.StatePath, "expected a running app to be migrated once it is stopped")
+	require.Equal(t, path.Join(legacyDir, common.FileStdOut), app.StdoutPath)
+	require.FileExists(t, app.StdoutPath)
+}
+
+func TestMigrateLegacy_Project(t *testing.T) {
+	testutil.SetupHome(t)
+	createLegacyApp(t, "web", common.AppStatusSuccess, -1)
+	t.Setenv(util.ProjectEnv, "shop")
+
+	migrated, err := MigrateLegacy()
+	require.NoError(t, err)
+	require.Empty(t, migrated, "expected nothing to be migrated within a project")
+}
@@ -0,0 +1,30 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	NameMinLength = 2
+	NameMaxLength = 100
+)
+
+var nameRegex = regexp.MustCompile("^[a-z0-9_-]+$")
+
+// ValidateName checks that the name can be used as an app name, it is also the name of the app directory
+func ValidateName(value string) error {
+	if len(value) < NameMinLength {
+		return fmt.Errorf("name must be at least %d characters long", NameMinLength)
+	}
+
+	if len(value) > NameMaxLength {
+		return fmt.Errorf("name must be less than %d characters long", NameMaxLength)
+	}
+
+	if !nameRegex.MatchString(value) {
+		return errors.New("name must only contain lowercase, alphanumeric and -_")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+const (
+	DefaultReadinessInterval = 500 * time.Millisecond
+)
+
+// Readiness describes when a started app is ready, every probe that is set must pass.
+// Apps without readiness probes are ready as soon as their process is started
+type Readiness struct {
+	// TCP is the host:port that must accept connections
+	TCP string `json:"tcp" yaml:"tcp"`
+	// HTTP is the URL that must answer GET with 200
+	HTTP string `json:"http" yaml:"http"`
+	// LogPattern is a regular expression that must match a line of stdout or stderr
+	LogPattern string `json:"log_pattern" yaml:"log_pattern"`
+	// File must exist, relative paths are resolved against the app cwd
+	File string `json:"file" yaml:"file"`
+
+	// Interval between the TCP, HTTP and file probes
+	Interval time.Duration `json:"interval" yaml:"interval"`
+}
+
+// WithDefaults fills the unset interval
+func (r Readiness) WithDefaults() Readiness {
+	if r.Interval <= 0 {
+		r.Interval = DefaultReadinessInterval
+	}
+	return r
+}
+
+func (r Readiness) IsEmpty() bool {
+	return len(r.TCP) == 0 && len(r.HTTP) == 0 && len(r.LogPattern) == 0 && len(r.File) == 0
+}
+
+func (r Readiness) Validate() error {
+	if r.IsEmpty() {
+		return errors.New("readiness needs at least one of tcp, http, log_pattern or file")
+	}
+	if len(r.TCP) != 0 {
+		if _, _, err := net.SplitHostPort(r.TCP); err != nil {
+			return fmt.Errorf("readiness tcp must be host:port: %s", r.TCP)
+		}
+	}
+	if len(r.HTTP) != 0 {
+		u, err := url.Parse(r.HTTP)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("readiness http must be an http(s) URL: %s", r.HTTP)
+		}
+	}
+	if len(r.LogPattern) != 0 {
+		if _, err := regexp.Compile(r.LogPattern); err != nil {
+			return fmt.Errorf("readiness log_pattern is not a valid regular expression: %w", err)
+		}
+	}
+	if r.Interval < 0 {
+		return errors.New("readiness interval must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		readiness Readiness
+		wantErr   bool
+	}{
+		{name: "tcp", readiness: Readiness{TCP: "localhost:5432"}},
+		{name: "all probes", readiness: Readiness{TCP: "localhost:80", HTTP: "http://localhost/ready", LogPattern: "listening on \\d+", File: "ready.pid"}},
+		{name: "none", readiness: Readiness{}, wantErr: true},
+		{name: "tcp without port", readiness: Readiness{TCP: "localhost"}, wantErr: true},
+		{name: "http without scheme", readiness: Readiness{HTTP: "localhost/ready"}, wantErr: true},
+		{name: "invalid pattern", readiness: Readiness{LogPattern: "("}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.readiness.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package apps
+
+import (
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+const (
+	DefaultBackoffBase = time.Second
+	DefaultBackoffCap  = time.Minute
+	DefaultResetWindow = 10 * time.Minute
+)
+
+// Restart describes when and how fast the background wrapper restarts an exited app
+type Restart struct {
+	Policy common.RestartPolicy `json:"policy" yaml:"policy"`
+	// MaxRetries is the amount of consecutive restarts, 0 means unlimited
+	MaxRetries  int           `json:"max_retries" yaml:"max_retries"`
+	BackoffBase time.Duration `json:"backoff_base" yaml:"backoff_base"`
+	BackoffCap  time.Duration `json:"backoff_cap" yaml:"backoff_cap"`
+	// ResetWindow once the app stays up for this long, the restart count starts from 0 again
+	ResetWindow time.Duration `json:"reset_window" yaml:"reset_window"`
+}
+
+// ShouldRestart reports whether the policy asks for a restart after the app exited with exitCode
+func (r Restart) ShouldRestart(exitCode int) bool {
+	switch r.Policy {
+	case common.RestartPolicyAlways:
+		return true
+	case common.RestartPolicyOnFailure:
+		return exitCode != 0
+	}
+	return false
+}
+
+// CanRetry reports whether another restart is allowed after restartCount consecutive restarts
+func (r Restart) CanRetry(restartCount int) bool {
+	return r.MaxRetries <= 0 || restartCount < r.MaxRetries
+}
+
+// Backoff returns the delay before the given restart attempt (0 based), doubling the base up to the cap
+func (r Restart) Backoff(attempt int) time.Duration {
+	base := r.BackoffBase
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	limit := r.BackoffCap
+	if limit <= 0 {
+		limit = DefaultBackoffCap
+	}
+
+	delay := base
+	for range attempt {
+		if delay >= limit {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// ResetAfter returns the uptime after which the restart count is reset
+func (r Restart) ResetAfter() time.Duration {
+	if r.ResetWindow <= 0 {
+		return DefaultResetWindow
+	}
+	return r.ResetWindow
+}
+
+// IsStable reports whether a run that lasted for uptime is long enough to reset the restart count
+func (r Restart) IsStable(uptime time.Duration) bool {
+	return uptime >= r.ResetAfter()
+}
@@ -0,0 +1,56 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func TestRestartShouldRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   common.RestartPolicy
+		exitCode int
+		expected bool
+	}{
+		{name: "empty policy", policy: "", exitCode: 1, expected: false},
+		{name: "never on failure", policy: common.RestartPolicyNever, exitCode: 1, expected: false},
+		{name: "on-failure with failure", policy: common.RestartPolicyOnFailure, exitCode: 1, expected: true},
+		{name: "on-failure with success", policy: common.RestartPolicyOnFailure, exitCode: 0, expected: false},
+		{name: "always with success", policy: common.RestartPolicyAlways, exitCode: 0, expected: true},
+		{name: "always with failure", policy: common.RestartPolicyAlways, exitCode: 137, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, Restart{Policy: tt.policy}.ShouldRestart(tt.exitCode))
+		})
+	}
+}
+
+func TestRestartCanRetry(t *testing.T) {
+	require.True(t, Restart{MaxRetries: 0}.CanRetry(1000), "expected unlimited retries when max retries is 0")
+	require.True(t, Restart{MaxRetries: 3}.CanRetry(2))
+	require.False(t, Restart{MaxRetries: 3}.CanRetry(3))
+}
+
+func TestRestartBackoff(t *testing.T) {
+	r := Restart{BackoffBase: time.Second, BackoffCap: 10 * time.Second}
+
+	require.Equal(t, time.Second, r.Backoff(0))
+	require.Equal(t, 2*time.Second, r.Backoff(1))
+	require.Equal(t, 8*time.Second, r.Backoff(3))
+	require.Equal(t, 10*time.Second, r.Backoff(4))
+	require.Equal(t, 10*time.Second, r.Backoff(1000), "expected backoff to be capped without overflowing")
+
+	require.Equal(t, DefaultBackoffBase, Restart{}.Backoff(0), "expected default base when not configured")
+}
+
+func TestRestartIsStable(t *testing.T) {
+	require.False(t, Restart{}.IsStable(time.Minute))
+	require.True(t, Restart{}.IsStable(DefaultResetWindow))
+	require.True(t, Restart{ResetWindow: time.Second}.IsStable(2*time.Second))
+}
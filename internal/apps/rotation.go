@@ -0,0 +1,57 @@
+package apps
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	DefaultLogMaxFiles = 5
+)
+
+// ByteSize is a size in bytes, in YAML it can also be written as 10MB, 512KB...
+type ByteSize int64
+
+func (s *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := util.ParseSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*s = ByteSize(size)
+	return nil
+}
+
+func (s ByteSize) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+func (s ByteSize) String() string {
+	return util.FormatSize(int64(s))
+}
+
+// LogRotation describes when the background wrapper rotates stdout.log/stderr.log
+type LogRotation struct {
+	// MaxSize rotates the log once it would grow over this size, 0 disables size based rotation
+	MaxSize ByteSize `json:"max_size" yaml:"max_size"`
+	// MaxAge rotates the log once it is older than this, 0 disables age based rotation
+	MaxAge time.Duration `json:"max_age" yaml:"max_age"`
+	// MaxFiles is the amount of rotated segments to keep
+	MaxFiles int `json:"max_files" yaml:"max_files"`
+	// Compress gzips the rotated segments
+	Compress bool `json:"compress" yaml:"compress"`
+}
+
+func (r LogRotation) Enabled() bool {
+	return r.MaxSize > 0 || r.MaxAge > 0
+}
+
+// Keep returns the amount of rotated segments to keep
+func (r LogRotation) Keep() int {
+	if r.MaxFiles <= 0 {
+		return DefaultLogMaxFiles
+	}
+	return r.MaxFiles
+}
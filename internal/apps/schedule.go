@@ -0,0 +1,57 @@
+package apps
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/schedule"
+)
+
+// Schedule runs an app with the scheduled mode at the times of a cron expression
+type Schedule struct {
+	// Cron is a 5 field cron expression, a descriptor like @daily or @every 10m
+	Cron string `json:"cron" yaml:"cron"`
+	// Overlap is what happens when a run is due while the previous run is still running, skip by default
+	Overlap common.OverlapPolicy `json:"overlap" yaml:"overlap"`
+}
+
+// WithDefaults fills the unset overlap policy
+func (s Schedule) WithDefaults() Schedule {
+	if len(s.Overlap) == 0 {
+		s.Overlap = common.OverlapSkip
+	}
+	return s
+}
+
+func (s Schedule) Validate() error {
+	if _, err := schedule.Parse(s.Cron); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if len(s.Overlap) != 0 && !slices.Contains(common.ValidOverlapPolicies, s.Overlap) {
+		return fmt.Errorf("unknown overlap policy: %s", s.Overlap)
+	}
+	return nil
+}
+
+// ValidateSchedule checks that the schedule matches the mode, scheduled apps are not restarted since they run again anyway
+func ValidateSchedule(mode common.RunMode, sched *Schedule, restart Restart) error {
+	if mode != common.RunModeScheduled {
+		if sched != nil {
+			return fmt.Errorf("schedule needs the %s mode", common.RunModeScheduled)
+		}
+		return nil
+	}
+
+	if sched == nil {
+		return fmt.Errorf("%s mode needs a schedule", common.RunModeScheduled)
+	}
+	if len(restart.Policy) != 0 && restart.Policy != common.RestartPolicyNever {
+		return fmt.Errorf("scheduled apps cannot have the %s restart policy", restart.Policy)
+	}
+	return sched.Validate()
+}
+
+func (s Schedule) String() string {
+	return fmt.Sprintf("%s (overlap: %s)", s.Cron, s.Overlap)
+}
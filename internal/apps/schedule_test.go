@@ -0,0 +1,41 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	never := Restart{Policy: common.RestartPolicyNever}
+
+	tests := []struct {
+		name     string
+		mode     common.RunMode
+		schedule *Schedule
+		restart  Restart
+		wantErr  bool
+	}{
+		{name: "once", mode: common.RunModeOnce, restart: never},
+		{name: "cron", mode: common.RunModeScheduled, schedule: &Schedule{Cron: "*/5 * * * *"}, restart: never},
+		{name: "every", mode: common.RunModeScheduled, schedule: &Schedule{Cron: "@every 10m", Overlap: common.OverlapQueue}, restart: never},
+		{name: "schedule without scheduled mode", mode: common.RunModeOnBoot, schedule: &Schedule{Cron: "@daily"}, restart: never, wantErr: true},
+		{name: "scheduled mode without schedule", mode: common.RunModeScheduled, restart: never, wantErr: true},
+		{name: "invalid cron", mode: common.RunModeScheduled, schedule: &Schedule{Cron: "* * *"}, restart: never, wantErr: true},
+		{name: "unknown overlap", mode: common.RunModeScheduled, schedule: &Schedule{Cron: "@hourly", Overlap: "maybe"}, restart: never, wantErr: true},
+		{name: "restart policy", mode: common.RunModeScheduled, schedule: &Schedule{Cron: "@hourly"}, restart: Restart{Policy: common.RestartPolicyOnFailure}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchedule(tt.mode, tt.schedule, tt.restart)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// SchemaVersion is the version of config.json written by this version of runapp,
+// every change of a persisted field that older records cannot be read with adds a migration and bumps it
+const SchemaVersion = 1
+
+// ErrNewerSchema is returned for an app whose config.json was written by a newer version of runapp,
+// it is not read because saving it again would drop the fields this version does not know
+var ErrNewerSchema = errors.New("app config was written by a newer version of runapp")
+
+// record is config.json before it is decoded into an App, migrations work on it because older formats may not fit App
+type record map[string]any
+
+// migrations upgrade a record by one version, migrations[i] upgrades version i to i+1
+var migrations = []func(rec record) error{
+	migrateV0,
+}
+
+// migrateV0 upgrades the records written before config.json was versioned, their fields were added over time
+// without a version, the missing ones get the defaults they had when they were added
+func migrateV0(rec record) error {
+	restart := rec.object("restart")
+	restart.setDefault("policy", string(common.RestartPolicyNever))
+	restart.setDefault("backoff_base", int64(DefaultBackoffBase))
+	restart.setDefault("backoff_cap", int64(DefaultBackoffCap))
+	restart.setDefault("reset_window", int64(DefaultResetWindow))
+
+	rec.object("log_rotation").setDefault("max_files", DefaultLogMaxFiles)
+	rec.object("history").setDefault("max_runs", DefaultHistoryMaxRuns)
+
+	// the background process was only started by runapp run
+	rec.setDefault("triggered_by", string(common.TriggerRun))
+	return nil
+}
+
+// object returns the nested object under key, it is created when missing or null
+func (rec record) object(key string) record {
+	if value, ok := rec[key].(map[string]any); ok {
+		return value
+	}
+	value := make(map[string]any)
+	rec[key] = value
+	return value
+}
+
+// setDefault sets key to value when it is missing, null, empty or zero
+func (rec record) setDefault(key string, value any) {
+	switch current := rec[key].(type) {
+	case nil:
+	case string:
+		if len(current) != 0 {
+			return
+		}
+	case json.Number:
+		if current.String() != "0" {
+			return
+		}
+	default:
+		return
+	}
+	rec[key] = value
+}
+
+// version returns the schema version of the record, records without one were written before it existed
+func (rec record) version() (int, error) {
+	value, ok := rec["schema_version"]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schema_version must be a number, got %v", value)
+	}
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("schema_version must be a positive integer, got %s", number)
+	}
+	return int(version), nil
+}
+
+// decodeApp parses config.json, records of older versions are upgraded to SchemaVersion in memory,
+// they are rewritten on the next save or by runapp doctor --migrate
+func decodeApp(content []byte) (*App, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	// numbers stay exact, durations are stored in nanoseconds
+	decoder.UseNumber()
+
+	var rec record
+	if err := decoder.Decode(&rec); err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, errors.New("config is null")
+	}
+
+	version, err := rec.version()
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: version %d, supported up to %d", ErrNewerSchema, version, SchemaVersion)
+	}
+
+	if version < SchemaVersion {
+		for _, migrate := range migrations[version:] {
+			if err := migrate(rec); err != nil {
+				return nil, fmt.Errorf("migrating from version %d: %w", version, err)
+			}
+		}
+		rec["schema_version"] = SchemaVersion
+
+		if content, err = json.Marshal(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	var app App
+	if err := json.Unmarshal(content, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// ConfigCheck is the result of checking the config.json of an app directory
+type ConfigCheck struct {
+	Name string
+	// Version is the schema version of the stored record, 0 for records written before it existed
+	Version int
+	// Err is set when the record cannot be read, e.g. it is corrupt or written by a newer version
+	Err error
+}
+
+// Outdated reports whether the record is readable and written with an older schema version
+func (c ConfigCheck) Outdated() bool {
+	return c.Err == nil && c.Version < SchemaVersion
+}
+
+// CheckConfigs reads the config.json of every app directory without correcting or saving anything
+func CheckConfigs() ([]ConfigCheck, error) {
+	homeDir, err := util.HomeDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(homeDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ConfigCheck{}, nil
+		}
+		return nil, err
+	}
+
+	checks := make([]ConfigCheck, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		checks = append(checks, checkConfig(path.Join(homeDir, entry.Name())))
+	}
+	return checks, nil
+}
+
+func checkConfig(appDir string) ConfigCheck {
+	check := ConfigCheck{Name: path.Base(appDir)}
+
+	content, err := os.ReadFile(path.Join(appDir, common.FileConfig))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("%w: %s is missing", ErrCorrupt, common.FileConfig)
+		}
+		check.Err = err
+		return check
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var rec record
+	if err := decoder.Decode(&rec); err != nil {
+		check.Err = fmt.Errorf("%w: %v", ErrCorrupt, err)
+		return check
+	}
+	if check.Version, err = rec.version(); err != nil {
+		check.Err = fmt.Errorf("%w: %v", ErrCorrupt, err)
+		return check
+	}
+
+	// the whole record is decoded to catch fields that do not fit App
+	if _, err := decodeApp(content); err != nil {
+		if !errors.Is(err, ErrNewerSchema) {
+			err = fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		check.Err = err
+	}
+	return check
+}
+
+// MigrateConfig rewrites the config.json of the app with the current schema version
+func MigrateConfig(name string) error {
+	_, err := Update(name, func(app *App) error {
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,175 @@
+package apps
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/testutil"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+func TestDecodeApp_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		check   func(t *testing.T, app *App)
+	}{
+		{
+			// written before restart policies, log rotation, history and the state directory existed
+			fixture: "v0_baseline.json",
+			check: func(t *testing.T, app *App) {
+				require.Equal(t, "api", app.Name)
+				require.Equal(t, common.RunModeOnce, app.Mode)
+				require.Equal(t, common.AppStatusSuccess, app.Status)
+				require.Equal(t, []string{"PATH=/usr/bin:/bin", "PORT=8080"}, app.Env)
+				require.Equal(t, "/home/user/.config/runapp/api/stdout.log", app.StdoutPath)
+				require.Empty(t, app.StatePath)
+				require.Equal(t, new(0), app.ExitCode)
+
+				require.Equal(t, Restart{
+					Policy:      common.RestartPolicyNever,
+					BackoffBase: DefaultBackoffBase,
+					BackoffCap:  DefaultBackoffCap,
+					ResetWindow: DefaultResetWindow,
+				}, app.Restart)
+				require.Equal(t, DefaultLogMaxFiles, app.LogRotation.MaxFiles)
+				require.Equal(t, DefaultHistoryMaxRuns, app.History.MaxRuns)
+				require.Equal(t, common.TriggerRun, app.TriggeredBy)
+			},
+		},
+		{
+			// written by the last version without a schema version, the stored values are kept
+			fixture: "v0_unversioned.json",
+			check: func(t *testing.T, app *App) {
+				require.Equal(t, "/home/user/.local/state/runapp/api", app.StatePath)
+				require.Equal(t, map[string]string{"tier": "web"}, app.Labels)
+				require.Equal(t, Restart{
+					Policy:      common.RestartPolicyOnFailure,
+					MaxRetries:  3,
+					BackoffBase: 2 * time.Second,
+					BackoffCap:  30 * time.Second,
+					ResetWindow: DefaultResetWindow,
+				}, app.Restart)
+				require.Equal(t, LogRotation{MaxSize: 10 * 1024 * 1024, MaxFiles: 3, Compress: true}, app.LogRotation)
+				require.Equal(t, History{MaxRuns: 50, ArchiveLogs: true}, app.History)
+				require.Equal(t, common.TriggerRestart, app.TriggeredBy)
+				require.Equal(t, 4240, app.WrapperPID)
+			},
+		},
+		{
+			fixture: "v1.json",
+			check: func(t *testing.T, app *App) {
+				require.Equal(t, common.RestartPolicyOnFailure, app.Restart.Policy)
+				require.Equal(t, 2*time.Second, app.Restart.BackoffBase)
+				require.Equal(t, 3, app.LogRotation.MaxFiles)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			content, err := os.ReadFile(path.Join("testdata", "schema", tt.fixture))
+			require.NoError(t, err)
+
+			app, err := decodeApp(content)
+			require.NoError(t, err)
+			require.Equal(t, SchemaVersion, app.SchemaVersion)
+			tt.check(t, app)
+
+			// a migrated record is stable, decoding what would be saved gives the same app
+			b, err := json.Marshal(app)
+			require.NoError(t, err)
+			again, err := decodeApp(b)
+			require.NoError(t, err)
+			require.Equal(t, app, again)
+		})
+	}
+}
+
+func TestDecodeApp_Invalid(t *testing.T) {
+	newer, err := os.ReadFile(path.Join("testdata", "schema", "newer.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		content     []byte
+		expectedErr error
+	}{
+		{name: "newer version", content: newer, expectedErr: ErrNewerSchema},
+		{name: "null", content: []byte("null")},
+		{name: "not an object", content: []byte(`["api"]`)},
+		{name: "invalid version", content: []byte(`{"schema_version":"1","name":"api"}`)},
+		{name: "negative version", content: []byte(`{"schema_version":-1,"name":"api"}`)},
+		{name: "invalid field", content: []byte(`{"schema_version":1,"name":"api","pid":"42"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeApp(tt.content)
+			require.Error(t, err)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func writeFixture(t *testing.T, name string, fixture string) {
+	t.Helper()
+
+	homeDir, err := util.HomeDirPath()
+	require.NoError(t, err)
+	appDir := path.Join(homeDir, name)
+	require.NoError(t, os.MkdirAll(appDir, os.ModePerm))
+
+	content, err := os.ReadFile(path.Join("testdata", "schema", fixture))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path.Join(appDir, common.FileConfig), content, 0644))
+}
+
+func TestCheckConfigs(t *testing.T) {
+	testutil.SetupHome(t)
+	writeFixture(t, "api", "v0_baseline.json")
+	writeFixture(t, "current", "v1.json")
+	writeFixture(t, "newer", "newer.json")
+
+	homeDir, err := util.HomeDirPath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(path.Join(homeDir, "broken"), os.ModePerm))
+	require.NoError(t, os.WriteFile(path.Join(homeDir, "broken", common.FileConfig), []byte("{"), 0644))
+
+	checks, err := CheckConfigs()
+	require.NoError(t, err)
+	require.Len(t, checks, 4)
+
+	byName := make(map[string]ConfigCheck, len(checks))
+	for _, check := range checks {
+		byName[check.Name] = check
+	}
+	require.True(t, byName["api"].Outdated())
+	require.Equal(t, 0, byName["api"].Version)
+	require.False(t, byName["current"].Outdated())
+	require.NoError(t, byName["current"].Err)
+	require.ErrorIs(t, byName["newer"].Err, ErrNewerSchema)
+	require.ErrorIs(t, byName["broken"].Err, ErrCorrupt)
+
+	// the fixture is named api, the record is rewritten in place
+	require.NoError(t, MigrateConfig("api"))
+	check := checkConfig(path.Join(homeDir, "api"))
+	require.NoError(t, check.Err)
+	require.Equal(t, SchemaVersion, check.Version)
+
+	app, err := Get("api")
+	require.NoError(t, err)
+	require.Equal(t, common.RestartPolicyNever, app.Restart.Policy)
+
+	// a newer record is never rewritten
+	require.Error(t, MigrateConfig("newer"))
+	check = checkConfig(path.Join(homeDir, "newer"))
+	require.Equal(t, 99, check.Version)
+}
@@ -0,0 +1,75 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+// ValidateTemplate fills in the defaults of an app created with runapp run or the APIs and validates it,
+// the mode defaults to once (scheduled with a schedule) and the restart policy to never
+func (app *App) ValidateTemplate() error {
+	if err := ValidateName(app.Name); err != nil {
+		return err
+	}
+	if len(app.Command) == 0 {
+		return errors.New("command is required")
+	}
+
+	if len(app.Mode) == 0 {
+		app.Mode = common.RunModeOnce
+		if app.Schedule != nil {
+			app.Mode = common.RunModeScheduled
+		}
+	}
+	if _, ok := common.PrettyRunMode[app.Mode]; !ok {
+		return fmt.Errorf("unknown mode: %s", app.Mode)
+	}
+	if len(app.Restart.Policy) == 0 {
+		app.Restart.Policy = common.RestartPolicyNever
+	}
+	if !slices.Contains(common.ValidRestartPolicies, app.Restart.Policy) {
+		return fmt.Errorf("restart must be %s, %s or %s", common.RestartPolicyNever, common.RestartPolicyOnFailure, common.RestartPolicyAlways)
+	}
+
+	for key, value := range app.Labels {
+		if err := ValidateLabel(key, value); err != nil {
+			return err
+		}
+	}
+
+	if app.Readiness != nil {
+		if err := app.Readiness.Validate(); err != nil {
+			return err
+		}
+		app.Readiness = new(app.Readiness.WithDefaults())
+	}
+	if app.HealthCheck != nil {
+		if err := app.HealthCheck.Validate(); err != nil {
+			return err
+		}
+		app.HealthCheck = new(app.HealthCheck.WithDefaults())
+	}
+
+	if err := ValidateSchedule(app.Mode, app.Schedule, app.Restart); err != nil {
+		return err
+	}
+	if app.Schedule != nil {
+		app.Schedule = new(app.Schedule.WithDefaults())
+	}
+	if err := ValidateWatch(app.Watch, app.Schedule); err != nil {
+		return err
+	}
+	if app.Watch != nil {
+		app.Watch = new(app.Watch.WithDefaults())
+	}
+
+	if app.Limits != nil {
+		if err := app.Limits.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	app := App{
+		Name:      "api",
+		Command:   "./api",
+		Readiness: &Readiness{TCP: "localhost:8080"},
+		Watch:     &Watch{Patterns: []string{"**/*.go"}},
+	}
+	require.NoError(t, app.ValidateTemplate())
+	require.Equal(t, common.RunModeOnce, app.Mode)
+	require.Equal(t, common.RestartPolicyNever, app.Restart.Policy)
+	require.Equal(t, DefaultWatchDebounce, app.Watch.Debounce)
+	require.Equal(t, DefaultReadinessInterval, app.Readiness.Interval)
+
+	scheduled := App{Name: "backup", Command: "./backup", Schedule: &Schedule{Cron: "@daily"}}
+	require.NoError(t, scheduled.ValidateTemplate())
+	require.Equal(t, common.RunModeScheduled, scheduled.Mode)
+	require.Equal(t, common.OverlapSkip, scheduled.Schedule.Overlap)
+
+	tests := []struct {
+		name     string
+		app      App
+		expected string
+	}{
+		{name: "name", app: App{Name: "a", Command: "./api"}, expected: "name must be at least"},
+		{name: "command", app: App{Name: "api"}, expected: "command is required"},
+		{name: "mode", app: App{Name: "api", Command: "./api", Mode: "sometimes"}, expected: "unknown mode: sometimes"},
+		{name: "restart policy", app: App{Name: "api", Command: "./api", Restart: Restart{Policy: "maybe"}}, expected: "restart must be"},
+		{name: "label", app: App{Name: "api", Command: "./api", Labels: map[string]string{"bad key": "1"}}, expected: "label"},
+		{name: "readiness", app: App{Name: "api", Command: "./api", Readiness: &Readiness{}}, expected: "readiness needs at least one"},
+		{name: "health check", app: App{Name: "api", Command: "./api", HealthCheck: &HealthCheck{}}, expected: "health check needs exactly one"},
+		{name: "schedule without mode", app: App{Name: "api", Command: "./api", Mode: common.RunModeOnce, Schedule: &Schedule{Cron: "@daily"}}, expected: "schedule needs the scheduled mode"},
+		{name: "watch with schedule", app: App{Name: "api", Command: "./api", Schedule: &Schedule{Cron: "@daily"}, Watch: &Watch{Patterns: []string{"*.go"}}}, expected: "watch cannot be used with a schedule"},
+		{name: "limits", app: App{Name: "api", Command: "./api", Limits: &Limits{CPUQuota: -1}}, expected: "cpu quota must not be negative"},
+		{name: "restart with schedule", app: App{Name: "api", Command: "./api", Schedule: &Schedule{Cron: "@daily"}, Restart: Restart{Policy: common.RestartPolicyAlways}}, expected: "scheduled apps cannot have the always restart policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.app.ValidateTemplate()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
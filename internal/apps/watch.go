@@ -0,0 +1,128 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWatchDebounce = 500 * time.Millisecond
+)
+
+// Watch restarts the app when files under its cwd change
+type Watch struct {
+	// Patterns are globs relative to the cwd, ** matches any amount of directories (e.g. src/**/*.go)
+	Patterns []string `json:"patterns" yaml:"patterns"`
+	// Ignore are globs of files and directories that never restart the app (e.g. vendor/**)
+	Ignore []string `json:"ignore" yaml:"ignore"`
+	// Debounce is the time without changes after which the app is restarted, a burst of changes restarts it once
+	Debounce time.Duration `json:"debounce" yaml:"debounce"`
+}
+
+// WithDefaults fills the unset debounce
+func (w Watch) WithDefaults() Watch {
+	if w.Debounce <= 0 {
+		w.Debounce = DefaultWatchDebounce
+	}
+	return w
+}
+
+func (w Watch) Validate() error {
+	if len(w.Patterns) == 0 {
+		return errors.New("watch needs at least one pattern")
+	}
+	for _, pattern := range slices.Concat(w.Patterns, w.Ignore) {
+		if err := validateGlob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateWatch checks that the watch can be used with the app, scheduled apps are started by their schedule only
+func ValidateWatch(watch *Watch, sched *Schedule) error {
+	if watch == nil {
+		return nil
+	}
+	if sched != nil {
+		return errors.New("watch cannot be used with a schedule")
+	}
+	return watch.Validate()
+}
+
+// Matches reports whether a change of the file (relative to the cwd) restarts the app
+func (w Watch) Matches(name string) bool {
+	return matchAny(w.Patterns, name) && !w.Ignored(name)
+}
+
+// Ignored reports whether the file or directory (relative to the cwd) matches an ignore pattern
+func (w Watch) Ignored(name string) bool {
+	return matchAny(w.Ignore, name)
+}
+
+func (w Watch) Equal(other Watch) bool {
+	return slices.Equal(w.Patterns, other.Patterns) && slices.Equal(w.Ignore, other.Ignore) && w.Debounce == other.Debounce
+}
+
+func (w Watch) String() string {
+	res := strings.Join(w.Patterns, ", ")
+	if len(w.Ignore) != 0 {
+		res += " (ignore: " + strings.Join(w.Ignore, ", ") + ")"
+	}
+	return fmt.Sprintf("%s, debounce: %s", res, w.Debounce)
+}
+
+func validateGlob(pattern string) error {
+	if len(pattern) == 0 {
+		return errors.New("watch pattern must not be empty")
+	}
+	if path.IsAbs(pattern) {
+		return fmt.Errorf("watch pattern must be relative to the cwd: %s", pattern)
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == ".." {
+			return fmt.Errorf("watch pattern must not leave the cwd: %s", pattern)
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid watch pattern: %s", pattern)
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return MatchGlob(pattern, name)
+	})
+}
+
+// MatchGlob matches a slash separated name against a glob, ** matches zero or more directories
+func MatchGlob(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) != 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,75 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "*.go", name: "main.go", expected: true},
+		{pattern: "*.go", name: "cmd/main.go", expected: false},
+		{pattern: "src/**/*.go", name: "src/main.go", expected: true},
+		{pattern: "src/**/*.go", name: "src/a/b/main.go", expected: true},
+		{pattern: "src/**/*.go", name: "src/a/main.txt", expected: false},
+		{pattern: "src/**/*.go", name: "lib/main.go", expected: false},
+		{pattern: "**/*.go", name: "main.go", expected: true},
+		{pattern: "vendor/**", name: "vendor", expected: true},
+		{pattern: "vendor/**", name: "vendor/a/b.go", expected: true},
+		{pattern: "vendor/**", name: "vendored/b.go", expected: false},
+		{pattern: "**", name: "a/b/c", expected: true},
+		{pattern: "config.yaml", name: "config.yaml", expected: true},
+		{pattern: "*_test.go", name: "main_test.go", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, MatchGlob(tt.pattern, tt.name))
+		})
+	}
+}
+
+func TestWatchMatches(t *testing.T) {
+	watch := Watch{Patterns: []string{"**/*.go"}, Ignore: []string{"vendor/**", "**/*_test.go"}}
+
+	require.True(t, watch.Matches("main.go"))
+	require.True(t, watch.Matches("internal/app.go"))
+	require.False(t, watch.Matches("vendor/lib/lib.go"))
+	require.False(t, watch.Matches("internal/app_test.go"))
+	require.False(t, watch.Matches("README.md"))
+	require.True(t, watch.Ignored("vendor"))
+}
+
+func TestValidateWatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		watch    *Watch
+		schedule *Schedule
+		wantErr  bool
+	}{
+		{name: "without watch"},
+		{name: "patterns", watch: &Watch{Patterns: []string{"src/**/*.go"}, Ignore: []string{"vendor/**"}}},
+		{name: "no patterns", watch: &Watch{Ignore: []string{"vendor/**"}}, wantErr: true},
+		{name: "absolute pattern", watch: &Watch{Patterns: []string{"/src/*.go"}}, wantErr: true},
+		{name: "pattern leaving the cwd", watch: &Watch{Patterns: []string{"../*.go"}}, wantErr: true},
+		{name: "invalid pattern", watch: &Watch{Patterns: []string{"src/[a-"}}, wantErr: true},
+		{name: "invalid ignore", watch: &Watch{Patterns: []string{"*.go"}, Ignore: []string{""}}, wantErr: true},
+		{name: "with schedule", watch: &Watch{Patterns: []string{"*.go"}}, schedule: &Schedule{Cron: "@daily"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWatch(tt.watch, tt.schedule)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
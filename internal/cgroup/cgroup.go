@@ -0,0 +1,222 @@
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	// cpuPeriod is the period of cpu.max in microseconds, the quota is the share of it the app may run
+	cpuPeriod = 100000
+	// supervisorGroup is the leaf cgroup runapp moves itself into,
+	// a cgroup with processes cannot enable controllers for its children
+	supervisorGroup = "runapp.supervisor"
+)
+
+var (
+	// mountPoint and procSelfCgroup are variables so the tests can use a fake hierarchy
+	mountPoint     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+
+	// setupMu serializes moving runapp into its leaf cgroup, the daemon creates the cgroups of its apps concurrently
+	setupMu sync.Mutex
+)
+
+// ErrUnavailable is returned when runapp does not run in a cgroup v2 hierarchy
+var ErrUnavailable = errors.New("cgroup v2 is not available")
+
+// Group is the cgroup of a run of an app, the app process is started in it with SysProcAttr.CgroupFD
+type Group struct {
+	path string
+	dir  *os.File
+	// oomKills is the count of memory.events before the run, the cgroup is reused when it could not be removed
+	oomKills int
+}
+
+// Create creates (or reuses) the cgroup of the app next to the cgroup of runapp and writes its limits
+func Create(name string, limits apps.Limits) (*Group, error) {
+	parent, err := delegatedRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	controllers := controllersFor(limits)
+	if err := enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+
+	groupPath := path.Join(parent, groupName(name))
+	if err := os.Mkdir(groupPath, 0755); err != nil && !errors.Is(err, os.ErrExist) {
+		return nil, err
+	}
+
+	settings := []struct {
+		file  string
+		value string
+		set   bool
+	}{
+		{"memory.max", strconv.FormatInt(int64(limits.MemoryMax), 10), limits.MemoryMax != 0},
+		{"cpu.max", fmt.Sprintf("%d %d", limits.CPUQuota*cpuPeriod/100, cpuPeriod), limits.CPUQuota != 0},
+		{"pids.max", strconv.Itoa(limits.PidsMax), limits.PidsMax != 0},
+	}
+	for _, setting := range settings {
+		filePath := path.Join(groupPath, setting.file)
+		if !setting.set {
+			// a reused cgroup may still have the limit of a previous run
+			if util.FileExists(filePath) {
+				if err := os.WriteFile(filePath, []byte("max"), 0644); err != nil {
+					return nil, fmt.Errorf("failed to reset %s: %w", setting.file, err)
+				}
+			}
+			continue
+		}
+		if err := os.WriteFile(filePath, []byte(setting.value), 0644); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", setting.file, err)
+		}
+	}
+
+	dir, err := os.Open(groupPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Group{path: groupPath, dir: dir, oomKills: readOOMKills(groupPath)}, nil
+}
+
+// FD is the file descriptor of the cgroup directory for SysProcAttr.CgroupFD
+func (g *Group) FD() int {
+	return int(g.dir.Fd())
+}
+
+// OOMKilled reports whether the kernel killed a process of the cgroup since it was created for the run
+func (g *Group) OOMKilled() bool {
+	return readOOMKills(g.path) > g.oomKills
+}
+
+// Close removes the cgroup, it stays while descendants of the app are still running in it
+func (g *Group) Close() {
+	if err := g.dir.Close(); err != nil {
+		util.DebugLog("failed to close cgroup %s: %v", g.path, err)
+	}
+	if err := os.Remove(g.path); err != nil {
+		util.DebugLog("failed to remove cgroup %s: %v", g.path, err)
+	}
+}
+
+// delegatedRoot returns the cgroup that contains the cgroup of runapp, the one the apps are created in
+func delegatedRoot() (string, error) {
+	if !util.FileExists(path.Join(mountPoint, "cgroup.controllers")) {
+		return "", ErrUnavailable
+	}
+
+	setupMu.Lock()
+	defer setupMu.Unlock()
+
+	own, err := ownGroup()
+	if err != nil {
+		return "", err
+	}
+	dir := path.Join(mountPoint, own)
+	if path.Base(dir) == supervisorGroup {
+		return path.Dir(dir), nil
+	}
+
+	leaf := path.Join(dir, supervisorGroup)
+	if err := os.Mkdir(leaf, 0755); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("cgroup %s is not delegated to runapp: %w", dir, err)
+	}
+	if err := os.WriteFile(path.Join(leaf, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return "", fmt.Errorf("failed to move runapp into %s: %w", leaf, err)
+	}
+	return dir, nil
+}
+
+// ownGroup returns the cgroup of runapp relative to the mount point, e.g. /user.slice/user@1000.service/app.slice
+func ownGroup() (string, error) {
+	b, err := os.ReadFile(procSelfCgroup)
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.Lines(string(b)) {
+		// the cgroup v2 hierarchy has the id 0 and no controllers
+		if group, ok := strings.CutPrefix(strings.TrimSpace(line), "0::"); ok {
+			return group, nil
+		}
+	}
+	return "", ErrUnavailable
+}
+
+// enableControllers enables the controllers for the children of the cgroup, they must be delegated to it
+func enableControllers(dir string, controllers []string) error {
+	available, err := os.ReadFile(path.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return err
+	}
+	enabled, err := os.ReadFile(path.Join(dir, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, controller := range controllers {
+		if !slices.Contains(strings.Fields(string(available)), controller) {
+			return fmt.Errorf("the %s controller is not delegated to cgroup %s", controller, dir)
+		}
+		if !slices.Contains(strings.Fields(string(enabled)), controller) {
+			missing = append(missing, "+"+controller)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(path.Join(dir, "cgroup.subtree_control"), []byte(strings.Join(missing, " ")), 0644); err != nil {
+		return fmt.Errorf("failed to enable %s in cgroup %s: %w", strings.Join(missing, " "), dir, err)
+	}
+	return nil
+}
+
+// controllersFor returns the controllers the limits need
+func controllersFor(limits apps.Limits) []string {
+	var controllers []string
+	if limits.CPUQuota != 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if limits.MemoryMax != 0 {
+		controllers = append(controllers, "memory")
+	}
+	if limits.PidsMax != 0 {
+		controllers = append(controllers, "pids")
+	}
+	return controllers
+}
+
+// groupName returns the name of the cgroup of the app, apps of a project are prefixed with the project
+func groupName(name string) string {
+	if project := util.Project(); len(project) != 0 {
+		return "runapp-" + project + "." + name
+	}
+	return "runapp-" + name
+}
+
+// readOOMKills returns the oom_kill count of memory.events, 0 when the memory controller is not enabled
+func readOOMKills(groupPath string) int {
+	b, err := os.ReadFile(path.Join(groupPath, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for line := range strings.Lines(string(b)) {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "oom_kill "); ok {
+			count, _ := strconv.Atoi(value)
+			return count
+		}
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package cgroup
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// setupHierarchy creates a fake cgroup v2 hierarchy with runapp in /user.slice/runapp.service
+func setupHierarchy(t *testing.T, delegated string) string {
+	t.Helper()
+	t.Setenv(util.ProjectEnv, "")
+
+	root := t.TempDir()
+	own := path.Join(root, "user.slice", "runapp.service")
+	require.NoError(t, os.MkdirAll(own, os.ModePerm))
+	require.NoError(t, os.WriteFile(path.Join(root, "cgroup.controllers"), []byte("cpuset cpu io memory pids\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(own, "cgroup.controllers"), []byte(delegated+"\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(own, "cgroup.subtree_control"), nil, 0644))
+
+	procFile := path.Join(t.TempDir(), "cgroup")
+	require.NoError(t, os.WriteFile(procFile, []byte("0::/user.slice/runapp.service\n"), 0644))
+
+	previousMount, previousProc := mountPoint, procSelfCgroup
+	mountPoint, procSelfCgroup = root, procFile
+	t.Cleanup(func() {
+		mountPoint, procSelfCgroup = previousMount, previousProc
+	})
+	return own
+}
+
+func TestCreate(t *testing.T) {
+	own := setupHierarchy(t, "cpu memory pids")
+
+	group, err := Create("api", apps.Limits{MemoryMax: 512 * 1024 * 1024, CPUQuota: 50, PidsMax: 64})
+	require.NoError(t, err)
+
+	// runapp moves itself out of the cgroup whose controllers it enables for the apps
+	procs, err := os.ReadFile(path.Join(own, supervisorGroup, "cgroup.procs"))
+	require.NoError(t, err)
+	require.Equal(t, strconv.Itoa(os.Getpid()), string(procs))
+
+	subtreeControl, err := os.ReadFile(path.Join(own, "cgroup.subtree_control"))
+	require.NoError(t, err)
+	require.Equal(t, "+cpu +memory +pids", string(subtreeControl))
+
+	groupPath := path.Join(own, "runapp-api")
+	for file, expected := range map[string]string{
+		"memory.max": "536870912",
+		"cpu.max":    "50000 100000",
+		"pids.max":   "64",
+	} {
+		content, err := os.ReadFile(path.Join(groupPath, file))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(content), file)
+	}
+
+	require.False(t, group.OOMKilled())
+	require.NoError(t, os.WriteFile(path.Join(groupPath, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n"), 0644))
+	require.True(t, group.OOMKilled())
+}
+
+func TestCreate_Reused(t *testing.T) {
+	own := setupHierarchy(t, "cpu memory pids")
+	groupPath := path.Join(own, "runapp-api")
+
+	// the cgroup of a previous run was left with its limits and OOM kill
+	require.NoError(t, os.MkdirAll(groupPath, os.ModePerm))
+	require.NoError(t, os.WriteFile(path.Join(groupPath, "pids.max"), []byte("64"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(groupPath, "memory.events"), []byte("oom_kill 2\n"), 0644))
+
+	group, err := Create("api", apps.Limits{MemoryMax: 1024 * 1024})
+	require.NoError(t, err)
+	require.False(t, group.OOMKilled())
+
+	pidsMax, err := os.ReadFile(path.Join(groupPath, "pids.max"))
+	require.NoError(t, err)
+	require.Equal(t, "max", string(pidsMax))
+}
+
+func TestCreate_NotDelegated(t *testing.T) {
+	setupHierarchy(t, "pids")
+
+	_, err := Create("api", apps.Limits{MemoryMax: 1024 * 1024})
+	require.ErrorContains(t, err, "the memory controller is not delegated")
+}
+
+func TestCreate_Unavailable(t *testing.T) {
+	setupHierarchy(t, "cpu memory pids")
+	require.NoError(t, os.Remove(path.Join(mountPoint, "cgroup.controllers")))
+
+	_, err := Create("api", apps.Limits{PidsMax: 10})
+	require.ErrorIs(t, err, ErrUnavailable)
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/manifest"
+	"github.com/0xB1a60/runapp/internal/runner"
+)
+
+func buildApplyCmd() *cobra.Command {
+	var file string
+	var prune bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:          "apply",
+		SilenceUsage: true,
+		Short:        "Create, restart or prune apps to match a manifest file",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			m, err := manifest.Load(file)
+			if err != nil {
+				return err
+			}
+
+			for _, spec := range m.Apps {
+				if err := nameValidateFunc(spec.Name); err != nil {
+					return fmt.Errorf("app: %s: %w", spec.Name, err)
+				}
+			}
+
+			list, err := apps.List()
+			if err != nil {
+				return err
+			}
+
+			changes := manifest.Plan(m, list, prune)
+
+			hasChanges := false
+			for _, change := range changes {
+				fmt.Println(formatChange(change))
+				if change.Action != manifest.ActionUnchanged {
+					hasChanges = true
+				}
+			}
+
+			if !hasChanges {
+				fmt.Println("🤖 Nothing to apply, all apps are up to date")
+				return nil
+			}
+
+			if dryRun {
+				return nil
+			}
+
+			for _, change := range changes {
+				if err := applyChange(cmd.Context(), change); err != nil {
+					return fmt.Errorf("failed to %s app: %s: %w", change.Action, change.Name, err)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", manifest.DefaultFile, "path to the manifest file")
+	cmd.Flags().BoolVar(&prune, "prune", false, "remove apps that are not declared in the manifest")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print what would change")
+	return cmd
+}
+
+func applyChange(ctx context.Context, change manifest.Change) error {
+	switch change.Action {
+	case manifest.ActionCreate:
+		return createAndRunApp(ctx, change.Spec.ToApp(os.Environ()), startOptions{skipLogs: true})
+	case manifest.ActionRestart:
+		if err := stopIfExists(ctx, change.Name); err != nil {
+			return err
+		}
+		return createAndRunApp(ctx, change.Spec.ToApp(os.Environ()), startOptions{skipLogs: true})
+	case manifest.ActionUpdate:
+		app, err := apps.Update(change.Name, func(stored *apps.App) error {
+			stored.Labels = change.Spec.Labels
+			stored.DependsOn = change.Spec.DependsOn
+			stored.History = change.Spec.History
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(tml.Sprintf("<green>app: %s updated</green>", app.Name))
+	case manifest.ActionPrune:
+		if err := stopIfExists(ctx, change.Name); err != nil {
+			return err
+		}
+		app, err := apps.Get(change.Name)
+		if err != nil {
+			return err
+		}
+		if err := runner.Remove(ctx, app); err != nil {
+			return err
+		}
+		fmt.Println(tml.Sprintf("<green>app: %s removed</green>", app.Name))
+	}
+	return nil
+}
+
+// stopIfExists kills the app if it exists and is running
+func stopIfExists(ctx context.Context, name string) error {
+	app, err := apps.Get(name)
+	if err != nil {
+		if errors.Is(err, apps.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if app.IsRunning() {
+		if err := killApp(ctx, app); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatChange(change manifest.Change) string {
+	switch change.Action {
+	case manifest.ActionCreate:
+		return tml.Sprintf("<green>+ %s will be created</green>", change.Name)
+	case manifest.ActionRestart:
+		return tml.Sprintf("<yellow>~ %s will be restarted (%s)</yellow>", change.Name, change.Reason)
+	case manifest.ActionUpdate:
+		return tml.Sprintf("<yellow>~ %s will be updated (%s)</yellow>", change.Name, change.Reason)
+	case manifest.ActionPrune:
+		return tml.Sprintf("<red>- %s will be pruned</red>", change.Name)
+	}
+	return fmt.Sprintf("  %s is up to date", change.Name)
+}
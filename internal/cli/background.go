@@ -1,20 +1,13 @@
 package cli
 
 import (
-	"errors"
-	"fmt"
-	"os"
-	"os/exec"
 	"os/signal"
-	"sync/atomic"
 	"syscall"
-	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/0xB1a60/runapp/internal/apps"
-	"github.com/0xB1a60/runapp/internal/common"
-	"github.com/0xB1a60/runapp/internal/util"
+	"github.com/0xB1a60/runapp/internal/supervisor"
 )
 
 // Go does not natively support fork so we let's get creative
@@ -37,153 +30,12 @@ func buildBackgroundCmd() *cobra.Command {
 				return err
 			}
 
-			stdoutFile, err := os.Create(app.StdoutPath)
-			if err != nil {
-				writeStdErr(app.StderrPath, err)
-				return err
-			}
-			defer func(stdoutFile *os.File) {
-				if err := stdoutFile.Close(); err != nil {
-					fmt.Println("failed to close stderr", err)
-				}
-			}(stdoutFile)
-
-			stderrFile, err := os.Create(app.StderrPath)
-			if err != nil {
-				writeStdErr(app.StderrPath, err)
-				return err
-			}
-			defer func(stderrFile *os.File) {
-				if err := stderrFile.Close(); err != nil {
-					fmt.Println("failed to close stderr", err)
-				}
-			}(stderrFile)
-
-			cmdArgs := append(util.GetShellArgs(), app.Command)
-
-			cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-			cmd.Env = app.Env
-			cmd.Dir = app.CWD
-			cmd.Stdout = stdoutFile
-			cmd.Stderr = stderrFile
-
-			if err := cmd.Start(); err != nil {
-				app.ExitCode = new(255)
-				app.Status = common.AppStatusFailed
-				app.FinishedAt = new(time.Now())
-
-				if err := app.SaveToFile(); err != nil {
-					writeStdErr(app.StderrPath, err)
-					return err
-				}
-
-				writeStdErr(app.StderrPath, err)
-				return err
-			}
-
-			// only now do we know the real PID of the spawned process —
-			// persist that instead of this wrapper's own PID
-			app.Status = common.AppStatusRunning
-			app.PID = cmd.Process.Pid
-			app.ExitCode = nil
-			app.StartedAt = new(time.Now())
-
-			if err := app.SaveToFile(); err != nil {
-				fmt.Println("error saving cfg", err)
-				writeStdErr(app.StderrPath, err)
-				return err
-			}
-
-			done := make(chan error, 1)
-
-			sig := make(chan os.Signal, 1)
-			signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
-
-			var killed atomic.Bool
-			go func() {
-				if err := cmd.Wait(); err != nil {
-					var exitError *exec.ExitError
-					if errors.As(err, &exitError) {
-						if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-							if !killed.Load() {
-								app.ExitCode = new(status.ExitStatus())
-								app.Status = common.AppStatusFailed
-								app.FinishedAt = new(time.Now())
-
-								if err := app.SaveToFile(); err != nil {
-									writeStdErr(app.StderrPath, err)
-									done <- err
-								}
-							}
-						}
-					}
-
-					writeStdErr(app.StderrPath, err)
-					if killed.Load() {
-						setKilledStatus(app)
-					}
-					done <- err
-					return
-				}
-
-				app.ExitCode = new(0)
-				app.Status = common.AppStatusSuccess
-				app.FinishedAt = new(time.Now())
-
-				if err := app.SaveToFile(); err != nil {
-					writeStdErr(app.StderrPath, err)
-				}
-				done <- nil
-			}()
-
-			ctx := cobraCmd.Context()
-
-			for {
-				select {
-				case s := <-sig:
-					util.DebugLog("signal received: %s", s.String())
-					killed.Store(true)
-					if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
-						fmt.Println("failed to send SIGTERM", err)
-					}
-				case <-done:
-					return nil
-				case <-ctx.Done():
-					killed.Store(true)
-					if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
-						fmt.Println("failed to send SIGTERM", err)
-					}
-				}
-			}
+			// killing the wrapper stops the app without applying its restart policy
+			ctx, stop := signal.NotifyContext(cobraCmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
 
+			return supervisor.Supervise(ctx, app, nil)
 		},
 	}
 	return cmd
 }
-
-func writeStdErr(path string, err error) {
-	stderrFile, stdErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if stdErr != nil {
-		fmt.Println("failed to open stderr", stdErr)
-		return
-	}
-	defer func(stderrFile *os.File) {
-		if err := stderrFile.Close(); err != nil {
-			fmt.Println("failed to close stderr", err)
-		}
-	}(stderrFile)
-
-	if _, err := stderrFile.Write([]byte(err.Error())); err != nil {
-		fmt.Println("failed to write stderr", err)
-	}
-}
-
-func setKilledStatus(app *apps.App) {
-	app.ExitCode = new(137)
-	app.Status = common.AppStatusFailed
-	app.FinishedAt = new(time.Now())
-
-	if err := app.SaveToFile(); err != nil {
-		writeStdErr(app.StderrPath, err)
-	}
-}
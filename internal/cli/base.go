@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/aquasecurity/table"
@@ -18,65 +19,41 @@ import (
 func Start(version string) error {
 	var asJson bool
 	var asYaml bool
+	var home string
+	var project string
 
 	rootCmd := &cobra.Command{
 		Use:          "runapp",
 		SilenceUsage: true,
 		Short:        "Run and manage background processes (apps)",
-		RunE: func(_ *cobra.Command, _ []string) error {
-			list, err := apps.List()
-			if err != nil {
-				return err
-			}
-
-			if asJson {
-				b, err := json.Marshal(list)
-				if err != nil {
-					return err
-				}
-				fmt.Println(string(b))
-				return nil
-			}
-
-			if asYaml {
-				b, err := yaml.Marshal(list)
-				if err != nil {
-					return err
-				}
-				fmt.Println(string(b))
-				return nil
-			}
-
-			if len(list) == 0 {
-				fmt.Println(common.NoAppsMessage)
-				return nil
-			}
-
-			t := table.New(os.Stdout)
-
-			t.SetHeaders("Name", "Status", "Mode", "PID")
-			t.SetHeaderStyle(table.StyleBold)
-			t.SetLineStyle(table.StyleBlue)
-			t.SetDividers(table.UnicodeRoundedDividers)
-
-			for _, app := range list {
-				t.AddRow(app.Name, formatStatus(app.Status, app.ExitCode), common.PrettyRunMode[app.Mode], strconv.Itoa(app.PID))
-			}
-
-			t.Render()
-			return nil
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return setupState(home, project)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printApps(cmd, asJson, asYaml)
 		},
 	}
 	rootCmd.Flags().BoolVar(&asJson, "json", false, "output as JSON")
 	rootCmd.Flags().BoolVar(&asYaml, "yaml", false, "output as YAML")
 	rootCmd.MarkFlagsMutuallyExclusive("json", "yaml")
+	rootCmd.PersistentFlags().StringVar(&home, "home", "", "directory with the config and logs of the apps (default $"+util.HomeEnv+", otherwise the XDG config and state directories)")
+	rootCmd.PersistentFlags().StringVarP(&project, "project", "p", "", "isolated namespace of apps, e.g. one per checkout (default $"+util.ProjectEnv+")")
+
+	rootCmd.AddCommand(buildListCmd())
 
 	rootCmd.AddCommand(buildVersionCmd(version))
 
 	rootCmd.AddCommand(buildRunCmd())
+	rootCmd.AddCommand(buildApplyCmd())
+	rootCmd.AddCommand(buildImportCmd())
 	rootCmd.AddCommand(buildRestartCmd())
+	rootCmd.AddCommand(buildWaitCmd())
 	rootCmd.AddCommand(buildLogsCmd())
+	rootCmd.AddCommand(buildHistoryCmd())
 	rootCmd.AddCommand(buildStatusCmd())
+	rootCmd.AddCommand(buildTopCmd())
+	rootCmd.AddCommand(buildUICmd())
+	rootCmd.AddCommand(buildDoctorCmd())
 
 	rootCmd.AddCommand(buildKillCmd())
 
@@ -84,11 +61,97 @@ func Start(version string) error {
 	rootCmd.AddCommand(buildRemoveManyCmd())
 
 	rootCmd.AddCommand(buildBackgroundCmd())
+	rootCmd.AddCommand(buildExecLimitsCmd())
+	rootCmd.AddCommand(buildDaemonCmd(version))
+	rootCmd.AddCommand(buildServeCmd())
 
+	rootCmd.AddCommand(buildExportCmd())
 	rootCmd.AddCommand(buildOnBootCmd())
 	if util.IsSystemd() {
 		rootCmd.AddCommand(buildInstallOnBootCmd())
+		rootCmd.AddCommand(buildUninstallOnBootCmd())
 	}
 
 	return rootCmd.Execute()
 }
+
+func buildListCmd() *cobra.Command {
+	var asJson bool
+	var asYaml bool
+
+	cmd := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		SilenceUsage: true,
+		Short:        "List all apps",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printApps(cmd, asJson, asYaml)
+		},
+	}
+	cmd.Flags().BoolVar(&asJson, "json", false, "output as JSON")
+	cmd.Flags().BoolVar(&asYaml, "yaml", false, "output as YAML")
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml")
+	return cmd
+}
+
+func printApps(cmd *cobra.Command, asJson bool, asYaml bool) error {
+	list, err := listApps(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if asJson {
+		b, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	if asYaml {
+		b, err := yaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	if len(list) == 0 {
+		fmt.Println(common.NoAppsMessage)
+		return nil
+	}
+
+	t := table.New(os.Stdout)
+
+	// the run columns are only shown once there are scheduled apps
+	hasScheduled := slices.ContainsFunc(list, func(app apps.App) bool {
+		return app.Schedule != nil
+	})
+
+	headers := []string{"Name", "Status", "Mode", "PID", "Restarts"}
+	if hasScheduled {
+		headers = append(headers, "Last run", "Next run")
+	}
+	t.SetHeaders(headers...)
+	t.SetHeaderStyle(table.StyleBold)
+	t.SetLineStyle(table.StyleBlue)
+	t.SetDividers(table.UnicodeRoundedDividers)
+
+	for _, app := range list {
+		row := []string{app.Name, formatStatus(app.Status, app.ExitCode), common.PrettyRunMode[app.Mode], strconv.Itoa(app.PID), formatRestarts(app.RestartCount, app.NextRestartAt)}
+		if hasScheduled {
+			if app.Schedule != nil {
+				row = append(row, formatLastRun(app), formatNextRun(app))
+			} else {
+				row = append(row, "", "")
+			}
+		}
+		t.AddRow(row...)
+	}
+
+	t.Render()
+	return nil
+}
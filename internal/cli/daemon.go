@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/daemon"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	daemonStartTimeout = 5 * time.Second
+)
+
+func buildDaemonCmd(version string) *cobra.Command {
+	var detach bool
+
+	cmd := &cobra.Command{
+		Use:          "daemon",
+		SilenceUsage: true,
+		Short:        "Supervise all apps in a single process, other commands use it while it is running",
+		Long: "Supervise all apps in a single process and expose them over a Unix socket JSON API.\n" +
+			"While the daemon is running, run, restart and kill go through it, otherwise every app gets its own background process.\n" +
+			"Stopping the daemon stops the apps it supervises.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if detach {
+				return startDetachedDaemon(cmd.Context())
+			}
+
+			socketPath, err := daemon.SocketPath()
+			if err != nil {
+				return err
+			}
+
+			stateDir, err := util.StateDirPath()
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(stateDir, os.ModePerm); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
+
+			fmt.Println(tml.Sprintf("<yellow>▶ runapp daemon listening on %s (PID: %d)</yellow>", socketPath, os.Getpid()))
+			if err := daemon.NewServer(version).Serve(ctx, socketPath); err != nil {
+				return err
+			}
+			fmt.Println("runapp daemon stopped")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&detach, "detach", false, "run the daemon in the background, its output goes to "+common.FileDaemonLog)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "status",
+		SilenceUsage: true,
+		Short:        "Show whether the daemon is running",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := daemon.Connect(cmd.Context())
+			if err != nil {
+				if errors.Is(err, daemon.ErrNotRunning) {
+					fmt.Println("🤖 runapp daemon is not running, apps are supervised by their own background process")
+					return nil
+				}
+				return err
+			}
+
+			info, err := client.Ping(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			supervised := "none"
+			if len(info.Supervised) != 0 {
+				supervised = strings.Join(info.Supervised, ", ")
+			}
+			fmt.Println(tml.Sprintf("<green>runapp daemon is running</green> (PID: %d, version: %s, up for %s)",
+				info.PID, info.Version, time.Since(info.StartedAt).Round(time.Second)))
+			fmt.Println("Supervised apps:", supervised)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "stop",
+		SilenceUsage: true,
+		Short:        "Stop the daemon and the apps it supervises",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := daemon.Connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := client.Shutdown(cmd.Context()); err != nil {
+				return err
+			}
+			fmt.Println(tml.Sprintf("<green>runapp daemon is stopping</green>"))
+			return nil
+		},
+	})
+	return cmd
+}
+
+// startDetachedDaemon starts the daemon in a new session and waits until it accepts connections
+func startDetachedDaemon(ctx context.Context) error {
+	if _, err := daemon.Connect(ctx); err == nil {
+		return errors.New("daemon is already running")
+	}
+
+	stateDir, err := util.StateDirPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stateDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	logPath := path.Join(stateDir, common.FileDaemonLog)
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func(logFile *os.File) {
+		if err := logFile.Close(); err != nil {
+			util.DebugLog("failed to close daemon log: %v", err)
+		}
+	}(logFile)
+
+	cmd := exec.Command(os.Args[0], "daemon")
+	cmd.Env = os.Environ()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Stdin = nil
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true, // start new session
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(daemonStartTimeout)
+	for time.Now().Before(deadline) {
+		if _, err := daemon.Connect(ctx); err == nil {
+			fmt.Println(tml.Sprintf("<green>runapp daemon started with PID: %d</green>", cmd.Process.Pid))
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon did not start in %s, see %s", daemonStartTimeout, logPath)
+}
+
+// listApps asks the daemon for the apps when it is running
+func listApps(ctx context.Context) ([]apps.App, error) {
+	if client, err := daemon.Connect(ctx); err == nil {
+		return client.List(ctx)
+	}
+	return apps.List()
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func buildDoctorCmd() *cobra.Command {
+	var migrate bool
+
+	cmd := &cobra.Command{
+		Use:          "doctor",
+		SilenceUsage: true,
+		Short:        "Check the stored config of every app",
+		Long: fmt.Sprintf("Check the stored config of every app, reports corrupt configs and configs written with an older schema version (current: %d).\n"+
+			"Older configs are upgraded when they are read, --migrate rewrites them with the current version", apps.SchemaVersion),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			checks, err := apps.CheckConfigs()
+			if err != nil {
+				return err
+			}
+			if len(checks) == 0 {
+				fmt.Println(common.NoAppsMessage)
+				return nil
+			}
+
+			var problems int
+			var outdated int
+			for _, check := range checks {
+				switch {
+				case check.Err != nil:
+					problems++
+					fmt.Println(tml.Sprintf("<red>✗</red> <italic>%s</italic>: %s", check.Name, check.Err.Error()))
+				case check.Outdated() && migrate:
+					if err := apps.MigrateConfig(check.Name); err != nil {
+						problems++
+						fmt.Println(tml.Sprintf("<red>✗</red> <italic>%s</italic>: failed to migrate: %s", check.Name, err.Error()))
+						continue
+					}
+					fmt.Println(tml.Sprintf("<green>✓</green> <italic>%s</italic>: migrated from schema version %d to %d", check.Name, check.Version, apps.SchemaVersion))
+				case check.Outdated():
+					outdated++
+					fmt.Println(tml.Sprintf("<yellow>!</yellow> <italic>%s</italic>: schema version %d, current is %d", check.Name, check.Version, apps.SchemaVersion))
+				default:
+					fmt.Println(tml.Sprintf("<green>✓</green> <italic>%s</italic>", check.Name))
+				}
+			}
+
+			if outdated != 0 {
+				fmt.Println(tml.Sprintf("use <bold>runapp doctor --migrate</bold> to upgrade outdated configs"))
+			}
+			if problems != 0 {
+				return fmt.Errorf("found %d problem(s)", problems)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&migrate, "migrate", false, "rewrite configs written with an older schema version")
+
+	return cmd
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/supervisor"
+)
+
+// the supervisor starts apps with rlimits through this command, it sets them and execs the app command
+func buildExecLimitsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                supervisor.ExecLimitsCmd,
+		DisableAutoGenTag:  true,
+		Hidden:             true,
+		DisableSuggestions: true,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		// the app command must start as it would without runapp in between, nothing is migrated
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return supervisor.ExecWithLimits(args)
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/export"
+	"github.com/0xB1a60/runapp/internal/tui"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+func buildExportCmd() *cobra.Command {
+	var all bool
+	var format string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:          "export [app...]",
+		SilenceUsage: true,
+		Short:        "Export apps to the service files of an init system",
+		Long: "Export apps to the service files of an init system, the services run the command of the app with its working directory, env and restart policy.\n" +
+			"The max retries of the restart policy is only kept by systemd and openrc, runit, s6 and supervisord restart the apps without limit.\n" +
+			"Use runapp export systemd to install systemd user units that are kept in sync with the apps",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exportFormat, err := export.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+
+			list, err := resolveExportApps(args, all)
+			if err != nil {
+				if errors.Is(err, tui.ErrStop) {
+					return nil
+				}
+				return err
+			}
+			if len(list) == 0 {
+				fmt.Println(common.NoAppsMessage)
+				return nil
+			}
+
+			dir, err := util.ResolvePath(output)
+			if err != nil {
+				return err
+			}
+
+			_, err = exportApps(list, all, func(app apps.App) (string, error) {
+				files, err := export.Render(exportFormat, app)
+				if err != nil {
+					return "", err
+				}
+				if err := export.Write(dir, files); err != nil {
+					return "", err
+				}
+				if app.Restart.Policy != common.RestartPolicyNever && app.Restart.MaxRetries != 0 && !export.SupportsMaxRetries(exportFormat) {
+					fmt.Println(tml.Sprintf("<yellow>app: %s max retries %d is not exported, %s restarts it without limit</yellow>", app.Name, app.Restart.MaxRetries, exportFormat))
+				}
+				// the first file is the service file or inside the service directory
+				name, _, _ := strings.Cut(files[0].Path, "/")
+				return path.Join(dir, name), nil
+			})
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "export all apps")
+	cmd.Flags().StringVar(&format, "format", "", "init system the services are generated for (one of: systemd, openrc, runit, s6, supervisord)")
+	cmd.Flags().StringVar(&output, "output", ".", "directory the service files are written to")
+
+	cmd.AddCommand(buildExportSystemdCmd())
+	return cmd
+}
+
+func buildExportSystemdCmd() *cobra.Command {
+	var all bool
+	var output string
+	var enable bool
+
+	cmd := &cobra.Command{
+		Use:          "systemd [app...]",
+		SilenceUsage: true,
+		Short:        "Generate a systemd user service for every app",
+		Long: "Generate a runapp-<app>.service systemd user unit for every app, systemd restarts the app according to its restart policy and journald keeps its logs.\n" +
+			"runapp run updates the units in the systemd user directory with the new command, env and restart policy, runapp remove disables and removes them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := resolveExportApps(args, all)
+			if err != nil {
+				if errors.Is(err, tui.ErrStop) {
+					return nil
+				}
+				return err
+			}
+			if len(list) == 0 {
+				fmt.Println(common.NoAppsMessage)
+				return nil
+			}
+
+			unitDir, err := export.SystemdUnitDir()
+			if err != nil {
+				return err
+			}
+			dir := unitDir
+			if len(output) != 0 {
+				if dir, err = util.ResolvePath(output); err != nil {
+					return err
+				}
+			}
+
+			exported, err := exportApps(list, all, func(app apps.App) (string, error) {
+				return export.WriteSystemdUnit(dir, app)
+			})
+			if err != nil {
+				return err
+			}
+
+			if dir != unitDir {
+				return nil
+			}
+			if err := util.ExecuteCommand(export.DaemonReloadCmd, true); err != nil {
+				util.DebugLog("failed to reload systemd units: %v", err)
+			}
+
+			for _, app := range exported {
+				unitName := export.SystemdUnitName(app.Name)
+				if !enable {
+					fmt.Println(tml.Sprintf("Start it on boot with: <magenta>systemctl --user enable --now %s</magenta>", unitName))
+					continue
+				}
+
+				// systemd takes over, the app must not run twice
+				if app.IsRunning() {
+					if err := killApp(cmd.Context(), &app); err != nil {
+						return err
+					}
+				}
+				if err := util.ExecuteCommand("systemctl --user enable --now "+unitName, false); err != nil {
+					return fmt.Errorf("failed to enable %s: %w", unitName, err)
+				}
+				fmt.Println(tml.Sprintf("<green>%s enabled and started</green>", unitName))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "export all apps")
+	cmd.Flags().StringVar(&output, "output", "", "directory the units are written to (default "+common.SystemdPath+")")
+	cmd.Flags().BoolVar(&enable, "enable", false, "enable and start the units, running apps are stopped first")
+	cmd.MarkFlagsMutuallyExclusive("output", "enable")
+
+	return cmd
+}
+
+// exportApps exports every app with exportFunc, which returns where the app was exported to.
+// Scheduled apps are skipped with --all, returns the exported apps
+func exportApps(list []apps.App, all bool, exportFunc func(app apps.App) (string, error)) ([]apps.App, error) {
+	exported := make([]apps.App, 0, len(list))
+	for _, app := range list {
+		exportedPath, err := exportFunc(app)
+		if err != nil {
+			if all && errors.Is(err, export.ErrScheduled) {
+				fmt.Println(tml.Sprintf("<yellow>app: %s skipped, scheduled apps cannot be exported</yellow>", app.Name))
+				continue
+			}
+			return exported, err
+		}
+		exported = append(exported, app)
+		fmt.Println(tml.Sprintf("<green>app: %s exported to %s</green>", app.Name, exportedPath))
+	}
+	return exported, nil
+}
+
+// resolveExportApps returns the apps by name, all apps with --all or the app picked in the TUI
+func resolveExportApps(names []string, all bool) ([]apps.App, error) {
+	if all {
+		return apps.List()
+	}
+
+	if len(names) == 0 {
+		has, err := apps.HasAny()
+		if err != nil || !has {
+			return nil, err
+		}
+
+		var appName string
+		entry := tui.FlagOrPromptEntry{
+			TUIFunc:      tui.NamePicker,
+			ValidateFunc: nameValidateFunc,
+			SetFunc: func(value string) {
+				appName = value
+			},
+		}
+		if err := tui.ResolveFlagsOrPrompt(entry); err != nil {
+			return nil, err
+		}
+		names = []string{appName}
+	}
+
+	list := make([]apps.App, 0, len(names))
+	for _, name := range names {
+		app, err := apps.Get(name)
+		if err != nil {
+			if errors.Is(err, apps.ErrNotFound) {
+				return nil, fmt.Errorf("app: %s does not exist", name)
+			}
+			return nil, err
+		}
+		list = append(list, *app)
+	}
+	return list, nil
+}
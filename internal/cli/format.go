@@ -2,9 +2,12 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/liamg/tml"
 
+	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
 )
 
@@ -26,6 +29,85 @@ func formatStatus(val common.AppStatus, exitCode *int) string {
 		return tml.Sprintf("<yellow>Running</yellow>")
 	case common.AppStatusStarting:
 		return tml.Sprintf("<yellow>Starting</yellow>")
+	case common.AppStatusRestarting:
+		return tml.Sprintf("<yellow>Restarting</yellow>")
+	case common.AppStatusUnhealthy:
+		return tml.Sprintf("<magenta>Unhealthy</magenta>")
+	case common.AppStatusScheduled:
+		return tml.Sprintf("<blue>Scheduled</blue>")
 	}
 	panic("unreachable")
 }
+
+// formatCompletion reports how an app that is not running anymore completed
+func formatCompletion(app apps.App) string {
+	status := common.AppStatusPretty[app.Status]
+	if app.ExitCode != nil {
+		status = fmt.Sprintf("%s (%d)", status, *app.ExitCode)
+	}
+
+	if app.Status == common.AppStatusFailed {
+		return tml.Sprintf("<red>Process completed with status: %s</red>", status)
+	}
+	return tml.Sprintf("<green>Process completed with status: %s</green>", status)
+}
+
+// prefixColors are used to tell apps apart when their logs are merged, red is left out as it marks stderr
+var prefixColors = []string{"cyan", "yellow", "green", "magenta", "blue", "lightcyan", "lightyellow", "lightgreen", "lightmagenta", "lightblue"}
+
+// formatAppPrefix returns the colored "name | " prefix of merged log lines, names are padded to width
+func formatAppPrefix(name string, width int, idx int) string {
+	color := prefixColors[idx%len(prefixColors)]
+	// tml only parses tags in the format, not in the arguments
+	return tml.Sprintf(fmt.Sprintf("<%s>%%-*s |</%s> ", color, color), width, name)
+}
+
+func formatCPU(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', 1, 64) + "%"
+}
+
+func formatFDs(fds int32) string {
+	if fds < 0 {
+		return "-"
+	}
+	return strconv.Itoa(int(fds))
+}
+
+// formatLastRun reports when the last run of a scheduled app started and how it exited
+func formatLastRun(app apps.App) string {
+	if app.StartedAt == nil {
+		return "-"
+	}
+
+	started := formatRunTime(*app.StartedAt)
+	switch {
+	case app.ExitCode == nil:
+		return tml.Sprintf("<yellow>%s (running)</yellow>", started)
+	case *app.ExitCode == 0:
+		return tml.Sprintf("<green>%s (0)</green>", started)
+	}
+	return tml.Sprintf("<red>%s (%d)</red>", started, *app.ExitCode)
+}
+
+func formatNextRun(app apps.App) string {
+	if app.NextRunAt == nil {
+		return "-"
+	}
+	return formatRunTime(*app.NextRunAt)
+}
+
+// formatRunTime leaves out the date of today
+func formatRunTime(t time.Time) string {
+	now := time.Now()
+	if t.Year() == now.Year() && t.YearDay() == now.YearDay() {
+		return t.Format(time.TimeOnly)
+	}
+	return t.Format(time.DateTime)
+}
+
+func formatRestarts(restartCount int, nextRestartAt *time.Time) string {
+	if nextRestartAt == nil {
+		return strconv.Itoa(restartCount)
+	}
+	return fmt.Sprintf("%d (next at %s)", restartCount, nextRestartAt.Format(time.TimeOnly))
+}
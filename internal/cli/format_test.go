@@ -2,9 +2,11 @@ package cli
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
 )
 
@@ -45,12 +47,24 @@ func TestFormatStatus(t *testing.T) {
 			exitCode: nil,
 			expected: "\x1b[0m\x1b[33mRunning\x1b[39m\x1b[0m",
 		},
+		{
+			name:     "AppStatusRestarting without exitCode",
+			val:      common.AppStatusRestarting,
+			exitCode: nil,
+			expected: "\x1b[0m\x1b[33mRestarting\x1b[39m\x1b[0m",
+		},
 		{
 			name:     "AppStatusStarting without exitCode",
 			val:      common.AppStatusStarting,
 			exitCode: nil,
 			expected: "\x1b[0m\x1b[33mStarting\x1b[39m\x1b[0m",
 		},
+		{
+			name:     "AppStatusScheduled with exitCode of the last run",
+			val:      common.AppStatusScheduled,
+			exitCode: new(1),
+			expected: "\x1b[0m\x1b[34mScheduled\x1b[39m\x1b[0m",
+		},
 	}
 
 	for _, tt := range tests {
@@ -60,3 +74,33 @@ func TestFormatStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatRestarts(t *testing.T) {
+	nextRestartAt := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	require.Equal(t, "0", formatRestarts(0, nil))
+	require.Equal(t, "3", formatRestarts(3, nil))
+	require.Equal(t, "3 (next at 15:04:05)", formatRestarts(3, &nextRestartAt))
+}
+
+func TestFormatRuns(t *testing.T) {
+	startedAt := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	nextRunAt := time.Date(2025, 1, 3, 3, 0, 0, 0, time.UTC)
+
+	require.Equal(t, "-", formatLastRun(apps.App{}))
+	require.Equal(t, "\x1b[0m\x1b[32m2025-01-02 03:00:00 (0)\x1b[39m\x1b[0m", formatLastRun(apps.App{StartedAt: &startedAt, ExitCode: new(0)}))
+	require.Equal(t, "\x1b[0m\x1b[31m2025-01-02 03:00:00 (2)\x1b[39m\x1b[0m", formatLastRun(apps.App{StartedAt: &startedAt, ExitCode: new(2)}))
+	require.Equal(t, "\x1b[0m\x1b[33m2025-01-02 03:00:00 (running)\x1b[39m\x1b[0m", formatLastRun(apps.App{StartedAt: &startedAt}))
+
+	require.Equal(t, "-", formatNextRun(apps.App{}))
+	require.Equal(t, "2025-01-03 03:00:00", formatNextRun(apps.App{NextRunAt: &nextRunAt}))
+
+	today := time.Now().Truncate(time.Second)
+	require.Equal(t, today.Format(time.TimeOnly), formatNextRun(apps.App{NextRunAt: &today}), "expected the date of today to be left out")
+}
+
+func TestFormatAppPrefix(t *testing.T) {
+	require.Equal(t, "\x1b[0m\x1b[36mapi    |\x1b[39m \x1b[0m", formatAppPrefix("api", 6, 0))
+	require.Equal(t, "\x1b[0m\x1b[33mworker |\x1b[39m \x1b[0m", formatAppPrefix("worker", 6, 1))
+	require.Equal(t, formatAppPrefix("api", 6, 0), formatAppPrefix("api", 6, len(prefixColors)), "expected colors to cycle")
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aquasecurity/table"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/tui"
+)
+
+func buildHistoryCmd() *cobra.Command {
+	var asJson bool
+	var asYaml bool
+
+	cmd := &cobra.Command{
+		Use:          "history [app]",
+		SilenceUsage: true,
+		Short:        "List the finished runs of an app",
+		Args:         cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var appName string
+			if len(args) != 0 {
+				appName = args[0]
+			}
+
+			has, err := apps.HasAny()
+			if err != nil {
+				return err
+			}
+			if !has {
+				fmt.Println(common.NoAppsMessage)
+				return nil
+			}
+
+			entry := tui.FlagOrPromptEntry{
+				Value:        appName,
+				TUIFunc:      tui.NamePicker,
+				ValidateFunc: nameValidateFunc,
+				SetFunc: func(value string) {
+					appName = value
+				},
+			}
+			if err := tui.ResolveFlagsOrPrompt(entry); err != nil {
+				if errors.Is(err, tui.ErrStop) {
+					return nil
+				}
+				return err
+			}
+
+			app, err := apps.Get(appName)
+			if err != nil {
+				if errors.Is(err, apps.ErrNotFound) {
+					return fmt.Errorf("app: %s does not exist", appName)
+				}
+				return err
+			}
+
+			runs, err := apps.ReadHistory(*app)
+			if err != nil {
+				return err
+			}
+			if runs == nil {
+				runs = []apps.Run{}
+			}
+
+			if asJson {
+				b, err := json.Marshal(runs)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				return nil
+			}
+
+			if asYaml {
+				b, err := yaml.Marshal(runs)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				return nil
+			}
+
+			if len(runs) == 0 {
+				fmt.Println(tml.Sprintf("<italic>%s</italic> has no finished runs yet", app.Name))
+				return nil
+			}
+
+			t := table.New(os.Stdout)
+			t.SetHeaders("#", "Started", "Duration", "Exit", "Signal", "Triggered by", "Logs")
+			t.SetHeaderStyle(table.StyleBold)
+			t.SetLineStyle(table.StyleBlue)
+			t.SetDividers(table.UnicodeRoundedDividers)
+
+			// the newest run first
+			for i := len(runs) - 1; i >= 0; i-- {
+				run := runs[i]
+				t.AddRow(strconv.Itoa(run.ID), formatRunTime(run.StartedAt), formatDuration(run.Duration),
+					formatExitCode(run.ExitCode), formatSignal(run), formatOptional(string(run.TriggeredBy)), formatArchived(run.LogsArchived))
+			}
+
+			t.Render()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJson, "json", false, "output as JSON")
+	cmd.Flags().BoolVar(&asYaml, "yaml", false, "output as YAML")
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml")
+
+	return cmd
+}
+
+func formatExitCode(exitCode int) string {
+	if exitCode == 0 {
+		return tml.Sprintf("<green>%d</green>", exitCode)
+	}
+	return tml.Sprintf("<red>%d</red>", exitCode)
+}
+
+// formatDuration rounds the duration to a readable precision
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+func formatOptional(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+
+// formatSignal marks the runs the kernel killed for going over the memory limit
+func formatSignal(run apps.Run) string {
+	if run.OOMKilled {
+		return tml.Sprintf("%s <red>(OOM)</red>", formatOptional(run.Signal))
+	}
+	return formatOptional(run.Signal)
+}
+
+func formatArchived(archived bool) string {
+	if archived {
+		return "archived"
+	}
+	return "-"
+}
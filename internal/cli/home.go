@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamg/tml"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// setupState exports --home and --project to the env, so the background processes and the daemon use the same apps,
+// then migrates the apps of older versions
+func setupState(home string, project string) error {
+	if len(home) != 0 {
+		absHome, err := filepath.Abs(home)
+		if err != nil {
+			return err
+		}
+		if err := os.Setenv(util.HomeEnv, absHome); err != nil {
+			return err
+		}
+	}
+	if len(project) != 0 {
+		if err := os.Setenv(util.ProjectEnv, project); err != nil {
+			return err
+		}
+	}
+	if project := util.Project(); len(project) != 0 {
+		if err := apps.ValidateName(project); err != nil {
+			return fmt.Errorf("invalid project: %w", err)
+		}
+	}
+
+	// a failed migration must not prevent managing the apps that were migrated
+	migrated, err := apps.MigrateLegacy()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tml.Sprintf("<red>failed to migrate apps:</red> %s", err.Error())) // no lint // handling this error is not needed
+	}
+	if len(migrated) != 0 {
+		fmt.Fprintln(os.Stderr, tml.Sprintf("<yellow>migrated %s to the config and state directories</yellow>", strings.Join(migrated, ", "))) // no lint // handling this error is not needed
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/manifest"
+)
+
+func buildImportCmd() *cobra.Command {
+	var prefix string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:          "import",
+		SilenceUsage: true,
+		Short:        "Create apps from a Procfile or docker-compose file",
+	}
+	cmd.PersistentFlags().StringVar(&prefix, "prefix", "", "prefix for the app names, e.g. the project name")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "only print the apps that would be created")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "procfile [path]",
+		SilenceUsage: true,
+		Short:        "Create apps from the processes of a Procfile",
+		Args:         cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filePath := manifest.DefaultProcfile
+			if len(args) != 0 {
+				filePath = args[0]
+			}
+
+			m, err := manifest.LoadProcfile(filePath)
+			if err != nil {
+				return err
+			}
+			return importApps(cmd.Context(), m, prefix, dryRun)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "compose [path]",
+		SilenceUsage: true,
+		Short:        "Create apps from the service commands of a compose file (default compose.yaml, compose.yml, docker-compose.yaml or docker-compose.yml)",
+		Args:         cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var filePath string
+			if len(args) != 0 {
+				filePath = args[0]
+			} else {
+				found, err := manifest.FindComposeFile(".")
+				if err != nil {
+					return err
+				}
+				filePath = found
+			}
+
+			m, skipped, err := manifest.LoadCompose(filePath)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range skipped {
+				fmt.Println(tml.Sprintf("<yellow>service: %s skipped, it has no command</yellow>", name))
+			}
+			return importApps(cmd.Context(), m, prefix, dryRun)
+		},
+	})
+	return cmd
+}
+
+func importApps(ctx context.Context, m *manifest.Manifest, prefix string, dryRun bool) error {
+	m.WithPrefix(prefix)
+
+	for _, spec := range m.Apps {
+		if err := nameValidateFunc(spec.Name); err != nil {
+			return fmt.Errorf("app: %s: %w", spec.Name, err)
+		}
+	}
+
+	if len(m.Apps) == 0 {
+		fmt.Println("🤖 Nothing to import")
+		return nil
+	}
+
+	for _, spec := range m.Apps {
+		if _, err := apps.Get(spec.Name); err == nil {
+			fmt.Println(tml.Sprintf("<yellow>app: %s already exists, skipping</yellow>", spec.Name))
+			continue
+		} else if !errors.Is(err, apps.ErrNotFound) {
+			return err
+		}
+
+		if dryRun {
+			fmt.Println(tml.Sprintf("<green>+ %s will be created</green> (%s)", spec.Name, spec.Command))
+			continue
+		}
+
+		if err := createAndRunApp(ctx, spec.ToApp(os.Environ()), startOptions{skipLogs: true}); err != nil {
+			return fmt.Errorf("failed to create app: %s: %w", spec.Name, err)
+		}
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/charmbracelet/huh/spinner"
 	"github.com/liamg/tml"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/runner"
 	"github.com/0xB1a60/runapp/internal/tui"
 	"github.com/0xB1a60/runapp/internal/util"
 )
@@ -68,7 +68,13 @@ func buildKillCmd() *cobra.Command {
 				return errors.New("app is not running")
 			}
 
-			killApp(cmd.Context(), app)
+			stopped, err := killAppAndDependents(cmd.Context(), app)
+			if err != nil {
+				return err
+			}
+			for _, name := range stopped {
+				fmt.Println(tml.Sprintf("<yellow>Dependent app: %s killed</yellow>", name))
+			}
 			fmt.Println(tml.Sprintf("<green>App successfully killed 💀</green>"))
 			return nil
 		},
@@ -76,29 +82,30 @@ func buildKillCmd() *cobra.Command {
 	return cmd
 }
 
-func killApp(ctx context.Context, app *apps.App) {
-	actionFunc := func() {
-		done := make(chan error)
-		go func() {
-			done <- util.SoftKill(app.PID)
-		}()
+func killApp(ctx context.Context, app *apps.App) error {
+	var err error
+	runKillSpinner(func() {
+		err = runner.Stop(ctx, app)
+	})
+	return err
+}
 
-		select {
-		case err := <-done:
-			if err != nil {
-				util.DebugLog("Failed to stop app: %v", err)
-			}
-			util.DebugLog("app stopped")
-		case <-time.After(time.Second * 10):
-			util.DebugLog("force killing app")
-			if err := util.ForceKill(app.PID); err != nil {
-				util.DebugLog("failed to force kill app: %v", err)
-			}
-			util.DebugLog("app force killed")
-		case <-ctx.Done():
+// killAppAndDependents kills the running apps that depend on the app before the app itself,
+// returns the names of the killed dependents
+func killAppAndDependents(ctx context.Context, app *apps.App) ([]string, error) {
+	var stopped []string
+	var err error
+	runKillSpinner(func() {
+		stopped, err = runner.StopDependents(ctx, app)
+		if err != nil {
+			return
 		}
-	}
+		err = runner.Stop(ctx, app)
+	})
+	return stopped, err
+}
 
+func runKillSpinner(actionFunc func()) {
 	err := spinner.New().
 		Title("Killing app...").
 		Action(actionFunc).
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -21,17 +22,106 @@ import (
 
 func buildLogsCmd() *cobra.Command {
 	var logType logs.LogType
+	var timestamps bool
+	var since string
+	var until string
+	var tailLines int
+	var follow bool
+	var noFollow bool
+	var all bool
+	var selectorValue string
+	var grep string
+	var invert bool
+	var level string
+	var beforeLines int
+	var afterLines int
+	var runID int
 
 	cmd := &cobra.Command{
-		Use:          "logs",
+		Use:          "logs [app...]",
 		SilenceUsage: true,
-		Short:        "Stream the logs (stdout,stderr) of an app",
-		Args:         cobra.RangeArgs(0, 1),
+		Short:        "Stream the logs (stdout,stderr) of one or more apps",
+		Args:         cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !slices.Contains(logs.ValidTypes, logType) {
 				return fmt.Errorf("type must be %s, %s or %s", logs.AllLogs, logs.OutLogs, logs.ErrLogs)
 			}
 
+			opts := logs.DefaultOptions()
+			opts.Type = logType
+			opts.Timestamps = timestamps
+			opts.Follow = follow && !noFollow
+
+			if tailLines < 0 && tailLines != logs.TailAll {
+				return errors.New("tail must be a positive number")
+			}
+			opts.Tail = tailLines
+
+			if len(grep) != 0 {
+				expr, err := regexp.Compile(grep)
+				if err != nil {
+					return fmt.Errorf("invalid grep expression: %w", err)
+				}
+				opts.Grep = expr
+			}
+			if invert && opts.Grep == nil {
+				return errors.New("invert requires --grep")
+			}
+			opts.Invert = invert
+
+			if beforeLines < 0 || afterLines < 0 {
+				return errors.New("context lines must be a positive number")
+			}
+			opts.Before = beforeLines
+			opts.After = afterLines
+
+			if len(level) != 0 {
+				value, err := logs.ParseLevel(level)
+				if err != nil {
+					return err
+				}
+				opts.Level = value
+			}
+
+			now := time.Now()
+			if len(since) != 0 {
+				value, err := util.ParseTimeOrDuration(since, now)
+				if err != nil {
+					return err
+				}
+				opts.Since = value
+			}
+			if len(until) != 0 {
+				value, err := util.ParseTimeOrDuration(until, now)
+				if err != nil {
+					return err
+				}
+				opts.Until = value
+			}
+
+			if all && len(args) != 0 {
+				return errors.New("--all can't be combined with app names")
+			}
+			if runID != 0 {
+				if runID < 0 {
+					return errors.New("run must be a positive number")
+				}
+				if all || len(selectorValue) != 0 || len(args) > 1 {
+					return errors.New("--run only works with a single app")
+				}
+				// archived logs never grow
+				opts.Follow = false
+			}
+
+			var selector *apps.Selector
+			if len(selectorValue) != 0 {
+				value, err := apps.ParseSelector(selectorValue)
+				if err != nil {
+					return err
+				}
+				selector = &value
+			}
+
 			has, err := apps.HasAny()
 			if err != nil {
 				return err
@@ -42,6 +132,17 @@ func buildLogsCmd() *cobra.Command {
 				return nil
 			}
 
+			if all || selector != nil || len(args) > 1 {
+				list, err := selectApps(args, all, selector)
+				if err != nil {
+					return err
+				}
+				if len(list) == 0 {
+					return errors.New("no apps match the selector")
+				}
+				return viewManyLogs(cmd.Context(), list, opts)
+			}
+
 			var appName string
 			if len(args) != 0 {
 				appName = args[0]
@@ -71,22 +172,41 @@ func buildLogsCmd() *cobra.Command {
 				return err
 			}
 
-			return viewLogs(cmd.Context(), *app, logType)
+			if runID != 0 {
+				return viewRunLogs(*app, runID, opts)
+			}
+			return viewLogs(cmd.Context(), *app, opts)
 		},
 	}
 	cmd.Flags().StringVar(&logType, "type", logs.AllLogs,
 		fmt.Sprintf("type of logs to show (one of: %s)", strings.Join(logs.ValidTypes, ", ")))
+	cmd.Flags().BoolVarP(&timestamps, "timestamps", "t", false, "show the time each line was captured")
+	cmd.Flags().StringVar(&since, "since", "", "only show lines captured after a timestamp (e.g. 2006-01-02T15:04:05Z) or relative duration (e.g. 10m)")
+	cmd.Flags().StringVar(&until, "until", "", "only show lines captured before a timestamp or relative duration, implies no streaming")
+	cmd.Flags().IntVarP(&tailLines, "tail", "n", logs.TailAll, "only show the last N lines (-1 shows all)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", true, "keep streaming new lines of running apps")
+	cmd.Flags().BoolVar(&noFollow, "no-follow", false, "print the current logs and exit, even if the app is running")
+	cmd.MarkFlagsMutuallyExclusive("follow", "no-follow")
+	cmd.Flags().StringVar(&grep, "grep", "", "only show lines matching a regular expression, applied after --tail")
+	cmd.Flags().BoolVar(&invert, "invert", false, "only show lines not matching --grep")
+	cmd.Flags().IntVarP(&afterLines, "after-context", "A", 0, "show N lines after each matching line")
+	cmd.Flags().IntVarP(&beforeLines, "before-context", "B", 0, "show N lines before each matching line")
+	cmd.Flags().StringVar(&level, "level", "",
+		fmt.Sprintf("only show lines logged at this level or above (one of: %s)", strings.Join(logs.ValidLevels, ", ")))
+	cmd.Flags().BoolVar(&all, "all", false, "show the logs of all apps")
+	cmd.Flags().StringVarP(&selectorValue, "selector", "l", "", "only show the logs of apps with matching labels (e.g. tier=web,env!=prod)")
+	cmd.Flags().IntVar(&runID, "run", 0, "show the archived logs of a previous run (see runapp history)")
 
 	return cmd
 }
 
-func viewLogs(ctx context.Context, app apps.App, logType logs.LogType) error {
-	if !app.IsRunning() {
-		return logs.PrintLines(app, logType)
+func viewLogs(ctx context.Context, app apps.App, opts logs.Options) error {
+	if !app.IsRunning() || !opts.Follow || !opts.Until.IsZero() {
+		return logs.PrintLines(app, opts)
 	}
 
 	fmt.Println(tml.Sprintf("<yellow>▶ Streaming logs for app: %s. You can stop the streaming with CTRL+C, the process won't be interrupted</yellow>", app.Name))
-	logStream, err := logs.Stream(ctx, app, logType)
+	logStream, err := logs.Stream(ctx, app, opts)
 	if err != nil {
 		return err
 	}
@@ -103,21 +223,7 @@ func viewLogs(ctx context.Context, app apps.App, logType logs.LogType) error {
 			case <-ticker.C:
 				if app, err := apps.Get(app.Name); err == nil {
 					if !app.IsRunning() {
-						var msg string
-						if app.ExitCode == nil {
-							if app.Status == common.AppStatusFailed {
-								msg = tml.Sprintf("<red>Process completed with status: %s</red>", common.AppStatusPretty[app.Status])
-							} else {
-								msg = tml.Sprintf("<green>Process completed with status: %s</green>", common.AppStatusPretty[app.Status])
-							}
-						} else {
-							if app.Status == common.AppStatusFailed {
-								msg = tml.Sprintf("<red>Process completed with status: %s (%d)</red>", common.AppStatusPretty[app.Status], *app.ExitCode)
-							} else {
-								msg = tml.Sprintf("<green>Process completed with status: %s (%d)</green>", common.AppStatusPretty[app.Status], *app.ExitCode)
-							}
-						}
-						fmt.Println(msg)
+						fmt.Println(formatCompletion(*app))
 						cancelFunc()
 						continue
 					}
@@ -131,10 +237,10 @@ func viewLogs(ctx context.Context, app apps.App, logType logs.LogType) error {
 				}
 			case log := <-logStream:
 				if log.IsErr {
-					fmt.Fprintln(os.Stderr, tml.Sprintf("<red>%s</red>", log.Value)) // no lint // handling this error is not needed
+					fmt.Fprintln(os.Stderr, logs.FormatLog(log, opts.Timestamps)) // no lint // handling this error is not needed
 					continue
 				}
-				fmt.Println(log.Value)
+				fmt.Println(logs.FormatLog(log, opts.Timestamps))
 			case <-ctx.Done():
 				return
 			}
@@ -144,3 +250,176 @@ func viewLogs(ctx context.Context, app apps.App, logType logs.LogType) error {
 	<-ctx.Done()
 	return nil
 }
+
+// viewRunLogs prints the archived logs of a finished run of the app
+func viewRunLogs(app apps.App, id int, opts logs.Options) error {
+	runs, err := apps.ReadHistory(app)
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(runs, func(run apps.Run) bool {
+		return run.ID == id
+	})
+	if idx == -1 {
+		return fmt.Errorf("run %d of app %s does not exist", id, app.Name)
+	}
+	if !runs[idx].LogsArchived {
+		return fmt.Errorf("logs of run %d were not archived, enable them with runapp run --archive-logs", id)
+	}
+	return logs.PrintLines(app.WithRunLogs(runs[idx]), opts)
+}
+
+// selectApps returns the apps named in args (or every app when all is set) that match the selector
+func selectApps(names []string, all bool, selector *apps.Selector) ([]apps.App, error) {
+	list, err := apps.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if !all && len(names) != 0 {
+		idx := make(map[string]apps.App, len(list))
+		for _, app := range list {
+			idx[app.Name] = app
+		}
+
+		named := make([]apps.App, 0, len(names))
+		for _, name := range names {
+			app, ok := idx[name]
+			if !ok {
+				return nil, fmt.Errorf("app: %s does not exist", name)
+			}
+			if !slices.ContainsFunc(named, func(a apps.App) bool { return a.Name == name }) {
+				named = append(named, app)
+			}
+		}
+		list = named
+	} else {
+		slices.SortFunc(list, func(a, b apps.App) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	if selector != nil {
+		list = slices.DeleteFunc(list, func(app apps.App) bool {
+			return !selector.Matches(app.Labels)
+		})
+	}
+	return list, nil
+}
+
+type appLog struct {
+	name string
+	log  logs.Log
+}
+
+// viewManyLogs merges the logs of several apps into one view, every line is prefixed with the app name,
+// apps that are not running are printed first, then the running ones are streamed until all of them exit
+func viewManyLogs(ctx context.Context, list []apps.App, opts logs.Options) error {
+	width := 0
+	for _, app := range list {
+		width = max(width, len(app.Name))
+	}
+
+	prefixes := make(map[string]string, len(list))
+	for i, app := range list {
+		prefixes[app.Name] = formatAppPrefix(app.Name, width, i)
+	}
+
+	printLog := func(name string, log logs.Log) {
+		line := prefixes[name] + logs.FormatLog(log, opts.Timestamps)
+		if log.IsErr {
+			fmt.Fprintln(os.Stderr, line) // no lint // handling this error is not needed
+			return
+		}
+		fmt.Println(line)
+	}
+
+	streamed := make([]apps.App, 0, len(list))
+	for _, app := range list {
+		if app.IsRunning() && opts.Follow && opts.Until.IsZero() {
+			streamed = append(streamed, app)
+			continue
+		}
+
+		if err := logs.EachLine(app, opts, func(log logs.Log) error {
+			printLog(app.Name, log)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("app: %s: %w", app.Name, err)
+		}
+	}
+
+	if len(streamed) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(streamed))
+	for _, app := range streamed {
+		names = append(names, app.Name)
+	}
+	fmt.Println(tml.Sprintf("<yellow>▶ Streaming logs for apps: %s. You can stop the streaming with CTRL+C, the processes won't be interrupted</yellow>", strings.Join(names, ", ")))
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	defer cancelFunc()
+
+	merged := make(chan appLog, 100)
+	// stops streaming a single app once it exits or gets removed
+	cancels := make(map[string]context.CancelFunc, len(streamed))
+
+	for _, app := range streamed {
+		appCtx, appCancel := context.WithCancel(ctx)
+		cancels[app.Name] = appCancel
+
+		logStream, err := logs.Stream(appCtx, app, opts)
+		if err != nil {
+			return fmt.Errorf("app: %s: %w", app.Name, err)
+		}
+
+		go func() {
+			for {
+				select {
+				case log := <-logStream:
+					select {
+					case merged <- appLog{name: app.Name, log: log}:
+					case <-appCtx.Done():
+						return
+					}
+				case <-appCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for len(cancels) != 0 {
+		select {
+		case <-ticker.C:
+			for name, appCancel := range cancels {
+				app, err := apps.Get(name)
+				if err != nil {
+					if !errors.Is(err, apps.ErrNotFound) {
+						util.DebugLog("failed to get app %s: %v", name, err)
+						continue
+					}
+					fmt.Fprintln(os.Stderr, prefixes[name]+tml.Sprintf("<red>%s</red>", "app was removed"))
+				} else if app.IsRunning() {
+					continue
+				} else {
+					fmt.Println(prefixes[name] + formatCompletion(*app))
+				}
+
+				appCancel()
+				delete(cancels, name)
+			}
+		case l := <-merged:
+			printLog(l.name, l.log)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,12 +14,25 @@ func buildOnBootCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                "onboot",
 		DisableAutoGenTag:  true,
-		Hidden:             true,
 		DisableSuggestions: true,
 		SilenceUsage:       true,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		// a typo of a subcommand must not start the apps and overwrite the boot report
+		Args: cobra.NoArgs,
+		// the parent is listed so runapp onboot status shows up in --help, runapp-boot.service runs the bare command
+		Short: "Start the on-boot and scheduled apps, run by runapp-boot.service (see runapp onboot status)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report := apps.BootReport{StartedAt: time.Now(), Apps: []apps.BootApp{}}
+			// the report is written however the on-boot run ends, runapp onboot status reads it
+			defer func() {
+				report.FinishedAt = time.Now()
+				if err := apps.WriteBootReport(report); err != nil {
+					fmt.Println("error while writing the on-boot report:", err)
+				}
+			}()
+
 			list, err := apps.List()
 			if err != nil {
+				report.Error = err.Error()
 				return err
 			}
 			if len(list) == 0 {
@@ -26,24 +40,65 @@ func buildOnBootCmd() *cobra.Command {
 				return nil
 			}
 
+			// dependencies are started first, with a cycle the apps are still started in the listed order
+			if sorted, err := apps.SortByDependencies(list); err != nil {
+				fmt.Println("error while ordering apps on boot:", err)
+				report.Error = err.Error()
+			} else {
+				list = sorted
+			}
+
 			fmt.Println("Running on-boot")
 			for _, app := range list {
-				fmt.Println()
-
-				if app.Status == common.AppStatusRunning || app.Status == common.AppStatusStarting {
-					fmt.Println("application already running", app.Name)
+				if app.Mode != common.RunModeOnBoot && app.Mode != common.RunModeScheduled {
 					continue
 				}
+				fmt.Println()
 
-				if app.Mode == common.RunModeOnBoot {
-					if err := runApp(app); err != nil {
-						fmt.Println(fmt.Sprintf("error while running (%s) on boot:", app.Name), err)
-					}
+				result := bootApp(cmd, app.Name)
+				report.Apps = append(report.Apps, result)
+				if result.Result == apps.BootResultFailed {
+					fmt.Println(fmt.Sprintf("error while running (%s) on boot:", app.Name), result.Error)
 				}
 			}
 			fmt.Println("Finished on-boot")
 			return nil
 		},
 	}
+	cmd.AddCommand(buildOnBootStatusCmd())
 	return cmd
 }
+
+// bootApp starts the app unless it is already running
+func bootApp(cmd *cobra.Command, name string) apps.BootApp {
+	result := apps.BootApp{Name: name, Result: apps.BootResultFailed}
+
+	// the app may have been started as a dependency of an app before it
+	current, err := apps.Get(name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if current.IsRunning() {
+		fmt.Println("application already running", name)
+		result.Result = apps.BootResultAlreadyRunning
+		return result
+	}
+
+	current, err = apps.Update(name, func(stored *apps.App) error {
+		stored.TriggeredBy = common.TriggerBoot
+		return nil
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := runApp(cmd.Context(), *current); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Result = apps.BootResultStarted
+	return result
+}
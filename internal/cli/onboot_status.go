@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aquasecurity/table"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// bootUnitProperties are read from systemd to describe runapp-boot.service
+var bootUnitProperties = []string{"UnitFileState", "ActiveState", "SubState", "Result"}
+
+// onBootStatus is the JSON/YAML output of onboot status
+type onBootStatus struct {
+	Unit      string `json:"unit" yaml:"unit"`
+	Installed bool   `json:"installed" yaml:"installed"`
+	// Enabled, State and Result are empty when systemd is not reachable
+	Enabled  string           `json:"enabled" yaml:"enabled"`
+	State    string           `json:"state" yaml:"state"`
+	Result   string           `json:"result" yaml:"result"`
+	LastBoot *apps.BootReport `json:"last_boot" yaml:"last_boot"`
+}
+
+func buildOnBootStatusCmd() *cobra.Command {
+	var asJson bool
+	var asYaml bool
+
+	cmd := &cobra.Command{
+		Use:          "status",
+		SilenceUsage: true,
+		Short:        "Show the state of runapp-boot.service and the apps the last boot started",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			status := onBootStatus{
+				Unit:      common.SystemdSvcName,
+				Installed: util.FileExists(common.SystemdSvcPath),
+			}
+
+			if util.IsSystemd() {
+				properties, err := util.SystemdUnitProperties(common.SystemdSvcName, bootUnitProperties...)
+				if err != nil {
+					util.DebugLog("failed to read the state of %s: %v", common.SystemdSvcName, err)
+				} else {
+					status.Enabled = properties["UnitFileState"]
+					status.State = properties["ActiveState"]
+					if sub := properties["SubState"]; len(sub) != 0 {
+						status.State += " (" + sub + ")"
+					}
+					status.Result = properties["Result"]
+				}
+			}
+
+			report, err := apps.ReadBootReport()
+			if err != nil {
+				return err
+			}
+			status.LastBoot = report
+
+			if asJson {
+				b, err := json.Marshal(status)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				return nil
+			}
+
+			if asYaml {
+				b, err := yaml.Marshal(status)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				return nil
+			}
+
+			t := table.New(os.Stdout)
+			t.SetLineStyle(table.StyleBlue)
+			t.SetDividers(table.UnicodeRoundedDividers)
+
+			t.AddRow("Unit", status.Unit)
+			t.AddRow("Installed", formatInstalled(status.Installed))
+			if len(status.State) != 0 {
+				t.AddRow("Enabled", formatOptional(status.Enabled))
+				t.AddRow("State", status.State)
+				t.AddRow("Result", formatUnitResult(status.Result))
+			} else {
+				t.AddRow("State", tml.Sprintf("<yellow>unknown, systemd is not reachable</yellow>"))
+			}
+			if report == nil {
+				t.AddRow("Last boot", "never")
+			} else {
+				t.AddRow("Last boot", fmt.Sprintf("%s (took %s)", report.StartedAt.Format(time.RFC1123), formatDuration(report.FinishedAt.Sub(report.StartedAt))))
+				if len(report.Error) != 0 {
+					t.AddRow("Boot error", tml.Sprintf("<red>%s</red>", report.Error))
+				}
+			}
+			t.Render()
+
+			if report == nil || len(report.Apps) == 0 {
+				return nil
+			}
+
+			fmt.Println()
+			appsTable := table.New(os.Stdout)
+			appsTable.SetHeaders("App", "Result", "Error")
+			appsTable.SetHeaderStyle(table.StyleBold)
+			appsTable.SetLineStyle(table.StyleBlue)
+			appsTable.SetDividers(table.UnicodeRoundedDividers)
+			for _, app := range report.Apps {
+				appsTable.AddRow(app.Name, formatBootResult(app.Result), formatOptional(app.Error))
+			}
+			appsTable.Render()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJson, "json", false, "output as JSON")
+	cmd.Flags().BoolVar(&asYaml, "yaml", false, "output as YAML")
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml")
+
+	return cmd
+}
+
+func formatInstalled(installed bool) string {
+	if installed {
+		return tml.Sprintf("<green>yes</green> (%s)", common.SystemdSvcPath)
+	}
+	return tml.Sprintf("<yellow>no</yellow>, install it with <magenta>runapp install-onboot</magenta>")
+}
+
+func formatUnitResult(result string) string {
+	if result == "success" {
+		return tml.Sprintf("<green>%s</green>", result)
+	}
+	return tml.Sprintf("<red>%s</red>", formatOptional(result))
+}
+
+func formatBootResult(result apps.BootResult) string {
+	switch result {
+	case apps.BootResultStarted:
+		return tml.Sprintf("<green>%s</green>", string(result))
+	case apps.BootResultFailed:
+		return tml.Sprintf("<red>%s</red>", string(result))
+	}
+	return string(result)
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/testutil"
+)
+
+func TestOnBoot_UnknownSubcommand(t *testing.T) {
+	testutil.SetupHome(t)
+
+	// only the root command rejects unknown subcommands by itself
+	root := &cobra.Command{Use: "runapp", SilenceErrors: true}
+	root.AddCommand(buildOnBootCmd())
+	root.SetArgs([]string{"onboot", "stauts"})
+	require.Error(t, root.Execute())
+
+	report, err := apps.ReadBootReport()
+	require.NoError(t, err)
+	require.Nil(t, report, "expected no boot report to be written")
+}
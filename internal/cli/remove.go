@@ -3,7 +3,6 @@ package cli
 import (
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/0xB1a60/runapp/internal/util"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/runner"
 	"github.com/0xB1a60/runapp/internal/tui"
 )
 
@@ -70,9 +70,13 @@ func buildRemoveCmd() *cobra.Command {
 				if !doRemove {
 					return nil
 				}
-				killApp(cmd.Context(), app)
+				// runner.Remove stops the app first
+				runKillSpinner(func() {
+					err = runner.Remove(cmd.Context(), app)
+				})
+				return err
 			}
-			return os.RemoveAll(app.ConfigPath)
+			return runner.Remove(cmd.Context(), app)
 		},
 	}
 	return cmd
@@ -125,7 +129,7 @@ func buildRemoveManyCmd() *cobra.Command {
 					isFailed := removeAllFailed && app.Status == common.AppStatusFailed
 					isSuccess := removeAllSuccess && app.Status == common.AppStatusSuccess
 					if isFailed || isSuccess {
-						if err := os.RemoveAll(app.ConfigPath); err != nil {
+						if err := runner.Remove(cmd.Context(), &app); err != nil {
 							return err
 						}
 						fmt.Println(tml.Sprintf("<green>app: %s removed</green>", app.Name))
@@ -145,7 +149,7 @@ func buildRemoveManyCmd() *cobra.Command {
 
 			for _, app := range list {
 				if app.Status == *value {
-					if err := os.RemoveAll(app.ConfigPath); err != nil {
+					if err := runner.Remove(cmd.Context(), &app); err != nil {
 						return err
 					}
 					fmt.Println(tml.Sprintf("<green>app: %s removed</green>", app.Name))
@@ -1,24 +1,26 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"syscall"
+	"time"
 
-	"github.com/0xB1a60/runapp/internal/logs"
 	"github.com/liamg/tml"
 	"github.com/spf13/cobra"
 
 	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/runner"
+	"github.com/0xB1a60/runapp/internal/supervisor"
 	"github.com/0xB1a60/runapp/internal/tui"
 	"github.com/0xB1a60/runapp/internal/util"
 )
 
 func buildRestartCmd() *cobra.Command {
 	var skipLogs bool
+	var waitReady bool
+	var readyTimeout time.Duration
 
 	cmd := &cobra.Command{
 		Use:          "restart",
@@ -74,53 +76,45 @@ func buildRestartCmd() *cobra.Command {
 				if !doRestart {
 					return nil
 				}
-				killApp(cmd.Context(), app)
-			}
-
-			app.Status = common.AppStatusStarting
-			app.ExitCode = nil
-			app.PID = -1
-			if err := app.SaveToFile(); err != nil {
-				return err
-			}
-
-			if err := os.Remove(app.StderrPath); err != nil {
-				return err
+				if err := killApp(cmd.Context(), app); err != nil {
+					return err
+				}
 			}
 
-			if err := os.Remove(app.StdoutPath); err != nil {
+			if err := supervisor.Reset(app, common.TriggerRestart); err != nil {
 				return err
 			}
 
-			if err := runApp(*app); err != nil {
+			if err := runApp(cmd.Context(), *app); err != nil {
 				return err
 			}
-
-			if skipLogs {
-				return nil
-			}
-			return viewLogs(cmd.Context(), *app, logs.AllLogs)
+			return afterStart(cmd.Context(), *app, startOptions{skipLogs: skipLogs, waitReady: waitReady, readyTimeout: readyTimeout})
 		},
 	}
 	cmd.Flags().BoolVar(&skipLogs, "skip-logs", false, "skip logs streaming after restart")
+	addWaitReadyFlags(cmd, &waitReady, &readyTimeout)
 	return cmd
 }
 
-func runApp(app apps.App) error {
-	cmd := exec.Command(os.Args[0], "background", app.Name)
-	cmd.Env = os.Environ()
+// runApp starts supervising a created (or reset) app and reports who supervises it
+func runApp(ctx context.Context, app apps.App) error {
+	started, err := runner.Start(ctx, app)
+	return reportStarted(app.Name, started, err)
+}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = nil
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true, // start new session
+// reportStarted prints the started dependencies and who supervises the app, err is the error of starting it
+func reportStarted(name string, started runner.Started, err error) error {
+	for _, dep := range started.Dependencies {
+		fmt.Println(tml.Sprintf("dependency <italic>%s</italic> started", dep))
 	}
-
-	if err := cmd.Start(); err != nil {
+	if err != nil {
 		return err
 	}
 
-	fmt.Println(tml.Sprintf("<italic>%s</italic> started with PID: %d", app.Name, cmd.Process.Pid))
+	if started.ByDaemon {
+		fmt.Println(tml.Sprintf("<italic>%s</italic> started by the runapp daemon", name))
+		return nil
+	}
+	fmt.Println(tml.Sprintf("<italic>%s</italic> started with PID: %d", name, started.PID))
 	return nil
 }
@@ -5,14 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"path"
-	"regexp"
 	"strings"
-	"syscall"
 	"time"
 
-	"github.com/0xB1a60/runapp/internal/logs"
 	"github.com/charmbracelet/huh"
 	"github.com/goombaio/namegenerator"
 	"github.com/liamg/tml"
@@ -20,6 +15,7 @@ import (
 
 	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/runner"
 	"github.com/0xB1a60/runapp/internal/tui"
 	"github.com/0xB1a60/runapp/internal/util"
 )
@@ -31,6 +27,23 @@ func buildRunCmd() *cobra.Command {
 	var command string
 	var skipLogs bool
 	var skipSystemdWarning bool
+	var restartPolicy string
+	var restart apps.Restart
+	var logMaxSize string
+	var logRotation apps.LogRotation
+	var history apps.History
+	var labels []string
+	var dependsOn []string
+	var schedule apps.Schedule
+	var overlap string
+	var watch apps.Watch
+	var healthCheck apps.HealthCheck
+	var readiness apps.Readiness
+	var waitReady bool
+	var readyTimeout time.Duration
+	var limits apps.Limits
+	var memoryMax string
+	var core string
 
 	cmd := &cobra.Command{
 		Use:          "run",
@@ -38,6 +51,57 @@ func buildRunCmd() *cobra.Command {
 		Short:        "Run an app",
 		Args:         cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			restart.Policy = common.RestartPolicy(restartPolicy)
+
+			if len(logMaxSize) != 0 {
+				size, err := util.ParseSize(logMaxSize)
+				if err != nil {
+					return err
+				}
+				logRotation.MaxSize = apps.ByteSize(size)
+			}
+
+			if history.MaxRuns <= 0 {
+				return errors.New("history-max-runs must be a positive number")
+			}
+
+			appLabels, err := apps.ParseLabels(labels)
+			if err != nil {
+				return err
+			}
+
+			// the probes, schedule and watch are validated with the rest of the app once the name and command are known
+			var appReadiness *apps.Readiness
+			if !readiness.IsEmpty() {
+				appReadiness = &readiness
+			}
+
+			var appHealthCheck *apps.HealthCheck
+			if len(healthCheck.HTTP) != 0 || len(healthCheck.TCP) != 0 || len(healthCheck.Command) != 0 {
+				appHealthCheck = &healthCheck
+			}
+
+			var appSchedule *apps.Schedule
+			if len(schedule.Cron) != 0 {
+				if waitReady {
+					return errors.New("--wait-ready cannot be used with --schedule, the app only starts at its next run")
+				}
+				schedule.Overlap = common.OverlapPolicy(overlap)
+				appSchedule = &schedule
+			}
+
+			var appWatch *apps.Watch
+			if len(watch.Patterns) != 0 {
+				appWatch = &watch
+			} else if len(watch.Ignore) != 0 {
+				return errors.New("--watch-ignore requires --watch")
+			}
+
+			appLimits, err := buildLimits(limits, memoryMax, core)
+			if err != nil {
+				return err
+			}
+
 			var appName string
 			if len(args) != 0 {
 				appName = args[0]
@@ -58,7 +122,8 @@ func buildRunCmd() *cobra.Command {
 				return err
 			}
 
-			if isSystemdUsed {
+			// scheduled apps are started on boot anyway
+			if isSystemdUsed && appSchedule == nil {
 				if !runOnBoot {
 					if value, err := tui.OnBool("Do you want your app to start on boot?"); err != nil {
 						util.DebugLog("error while building on-boot: %s", err)
@@ -94,8 +159,53 @@ func buildRunCmd() *cobra.Command {
 				return err
 			}
 
+			runMode := common.RunModeOnce
+			if runOnBoot {
+				runMode = common.RunModeOnBoot
+			}
+			if appSchedule != nil {
+				runMode = common.RunModeScheduled
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			app := apps.App{
+				Name:        appName,
+				Mode:        runMode,
+				Command:     command,
+				CWD:         cwd,
+				Env:         os.Environ(),
+				Labels:      appLabels,
+				DependsOn:   dependsOn,
+				Schedule:    appSchedule,
+				Watch:       appWatch,
+				Restart:     restart,
+				LogRotation: logRotation,
+				History:     history,
+				Readiness:   appReadiness,
+				HealthCheck: appHealthCheck,
+				Limits:      appLimits,
+			}
+			if err := app.ValidateTemplate(); err != nil {
+				return err
+			}
+
+			if len(dependsOn) != 0 {
+				list, err := apps.List()
+				if err != nil {
+					return err
+				}
+				if err := apps.ValidateDependencies(list, app); err != nil {
+					return err
+				}
+			}
+
 			if existingApp, err := apps.Get(appName); err == nil {
-				if existingApp.IsRunning() && util.PidExists(existingApp.PID) {
+				// restarting and scheduled apps only have their background process between runs
+				if existingApp.IsRunning() && (util.PidExists(existingApp.PID) || existingApp.HasSupervisor()) {
 
 					doRestart, err := tui.OnBool("App is already running, do you want to kill it?")
 					if err != nil {
@@ -105,33 +215,94 @@ func buildRunCmd() *cobra.Command {
 					if !doRestart {
 						return nil
 					}
-					killApp(cmd.Context(), existingApp)
+					if err := killApp(cmd.Context(), existingApp); err != nil {
+						return err
+					}
 				}
 			}
-
-			runMode := common.RunModeOnce
-			if runOnBoot {
-				runMode = common.RunModeOnBoot
-			}
-
-			return createAndRunApp(cmd.Context(), appName, runMode, command, skipLogs)
+			return createAndRunApp(cmd.Context(), app, startOptions{skipLogs: skipLogs, waitReady: waitReady, readyTimeout: readyTimeout})
 		},
 	}
 	cmd.Flags().BoolVar(&runOnBoot, "start-on-boot", false, "automatically start the app on boot")
 	cmd.Flags().BoolVar(&skipLogs, "skip-logs", false, "skip logs streaming after start")
+	cmd.Flags().StringVar(&schedule.Cron, "schedule", "", "run the app at the times of a cron expression (e.g. '0 3 * * *', @daily or '@every 10m') instead of once")
+	cmd.Flags().StringVar(&overlap, "overlap", string(common.OverlapSkip),
+		fmt.Sprintf("what happens when a scheduled run is due while the previous run is still running (one of: %s, %s, %s)", common.OverlapSkip, common.OverlapQueue, common.OverlapAllow))
+	cmd.MarkFlagsMutuallyExclusive("schedule", "start-on-boot")
 
 	if isSystemdUsed {
 		cmd.Flags().BoolVar(&skipSystemdWarning, "skip-systemd-warning", false, "suppress warning if systemd service is not detected")
 	}
 
 	cmd.Flags().StringVar(&command, "command", "", "command that will be executed")
+	cmd.Flags().StringArrayVar(&watch.Patterns, "watch", nil, "restart the app when files matching this glob (relative to the current directory, e.g. 'src/**/*.go') change, can be repeated")
+	cmd.Flags().StringArrayVar(&watch.Ignore, "watch-ignore", nil, "ignore changes of files matching this glob (e.g. 'vendor/**'), can be repeated")
+	cmd.Flags().DurationVar(&watch.Debounce, "watch-debounce", apps.DefaultWatchDebounce, "time without changes after which the app is restarted")
+
+	cmd.Flags().StringArrayVar(&labels, "label", nil, "label in key=value format used to select apps, can be repeated")
+	cmd.Flags().StringSliceVar(&dependsOn, "depends-on", nil, "apps that are started and ready before this app, comma separated or repeated")
+
+	cmd.Flags().StringVar(&restartPolicy, "restart", string(common.RestartPolicyNever),
+		fmt.Sprintf("restart policy when the app exits (one of: %s, %s, %s)", common.RestartPolicyNever, common.RestartPolicyOnFailure, common.RestartPolicyAlways))
+	cmd.Flags().IntVar(&restart.MaxRetries, "max-retries", 0, "maximum consecutive restarts, 0 means unlimited")
+	cmd.Flags().DurationVar(&restart.BackoffBase, "backoff-base", apps.DefaultBackoffBase, "delay before the first restart, doubled on every next attempt")
+	cmd.Flags().DurationVar(&restart.BackoffCap, "backoff-cap", apps.DefaultBackoffCap, "maximum delay between restarts")
+	cmd.Flags().DurationVar(&restart.ResetWindow, "reset-window", apps.DefaultResetWindow, "uptime after which the restart count is reset")
+
+	cmd.Flags().StringVar(&logMaxSize, "log-max-size", "", "rotate stdout.log/stderr.log once they grow over this size (e.g. 10MB)")
+	cmd.Flags().DurationVar(&logRotation.MaxAge, "log-max-age", 0, "rotate stdout.log/stderr.log once they are older than this (e.g. 24h)")
+	cmd.Flags().IntVar(&logRotation.MaxFiles, "log-max-files", apps.DefaultLogMaxFiles, "amount of rotated log files to keep")
+	cmd.Flags().BoolVar(&logRotation.Compress, "log-compress", false, "gzip rotated log files")
+
+	cmd.Flags().IntVar(&history.MaxRuns, "history-max-runs", apps.DefaultHistoryMaxRuns, "amount of finished runs kept in the history")
+	cmd.Flags().BoolVar(&history.ArchiveLogs, "archive-logs", false, "keep a copy of the logs of every run, readable with runapp logs --run")
+
+	cmd.Flags().StringVar(&readiness.TCP, "ready-tcp", "", "the app is ready once host:port accepts connections")
+	cmd.Flags().StringVar(&readiness.HTTP, "ready-http", "", "the app is ready once the URL answers GET with 200")
+	cmd.Flags().StringVar(&readiness.LogPattern, "ready-log", "", "the app is ready once a line of its output matches this regular expression")
+	cmd.Flags().StringVar(&readiness.File, "ready-file", "", "the app is ready once this file exists (relative to the current directory)")
+	addWaitReadyFlags(cmd, &waitReady, &readyTimeout)
+
+	cmd.Flags().StringVar(&healthCheck.HTTP, "health-http", "", "health check URL requested with GET (e.g. http://localhost:8080/health)")
+	cmd.Flags().IntVar(&healthCheck.ExpectStatus, "health-status", apps.DefaultHealthExpectStatus, "status code expected from --health-http")
+	cmd.Flags().StringVar(&healthCheck.TCP, "health-tcp", "", "health check host:port that must accept connections")
+	cmd.Flags().StringVar(&healthCheck.Command, "health-cmd", "", "health check command that must exit with 0")
+	cmd.Flags().DurationVar(&healthCheck.Interval, "health-interval", apps.DefaultHealthInterval, "time between health checks")
+	cmd.Flags().DurationVar(&healthCheck.Timeout, "health-timeout", apps.DefaultHealthTimeout, "time after which a health check fails")
+	cmd.Flags().IntVar(&healthCheck.FailureThreshold, "health-retries", apps.DefaultHealthFailureThreshold, "consecutive failed health checks after which the app is unhealthy")
+	cmd.Flags().BoolVar(&healthCheck.RestartOnUnhealthy, "health-restart", false, "stop an unhealthy app as failed so it is restarted according to --restart")
+	cmd.MarkFlagsMutuallyExclusive("health-http", "health-tcp", "health-cmd")
+
+	cmd.Flags().StringVar(&memoryMax, "memory-max", "", "memory the app may use before the kernel kills it (e.g. 512MB), needs a delegated cgroup v2 subtree")
+	cmd.Flags().IntVar(&limits.CPUQuota, "cpu-quota", 0, "cpu time the app may use in percent of one CPU (e.g. 50, 200 for two CPUs), needs a delegated cgroup v2 subtree")
+	cmd.Flags().IntVar(&limits.PidsMax, "pids-max", 0, "amount of processes and threads the app may have, needs a delegated cgroup v2 subtree")
+	cmd.Flags().Uint64Var(&limits.NoFile, "nofile", 0, "amount of files the app may have open (RLIMIT_NOFILE)")
+	cmd.Flags().StringVar(&core, "core", "", "max size of core dumps (RLIMIT_CORE, e.g. 0 to disable them or unlimited)")
 	return cmd
 }
 
-const (
-	nameMinLength = 2
-	nameMaxLength = 100
-)
+// buildLimits returns the limits set with the run flags, nil without any
+func buildLimits(limits apps.Limits, memoryMax string, core string) (*apps.Limits, error) {
+	if len(memoryMax) != 0 {
+		size, err := util.ParseSize(memoryMax)
+		if err != nil {
+			return nil, err
+		}
+		limits.MemoryMax = apps.ByteSize(size)
+	}
+	if len(core) != 0 {
+		size, err := apps.ParseLimitSize(core)
+		if err != nil {
+			return nil, err
+		}
+		limits.Core = &size
+	}
+
+	if limits.IsEmpty() {
+		return nil, nil
+	}
+	return &limits, nil
+}
 
 func nameTextInput() (*string, error) {
 	seed := time.Now().UTC().UnixNano()
@@ -144,7 +315,7 @@ func nameTextInput() (*string, error) {
 		huh.NewText().
 			Title("Name:").
 			Placeholder(placeholder).
-			CharLimit(nameMaxLength).
+			CharLimit(apps.NameMaxLength).
 			Lines(1).
 			Validate(nameValidateFunc).
 			Value(&value),
@@ -163,20 +334,7 @@ func nameValidateFunc(value string) error {
 	if len(value) == 0 {
 		return nil
 	}
-
-	if len(value) < nameMinLength {
-		return fmt.Errorf("name must be at least %d characters long", nameMinLength)
-	}
-
-	if len(value) > nameMaxLength {
-		return fmt.Errorf("name must be less than %d characters long", nameMaxLength)
-	}
-
-	match, _ := regexp.MatchString("^[a-z0-9_-]+$", value)
-	if !match {
-		return errors.New("name must only contain lowercase, alphanumeric and -_")
-	}
-	return nil
+	return apps.ValidateName(value)
 }
 
 func commandText() (*string, error) {
@@ -201,87 +359,13 @@ func commandValidateFunc(value string) error {
 	return nil
 }
 
-func createAndRunApp(ctx context.Context, name string, mode common.RunMode, command string, skipLogs bool) error {
-	util.DebugLog("Starting: %s with mode: %s and command: %s", name, string(mode), command)
-
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	homeDir, err := util.HomeDirPath()
-	if err != nil {
-		return err
-	}
-
-	runDir := path.Join(homeDir, name)
-	if err := os.RemoveAll(runDir); err != nil {
-		return err
-	}
-
-	if err := os.MkdirAll(runDir, os.ModePerm); err != nil {
-		return err
-	}
+// createAndRunApp creates a fresh app from the given template (name, mode, command, cwd, env and restart policy) and starts it
+func createAndRunApp(ctx context.Context, app apps.App, opts startOptions) error {
+	util.DebugLog("Starting: %s with mode: %s and command: %s", app.Name, string(app.Mode), app.Command)
 
-	stdoutPath := path.Join(runDir, common.FileStdOut)
-	stdoutFile, err := os.Create(stdoutPath)
-	if err != nil {
+	created, started, err := runner.Create(ctx, app)
+	if err := reportStarted(app.Name, started, err); err != nil {
 		return err
 	}
-	if err := stdoutFile.Close(); err != nil {
-		return err
-	}
-
-	stdErrPath := path.Join(runDir, common.FileStdErr)
-	stderrFile, err := os.Create(stdErrPath)
-	if err != nil {
-		return err
-	}
-	if err := stderrFile.Close(); err != nil {
-		return err
-	}
-
-	app := apps.App{
-		Name:       name,
-		Mode:       mode,
-		Status:     common.AppStatusStarting,
-		Command:    command,
-		PID:        -1,
-		CWD:        cwd,
-		Env:        os.Environ(),
-		ConfigPath: runDir,
-		StderrPath: stdErrPath,
-		StdoutPath: stdoutPath,
-	}
-
-	if err := app.SaveToFile(); err != nil {
-		return err
-	}
-
-	cmd := exec.Command(os.Args[0], "background", name)
-	cmd.Env = os.Environ()
-
-	if util.IsDebug() {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = nil
-		cmd.Stderr = nil
-	}
-
-	cmd.Stdin = nil
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true, // start new session
-	}
-
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	fmt.Println(tml.Sprintf("<italic>%s</italic> started with PID: %d", app.Name, cmd.Process.Pid))
-
-	if skipLogs {
-		return nil
-	}
-	return viewLogs(ctx, app, logs.AllLogs)
+	return afterStart(ctx, *created, opts)
 }
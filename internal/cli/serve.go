@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/web"
+)
+
+const (
+	defaultListenAddr = "127.0.0.1:8080"
+)
+
+func buildServeCmd() *cobra.Command {
+	var listenAddr string
+	var token string
+
+	cmd := &cobra.Command{
+		Use:          "serve",
+		SilenceUsage: true,
+		Short:        "Serve a web dashboard and REST API to see and control the apps",
+		Long: "Serve a web dashboard and REST API to see and control the apps.\n" +
+			"Every request needs the token, as a bearer token or from the cookie set by opening the dashboard with ?token=...\n" +
+			"The token is read from --token or " + web.TokenEnv + ", a random one is generated when neither is set.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(token) == 0 {
+				token = os.Getenv(web.TokenEnv)
+			}
+
+			generated := len(token) == 0
+			if generated {
+				var err error
+				token, err = web.GenerateToken()
+				if err != nil {
+					return err
+				}
+			}
+
+			listener, err := net.Listen("tcp", listenAddr)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
+
+			url := "http://" + listener.Addr().String()
+			fmt.Println(tml.Sprintf("<yellow>▶ runapp dashboard listening on %s</yellow>", url))
+			if generated {
+				fmt.Println(tml.Sprintf("Open <bold>%s/?token=%s</bold> to log in", url, token))
+			} else {
+				fmt.Println(tml.Sprintf("Open <bold>%s/?token=...</bold> with your token to log in", url))
+			}
+
+			if err := web.NewServer(token).Serve(ctx, listener); err != nil {
+				return err
+			}
+			fmt.Println("runapp dashboard stopped")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&listenAddr, "listen", defaultListenAddr, "address to listen on, e.g. 0.0.0.0:8080 to allow remote access")
+	cmd.Flags().StringVar(&token, "token", "", "token required by every request (default from "+web.TokenEnv+" or random)")
+	return cmd
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -17,8 +18,33 @@ import (
 	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/common"
 	"github.com/0xB1a60/runapp/internal/tui"
+	"github.com/0xB1a60/runapp/internal/util"
 )
 
+const (
+	statusSampleInterval = 250 * time.Millisecond
+)
+
+// appStatus is the JSON/YAML output of status, usage is only set for running apps
+type appStatus struct {
+	apps.App `yaml:",inline"`
+	Usage    *util.Usage `json:"usage,omitempty" yaml:"usage,omitempty"`
+}
+
+// appUsage measures the resource usage of the app process tree, nil if the app is not running
+func appUsage(app apps.App) *util.Usage {
+	if !app.HasProcess() || !util.PidExists(app.PID) {
+		return nil
+	}
+
+	usage, err := util.SampleUsage(app.PID, statusSampleInterval)
+	if err != nil {
+		util.DebugLog("failed to sample usage of %s: %v", app.Name, err)
+		return nil
+	}
+	return &usage
+}
+
 func buildStatusCmd() *cobra.Command {
 	var asJson bool
 	var asYaml bool
@@ -111,8 +137,10 @@ func buildStatusCmd() *cobra.Command {
 				return err
 			}
 
+			usage := appUsage(*app)
+
 			if asJson {
-				b, err := json.Marshal(app)
+				b, err := json.Marshal(appStatus{App: *app, Usage: usage})
 				if err != nil {
 					return err
 				}
@@ -121,7 +149,7 @@ func buildStatusCmd() *cobra.Command {
 			}
 
 			if asYaml {
-				b, err := yaml.Marshal(app)
+				b, err := yaml.Marshal(appStatus{App: *app, Usage: usage})
 				if err != nil {
 					return err
 				}
@@ -138,14 +166,70 @@ func buildStatusCmd() *cobra.Command {
 			t.AddRow("Status", formatStatus(app.Status, app.ExitCode))
 			t.AddRow("Mode", common.PrettyRunMode[app.Mode])
 			t.AddRow("PID", strconv.Itoa(app.PID))
+			if len(app.TriggeredBy) != 0 {
+				t.AddRow("Triggered by", string(app.TriggeredBy))
+			}
+			if usage != nil {
+				t.AddRow("CPU", formatCPU(usage.CPUPercent))
+				t.AddRow("Memory", util.HumanSize(usage.RSS))
+				t.AddRow("Threads", strconv.Itoa(int(usage.Threads)))
+				t.AddRow("Open files", formatFDs(usage.FDs))
+				t.AddRow("Processes", strconv.Itoa(usage.Processes))
+				t.AddRow("Uptime", usage.Uptime.String())
+			}
 			if app.StartedAt != nil {
 				t.AddRow("Started at", app.StartedAt.Format(time.RFC1123))
 			}
+			if app.Readiness != nil && app.ReadyAt != nil {
+				t.AddRow("Ready at", app.ReadyAt.Format(time.RFC1123))
+			}
 			if app.FinishedAt != nil {
 				t.AddRow("Finished at", app.FinishedAt.Format(time.RFC1123))
 			}
+			if app.Restart.Policy != "" && app.Restart.Policy != common.RestartPolicyNever {
+				t.AddRow("Restart policy", formatRestartPolicy(app.Restart))
+				t.AddRow("Restarts", strconv.Itoa(app.RestartCount))
+			}
+			if app.NextRestartAt != nil {
+				t.AddRow("Next restart at", app.NextRestartAt.Format(time.RFC1123))
+			}
+			if app.Schedule != nil {
+				t.AddRow("Schedule", app.Schedule.String())
+				t.AddRow("Last run", formatLastRun(*app))
+			}
+			if app.NextRunAt != nil {
+				t.AddRow("Next run at", app.NextRunAt.Format(time.RFC1123))
+			}
+			if app.Readiness != nil {
+				t.AddRow("Readiness", formatReadiness(*app.Readiness))
+			}
+			if app.HealthCheck != nil {
+				t.AddRow("Health check", formatHealthCheck(*app.HealthCheck))
+			}
+			if len(app.HealthError) != 0 {
+				t.AddRow("Health error", tml.Sprintf("<red>%s</red>", app.HealthError))
+			}
+			if app.Limits != nil {
+				t.AddRow("Limits", app.Limits.String())
+			}
+			if app.OOMKilled || (app.Limits != nil && app.Limits.MemoryMax != 0) {
+				t.AddRow("OOM killed", formatOOMKilled(app.OOMKilled))
+			}
 			t.AddRow("Command", app.Command)
 			t.AddRow("CWD", app.CWD)
+			if len(app.Labels) != 0 {
+				t.AddRow("Labels", apps.FormatLabels(app.Labels))
+			}
+			if len(app.DependsOn) != 0 {
+				t.AddRow("Depends on", strings.Join(app.DependsOn, ", "))
+			}
+			if app.Watch != nil {
+				t.AddRow("Watch", app.Watch.String())
+			}
+			if app.LogRotation.Enabled() {
+				t.AddRow("Log rotation", formatLogRotation(app.LogRotation))
+			}
+			t.AddRow("History", formatHistory(app.History))
 			t.AddRow("Stdout", app.StdoutPath)
 			t.AddRow("Stderr", app.StderrPath)
 			t.AddRow("Env", formatEnv(app.Env))
@@ -163,6 +247,13 @@ func buildStatusCmd() *cobra.Command {
 	return cmd
 }
 
+func formatOOMKilled(oomKilled bool) string {
+	if oomKilled {
+		return tml.Sprintf("<red>yes</red>, the last run went over its memory limit")
+	}
+	return "no"
+}
+
 func formatEnv(values []string) string {
 	var res strings.Builder
 	for _, value := range values {
@@ -171,3 +262,65 @@ func formatEnv(values []string) string {
 	}
 	return res.String()
 }
+
+func formatReadiness(readiness apps.Readiness) string {
+	var probes []string
+	if len(readiness.TCP) != 0 {
+		probes = append(probes, "TCP "+readiness.TCP)
+	}
+	if len(readiness.HTTP) != 0 {
+		probes = append(probes, "HTTP GET "+readiness.HTTP)
+	}
+	if len(readiness.LogPattern) != 0 {
+		probes = append(probes, fmt.Sprintf("log matches %q", readiness.LogPattern))
+	}
+	if len(readiness.File) != 0 {
+		probes = append(probes, "file "+readiness.File+" exists")
+	}
+	return strings.Join(probes, " and ")
+}
+
+func formatHealthCheck(check apps.HealthCheck) string {
+	check = check.WithDefaults()
+	res := fmt.Sprintf("%s (every %s, timeout: %s, unhealthy after %d failures)",
+		check, check.Interval, check.Timeout, check.FailureThreshold)
+	if len(check.HTTP) != 0 && check.ExpectStatus != apps.DefaultHealthExpectStatus {
+		res = fmt.Sprintf("%s expecting %d", res, check.ExpectStatus)
+	}
+	if check.RestartOnUnhealthy {
+		res += ", restarted when unhealthy"
+	}
+	return res
+}
+
+func formatRestartPolicy(restart apps.Restart) string {
+	maxRetries := "unlimited"
+	if restart.MaxRetries > 0 {
+		maxRetries = strconv.Itoa(restart.MaxRetries)
+	}
+	return fmt.Sprintf("%s (max retries: %s, backoff: %s-%s, reset after: %s)",
+		restart.Policy, maxRetries, restart.Backoff(0), restart.Backoff(math.MaxInt), restart.ResetAfter())
+}
+
+func formatHistory(history apps.History) string {
+	res := fmt.Sprintf("keep: %d runs", history.Limit())
+	if history.ArchiveLogs {
+		res += ", logs archived"
+	}
+	return res
+}
+
+func formatLogRotation(rotation apps.LogRotation) string {
+	var res []string
+	if rotation.MaxSize > 0 {
+		res = append(res, "max size: "+rotation.MaxSize.String())
+	}
+	if rotation.MaxAge > 0 {
+		res = append(res, "max age: "+rotation.MaxAge.String())
+	}
+	res = append(res, "keep: "+strconv.Itoa(rotation.Keep()))
+	if rotation.Compress {
+		res = append(res, "gzip")
+	}
+	return strings.Join(res, ", ")
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aquasecurity/table"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	TopSortCPU    = "cpu"
+	TopSortMemory = "mem"
+
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+var ValidTopSorts = []string{TopSortCPU, TopSortMemory}
+
+type topRow struct {
+	app   apps.App
+	usage util.Usage
+}
+
+func buildTopCmd() *cobra.Command {
+	var sortBy string
+	var interval time.Duration
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:          "top",
+		SilenceUsage: true,
+		Short:        "Show a live table of the resource usage of running apps",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(ValidTopSorts, sortBy) {
+				return fmt.Errorf("sort must be one of: %s", strings.Join(ValidTopSorts, ", "))
+			}
+			if interval <= 0 {
+				return errors.New("interval must be positive")
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			sampler := util.NewUsageSampler()
+
+			// the first sample is the average since each app started, start from a short live sample instead
+			if _, err := sampleRunningApps(sampler); err != nil {
+				return err
+			}
+			time.Sleep(statusSampleInterval)
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				rows, err := sampleRunningApps(sampler)
+				if err != nil {
+					return err
+				}
+				sortTopRows(rows, sortBy)
+
+				if !once {
+					fmt.Print(clearScreen)
+					fmt.Println(tml.Sprintf("<bold>runapp top</bold> - %d running, refreshed every %s, sorted by %s. Press CTRL+C to exit", len(rows), interval, sortBy))
+				}
+				renderTop(os.Stdout, rows)
+
+				if once {
+					return nil
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&sortBy, "sort", TopSortCPU, fmt.Sprintf("sort the apps by (one of: %s)", strings.Join(ValidTopSorts, ", ")))
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "refresh interval")
+	cmd.Flags().BoolVar(&once, "once", false, "print the table once and exit")
+	return cmd
+}
+
+// sampleRunningApps measures the usage of every running app, apps that exit while being measured are left out
+func sampleRunningApps(sampler *util.UsageSampler) ([]topRow, error) {
+	list, err := apps.List()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]topRow, 0, len(list))
+	for _, app := range list {
+		if !app.HasProcess() {
+			continue
+		}
+
+		usage, err := sampler.Sample(app.PID)
+		if err != nil {
+			util.DebugLog("failed to sample usage of %s: %v", app.Name, err)
+			continue
+		}
+		res = append(res, topRow{app: app, usage: usage})
+	}
+	return res, nil
+}
+
+// sortTopRows sorts the rows by the highest usage first, ties are sorted by name
+func sortTopRows(rows []topRow, sortBy string) {
+	slices.SortStableFunc(rows, func(a, b topRow) int {
+		var res int
+		switch sortBy {
+		case TopSortCPU:
+			res = cmp.Compare(b.usage.CPUPercent, a.usage.CPUPercent)
+		case TopSortMemory:
+			res = cmp.Compare(b.usage.RSS, a.usage.RSS)
+		}
+		if res != 0 {
+			return res
+		}
+		return strings.Compare(a.app.Name, b.app.Name)
+	})
+}
+
+func renderTop(w io.Writer, rows []topRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "🤖 No running apps") // no lint // handling this error is not needed
+		return
+	}
+
+	t := table.New(w)
+
+	t.SetHeaders("Name", "PID", "CPU", "Memory", "Threads", "Open files", "Processes", "Uptime")
+	t.SetHeaderStyle(table.StyleBold)
+	t.SetLineStyle(table.StyleBlue)
+	t.SetDividers(table.UnicodeRoundedDividers)
+
+	for _, row := range rows {
+		t.AddRow(row.app.Name, strconv.Itoa(row.app.PID), formatCPU(row.usage.CPUPercent), util.HumanSize(row.usage.RSS),
+			strconv.Itoa(int(row.usage.Threads)), formatFDs(row.usage.FDs), strconv.Itoa(row.usage.Processes), row.usage.Uptime.String())
+	}
+
+	t.Render()
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+func TestSortTopRows(t *testing.T) {
+	rows := []topRow{
+		{app: apps.App{Name: "idle"}, usage: util.Usage{CPUPercent: 0, RSS: 300}},
+		{app: apps.App{Name: "busy"}, usage: util.Usage{CPUPercent: 150, RSS: 100}},
+		{app: apps.App{Name: "also-idle"}, usage: util.Usage{CPUPercent: 0, RSS: 200}},
+	}
+
+	names := func() []string {
+		res := make([]string, 0, len(rows))
+		for _, row := range rows {
+			res = append(res, row.app.Name)
+		}
+		return res
+	}
+
+	sortTopRows(rows, TopSortCPU)
+	require.Equal(t, []string{"busy", "also-idle", "idle"}, names())
+
+	sortTopRows(rows, TopSortMemory)
+	require.Equal(t, []string{"idle", "also-idle", "busy"}, names())
+}
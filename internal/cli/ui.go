@@ -0,0 +1,536 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fsnotify/fsnotify"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/runner"
+	"github.com/0xB1a60/runapp/internal/tui"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	uiLogTail = 200
+	// uiRefreshPeriod catches apps that died without updating their config.json
+	uiRefreshPeriod = 2 * time.Second
+	uiMinLogLines   = 5
+)
+
+var (
+	uiTitleStyle    = lipgloss.NewStyle().Bold(true)
+	uiHeaderStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+	uiSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	uiPaneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("4"))
+	uiHelpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+func buildUICmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "ui",
+		SilenceUsage: true,
+		Short:        "Full-screen dashboard with the status and logs of all apps",
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			homeDir, err := util.HomeDirPath()
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(homeDir, os.ModePerm); err != nil {
+				return err
+			}
+
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				return err
+			}
+			defer func(watcher *fsnotify.Watcher) {
+				if err := watcher.Close(); err != nil {
+					util.DebugLog("failed to close watcher: %v", err)
+				}
+			}(watcher)
+
+			// the home dir reports created and removed apps, the app dirs report config.json changes
+			if err := watcher.Add(homeDir); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			model := newUIModel(ctx, homeDir, watcher)
+			if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+				if errors.Is(err, tea.ErrProgramKilled) {
+					return nil
+				}
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+type uiAppsMsg struct {
+	list []apps.App
+	err  error
+}
+
+type uiConfigChangedMsg struct{}
+
+type uiTickMsg struct{}
+
+// uiLogMsg carries a line of the app selected when the stream was started, gen drops lines of previous selections
+type uiLogMsg struct {
+	gen int
+	log logs.Log
+}
+
+type uiLogErrMsg struct {
+	gen int
+	err error
+}
+
+type uiActionMsg struct {
+	message string
+	err     error
+	// refollow restarts the log stream, the logs of a restarted app are recreated
+	refollow bool
+}
+
+type uiModel struct {
+	ctx     context.Context
+	homeDir string
+	watcher *fsnotify.Watcher
+
+	list     []apps.App
+	selected string
+
+	logGen    int
+	logName   string
+	logCancel context.CancelFunc
+	logStream <-chan logs.Log
+	logLines  []string
+
+	confirmRemove bool
+	message       string
+
+	width  int
+	height int
+}
+
+func newUIModel(ctx context.Context, homeDir string, watcher *fsnotify.Watcher) *uiModel {
+	return &uiModel{ctx: ctx, homeDir: homeDir, watcher: watcher}
+}
+
+func (m *uiModel) Init() tea.Cmd {
+	return tea.Batch(loadApps, m.waitConfigChange(), uiTick())
+}
+
+func loadApps() tea.Msg {
+	list, err := apps.List()
+	return uiAppsMsg{list: list, err: err}
+}
+
+func uiTick() tea.Cmd {
+	return tea.Tick(uiRefreshPeriod, func(time.Time) tea.Msg {
+		return uiTickMsg{}
+	})
+}
+
+func (m *uiModel) waitConfigChange() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		for {
+			select {
+			case event, ok := <-m.watcher.Events:
+				if !ok {
+					return nil
+				}
+				if event.Has(fsnotify.Chmod) {
+					continue
+				}
+				// logs are written next to config.json, only the config and the app dirs change the list
+				if path.Base(event.Name) == common.FileConfig || path.Dir(event.Name) == path.Clean(m.homeDir) {
+					return uiConfigChangedMsg{}
+				}
+			case err, ok := <-m.watcher.Errors:
+				if !ok {
+					return nil
+				}
+				util.DebugLog("watcher error: %v", err)
+			case <-m.ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+func (m *uiModel) waitLog() tea.Cmd {
+	gen, stream := m.logGen, m.logStream
+	if stream == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case log := <-stream:
+			return uiLogMsg{gen: gen, log: log}
+		case <-m.ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (m *uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	case uiAppsMsg:
+		if msg.err != nil {
+			m.message = tml.Sprintf("<red>%s</red>", msg.err.Error())
+			return m, nil
+		}
+		m.setApps(msg.list)
+		return m, m.followSelected()
+	case uiConfigChangedMsg:
+		return m, tea.Batch(loadApps, m.waitConfigChange())
+	case uiTickMsg:
+		return m, tea.Batch(loadApps, uiTick())
+	case uiLogMsg:
+		if msg.gen != m.logGen {
+			return m, nil
+		}
+		m.appendLog(logs.FormatLog(msg.log, false))
+		return m, m.waitLog()
+	case uiLogErrMsg:
+		if msg.gen == m.logGen {
+			m.message = tml.Sprintf("<red>failed to read logs: %s</red>", msg.err.Error())
+		}
+		return m, nil
+	case uiActionMsg:
+		m.message = msg.message
+		if msg.err != nil {
+			m.message = tml.Sprintf("<red>%s</red>", msg.err.Error())
+		}
+		if msg.refollow {
+			m.stopLogs()
+			m.logName = ""
+		}
+		return m, loadApps
+	}
+	return m, nil
+}
+
+func (m *uiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirmRemove {
+		m.confirmRemove = false
+		m.message = ""
+		if msg.String() != "y" {
+			return m, nil
+		}
+		app, ok := m.selectedApp()
+		if !ok {
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Removing %s...", app.Name)
+		return m, m.action(func(ctx context.Context) (string, error) {
+			if err := runner.Remove(ctx, &app); err != nil {
+				return "", err
+			}
+			return tml.Sprintf("<green>%s removed</green>", app.Name), nil
+		})
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		m.stopLogs()
+		return m, tea.Quit
+	case "up":
+		m.moveSelection(-1)
+		return m, m.followSelected()
+	case "down", "tab":
+		m.moveSelection(1)
+		return m, m.followSelected()
+	case "r":
+		app, ok := m.selectedApp()
+		if !ok {
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Restarting %s...", app.Name)
+		return m, func() tea.Msg {
+			if _, err := runner.Restart(m.ctx, &app); err != nil {
+				return uiActionMsg{err: err}
+			}
+			return uiActionMsg{message: tml.Sprintf("<green>%s restarted</green>", app.Name), refollow: true}
+		}
+	case "k":
+		app, ok := m.selectedApp()
+		if !ok {
+			return m, nil
+		}
+		if !app.IsRunning() {
+			m.message = tml.Sprintf("<red>%s is not running</red>", app.Name)
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Killing %s...", app.Name)
+		// the kill spinner cannot draw inside the dashboard, stop the app the same way without it
+		return m, m.action(func(ctx context.Context) (string, error) {
+			stopped, err := runner.StopDependents(ctx, &app)
+			if err != nil {
+				return "", err
+			}
+			if err := runner.Stop(ctx, &app); err != nil {
+				return "", err
+			}
+			if len(stopped) != 0 {
+				return tml.Sprintf("<green>%s killed 💀</green> (and its dependents: %s)", app.Name, strings.Join(stopped, ", ")), nil
+			}
+			return tml.Sprintf("<green>%s killed 💀</green>", app.Name), nil
+		})
+	case "d":
+		app, ok := m.selectedApp()
+		if !ok {
+			return m, nil
+		}
+		m.confirmRemove = true
+		m.message = tml.Sprintf("<yellow>Remove %s and its logs? (y/n)</yellow>", app.Name)
+		return m, nil
+	case "n":
+		// the run prompts need the terminal, the dashboard is restored once they are done
+		cmd := exec.Command(os.Args[0], "run", "--skip-logs")
+		return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+			if err != nil {
+				return uiActionMsg{err: err}
+			}
+			return uiActionMsg{}
+		})
+	}
+	return m, nil
+}
+
+func (m *uiModel) action(fn func(ctx context.Context) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		message, err := fn(m.ctx)
+		return uiActionMsg{message: message, err: err}
+	}
+}
+
+// setApps keeps the selected app by name, the first app is selected when it is gone
+func (m *uiModel) setApps(list []apps.App) {
+	m.list = list
+	for _, app := range list {
+		if m.watcher != nil {
+			// adding a watched dir again is a no-op
+			if err := m.watcher.Add(app.ConfigPath); err != nil {
+				util.DebugLog("failed to watch %s: %v", app.ConfigPath, err)
+			}
+		}
+		if app.Name == m.selected {
+			return
+		}
+	}
+
+	m.selected = ""
+	if len(list) != 0 {
+		m.selected = list[0].Name
+	}
+}
+
+func (m *uiModel) selectedIndex() int {
+	for i, app := range m.list {
+		if app.Name == m.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *uiModel) selectedApp() (apps.App, bool) {
+	idx := m.selectedIndex()
+	if idx == -1 {
+		return apps.App{}, false
+	}
+	return m.list[idx], true
+}
+
+func (m *uiModel) moveSelection(delta int) {
+	if len(m.list) == 0 {
+		return
+	}
+	idx := (m.selectedIndex() + delta + len(m.list)) % len(m.list)
+	m.selected = m.list[idx].Name
+}
+
+// followSelected starts streaming the logs of the selected app when the selection changed
+func (m *uiModel) followSelected() tea.Cmd {
+	if m.selected == m.logName {
+		return nil
+	}
+
+	m.stopLogs()
+	m.logName = m.selected
+	m.logLines = nil
+
+	app, ok := m.selectedApp()
+	if !ok {
+		return nil
+	}
+
+	opts := logs.DefaultOptions()
+	opts.Tail = uiLogTail
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	stream, err := logs.Stream(ctx, app, opts)
+	if err != nil {
+		cancel()
+		gen := m.logGen
+		return func() tea.Msg {
+			return uiLogErrMsg{gen: gen, err: err}
+		}
+	}
+
+	m.logCancel = cancel
+	m.logStream = stream
+	return m.waitLog()
+}
+
+func (m *uiModel) stopLogs() {
+	m.logGen++
+	m.logStream = nil
+	if m.logCancel != nil {
+		m.logCancel()
+		m.logCancel = nil
+	}
+}
+
+func (m *uiModel) appendLog(line string) {
+	m.logLines = append(m.logLines, line)
+	if len(m.logLines) > uiLogTail {
+		m.logLines = m.logLines[len(m.logLines)-uiLogTail:]
+	}
+}
+
+func (m *uiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(uiTitleStyle.Render("runapp"))
+	b.WriteString("\n\n")
+
+	table := m.renderApps()
+	b.WriteString(table)
+	b.WriteString("\n")
+
+	help := uiHelpStyle.Render("↑/↓ select • r restart • k kill • d remove • n new • q quit")
+	footer := help
+	if len(m.message) != 0 {
+		footer = m.message + "\n" + help
+	}
+
+	// title, blank line, table, pane border and footer
+	used := 2 + lipgloss.Height(table) + 2 + lipgloss.Height(footer)
+	logHeight := max(m.height-used, uiMinLogLines)
+	b.WriteString(m.renderLogs(logHeight))
+	b.WriteString("\n")
+	b.WriteString(footer)
+	return b.String()
+}
+
+func (m *uiModel) renderApps() string {
+	if len(m.list) == 0 {
+		return common.NoAppsMessage
+	}
+
+	headers := []string{"Name", "Status", "Mode", "PID", "Restarts"}
+	rows := make([][]string, 0, len(m.list))
+	for _, app := range m.list {
+		rows = append(rows, []string{
+			tui.FormatName(app),
+			formatStatus(app.Status, app.ExitCode),
+			common.PrettyRunMode[app.Mode],
+			strconv.Itoa(app.PID),
+			formatRestarts(app.RestartCount, app.NextRestartAt),
+		})
+	}
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = lipgloss.Width(header)
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			widths[i] = max(widths[i], lipgloss.Width(value))
+		}
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	lines = append(lines, "  "+uiHeaderStyle.Render(joinColumns(headers, widths)))
+	selected := m.selectedIndex()
+	for i, row := range rows {
+		line := joinColumns(row, widths)
+		if i == selected {
+			line = uiSelectedStyle.Render("▶") + " " + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinColumns(values []string, widths []int) string {
+	var b strings.Builder
+	for i, value := range values {
+		b.WriteString(value)
+		if i != len(values)-1 {
+			b.WriteString(strings.Repeat(" ", widths[i]-lipgloss.Width(value)+2))
+		}
+	}
+	return b.String()
+}
+
+func (m *uiModel) renderLogs(height int) string {
+	innerWidth := max(m.width-2, 1)
+	lineStyle := lipgloss.NewStyle().MaxWidth(innerWidth)
+
+	title := "No app selected"
+	if len(m.logName) != 0 {
+		title = fmt.Sprintf("Logs of %s", m.logName)
+	}
+
+	lines := m.logLines
+	if len(lines) > height-1 {
+		lines = lines[len(lines)-(height-1):]
+	}
+
+	content := make([]string, 0, height)
+	content = append(content, uiTitleStyle.Render(title))
+	for _, line := range lines {
+		content = append(content, lineStyle.Render(line))
+	}
+	for len(content) < height {
+		content = append(content, "")
+	}
+	return uiPaneStyle.Width(innerWidth).Render(strings.Join(content, "\n"))
+}
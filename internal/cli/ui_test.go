@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/testutil"
+)
+
+func TestUIModelSelection(t *testing.T) {
+	testutil.SetupHome(t)
+
+	m := newUIModel(context.Background(), "", nil)
+	list := []apps.App{
+		{Name: "api", Status: common.AppStatusRunning},
+		{Name: "web", Status: common.AppStatusSuccess},
+		{Name: "worker", Status: common.AppStatusFailed},
+	}
+
+	m.Update(uiAppsMsg{list: list})
+	require.Equal(t, "api", m.selected)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	require.Equal(t, "worker", m.selected)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	require.Equal(t, "web", m.selected)
+
+	// the selection follows the app, not its position
+	m.Update(uiAppsMsg{list: []apps.App{list[1], list[2]}})
+	require.Equal(t, "web", m.selected)
+
+	m.Update(uiAppsMsg{list: []apps.App{list[2]}})
+	require.Equal(t, "worker", m.selected)
+
+	m.Update(uiAppsMsg{list: nil})
+	require.Empty(t, m.selected)
+}
+
+func TestUIModelConfirmRemove(t *testing.T) {
+	testutil.SetupHome(t)
+
+	m := newUIModel(context.Background(), "", nil)
+	m.Update(uiAppsMsg{list: []apps.App{{Name: "api", Status: common.AppStatusSuccess}}})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	require.True(t, m.confirmRemove)
+	require.Contains(t, m.message, "Remove api")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	require.Nil(t, cmd)
+	require.False(t, m.confirmRemove)
+	require.Empty(t, m.message)
+}
+
+func TestUIModelView(t *testing.T) {
+	testutil.SetupHome(t)
+
+	m := newUIModel(context.Background(), "", nil)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	require.Contains(t, m.View(), common.NoAppsMessage)
+
+	m.Update(uiAppsMsg{list: []apps.App{
+		{Name: "api", Status: common.AppStatusRunning, Mode: common.RunModeOnce, PID: 42},
+		{Name: "web", Status: common.AppStatusSuccess, Mode: common.RunModeOnce, ExitCode: new(0)},
+	}})
+
+	// the stream of the selected app is started by the refresh, lines of a previous stream are dropped
+	m.Update(uiLogMsg{gen: m.logGen, log: logs.Log{Value: "hello"}})
+	m.Update(uiLogMsg{gen: m.logGen - 1, log: logs.Log{Value: "stale"}})
+
+	view := m.View()
+	require.Contains(t, view, "api")
+	require.Contains(t, view, "web")
+	require.Contains(t, view, "42")
+	require.Contains(t, view, "Logs of api")
+	require.Contains(t, view, "hello")
+	require.NotContains(t, view, "stale")
+	require.Contains(t, view, "r restart")
+}
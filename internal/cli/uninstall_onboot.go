@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	disableStopSvcCmd = "systemctl --user disable --now " + common.SystemdSvcName
+	rmSvcCmd          = "rm " + common.SystemdSvcPath
+)
+
+func buildUninstallOnBootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "uninstall-onboot",
+		Short:        "Remove the systemd service that starts runapp at boot, running apps keep running",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			systemdSvcPath, err := util.ResolvePath(common.SystemdSvcPath)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(systemdSvcPath); err != nil {
+				if os.IsNotExist(err) {
+					fmt.Println(tml.Sprintf("<yellow>%s is not installed</yellow>", common.SystemdSvcName))
+					return nil
+				}
+				return err
+			}
+
+			actionFunc := func(_ context.Context) error {
+				// KillMode=none keeps the apps running when the service is stopped
+				if err := util.ExecuteCommand(disableStopSvcCmd, true); err != nil {
+					return err
+				}
+				if err := os.Remove(systemdSvcPath); err != nil {
+					return err
+				}
+				return util.ExecuteCommand(daemonReloadCmd, true)
+			}
+
+			err = spinner.New().
+				Title("Executing commands...").
+				ActionWithErr(actionFunc).
+				Run()
+			if err != nil {
+				util.DebugLog("error in execute spinner: %v", err)
+
+				// fallback to user ran commands
+				fmt.Println("Execute the following commands to uninstall the systemd service:")
+				fmt.Println(tml.Sprintf("<magenta>%s</magenta>", disableStopSvcCmd))
+				fmt.Println(tml.Sprintf("<magenta>%s</magenta>", rmSvcCmd))
+				fmt.Println(tml.Sprintf("<magenta>%s</magenta>", daemonReloadCmd))
+				return nil
+			}
+
+			fmt.Println(tml.Sprintf("<green>%s uninstalled</green>", common.SystemdSvcName))
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/supervisor"
+)
+
+const (
+	defaultWaitTimeout = time.Minute
+	// timeoutExitCode is the exit code of timeout(1)
+	timeoutExitCode = 124
+)
+
+// ExitError makes runapp exit with the given code instead of 1
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// startOptions describes what happens once an app was started by run, restart, apply or import
+type startOptions struct {
+	skipLogs     bool
+	waitReady    bool
+	readyTimeout time.Duration
+}
+
+func addWaitReadyFlags(cmd *cobra.Command, waitReady *bool, readyTimeout *time.Duration) {
+	cmd.Flags().BoolVar(waitReady, "wait-ready", false, "wait until the app is ready (its readiness probes passed) before returning")
+	cmd.Flags().DurationVar(readyTimeout, "timeout", defaultWaitTimeout, "maximum time to wait with --wait-ready, 0 waits forever")
+}
+
+// afterStart waits for the started app to be ready and streams its logs, depending on opts
+func afterStart(ctx context.Context, app apps.App, opts startOptions) error {
+	// a scheduled app only runs at its next activation, its logs would be followed until it is killed
+	if app.Schedule != nil {
+		fmt.Println(tml.Sprintf("<italic>%s</italic> runs on schedule: %s", app.Name, app.Schedule.Cron))
+		return nil
+	}
+
+	if opts.waitReady {
+		if _, err := waitApp(ctx, app.Name, supervisor.WaitForReady, opts.readyTimeout); err != nil {
+			return err
+		}
+		fmt.Println(tml.Sprintf("<green>%s is ready</green>", app.Name))
+	}
+
+	if opts.skipLogs {
+		return nil
+	}
+	return viewLogs(ctx, app, logs.DefaultOptions())
+}
+
+// waitApp waits for the app to reach the state, a timeout exits with 124 like timeout(1)
+func waitApp(ctx context.Context, name string, waitFor supervisor.WaitFor, timeout time.Duration) (*apps.App, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	app, err := supervisor.Wait(ctx, name, waitFor)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return app, &ExitError{Code: timeoutExitCode, Err: fmt.Errorf("timed out after %s waiting for %s to be %s", timeout, name, waitFor)}
+	}
+	return app, err
+}
+
+func buildWaitCmd() *cobra.Command {
+	var waitFor string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:          "wait <app>",
+		SilenceUsage: true,
+		Short:        "Wait until an app is running, ready or exited",
+		Long: "Wait until an app is running (its process started), ready (its readiness probes passed) or exited.\n" +
+			"Exits with 0 once the state is reached, 1 if the app exited before, 124 on timeout.\n" +
+			"With --for exit, exits with the exit code of the app.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(supervisor.ValidWaitFor, supervisor.WaitFor(waitFor)) {
+				return fmt.Errorf("--for must be %s, %s or %s", supervisor.WaitForReady, supervisor.WaitForRunning, supervisor.WaitForExit)
+			}
+
+			appName := args[0]
+			if err := apps.ValidateName(appName); err != nil {
+				return err
+			}
+
+			app, err := waitApp(cmd.Context(), appName, supervisor.WaitFor(waitFor), timeout)
+			if err != nil {
+				if errors.Is(err, apps.ErrNotFound) {
+					return fmt.Errorf("app: %s does not exist", appName)
+				}
+				return err
+			}
+
+			if supervisor.WaitFor(waitFor) != supervisor.WaitForExit {
+				fmt.Println(tml.Sprintf("<green>%s is %s</green>", app.Name, waitFor))
+				return nil
+			}
+
+			fmt.Println(formatCompletion(*app))
+			if app.ExitCode != nil && *app.ExitCode != 0 {
+				return &ExitError{Code: *app.ExitCode, Err: fmt.Errorf("%s exited with code %d", app.Name, *app.ExitCode)}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&waitFor, "for", string(supervisor.WaitForReady), fmt.Sprintf("state to wait for (one of: %s, %s, %s)", supervisor.WaitForReady, supervisor.WaitForRunning, supervisor.WaitForExit))
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "maximum time to wait, 0 waits forever")
+	return cmd
+}
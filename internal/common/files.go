@@ -2,10 +2,24 @@ package common
 
 const (
 	FileConfig = "config.json"
+	// FileConfigLock is locked with flock while config.json is read and rewritten
+	FileConfigLock = "config.lock"
 
 	FileStdErr = "stderr.log"
 	FileStdOut = "stdout.log"
+	// FileCombined holds both streams as JSON lines with the capture time
+	FileCombined = "combined.log"
+	// FileHistory holds the finished runs of an app, DirRuns their archived logs
+	FileHistory = "history.json"
+	DirRuns     = "runs"
+
+	// FileDaemonSocket, FileDaemonLog and FileBootReport live next to the app directories in the state directory
+	FileDaemonSocket = "daemon.sock"
+	FileDaemonLog    = "daemon.log"
+	// FileBootReport holds the apps the last runapp onboot started or failed to start
+	FileBootReport = "onboot.json"
 
 	SystemdPath    = "~/.config/systemd/user"
-	SystemdSvcPath = SystemdPath + "/runapp-boot.service"
+	SystemdSvcName = "runapp-boot.service"
+	SystemdSvcPath = SystemdPath + "/" + SystemdSvcName
 )
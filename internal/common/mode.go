@@ -5,9 +5,12 @@ type RunMode string
 const (
 	RunModeOnce   RunMode = "once"
 	RunModeOnBoot RunMode = "on-boot"
+	// RunModeScheduled runs the app at the times of its schedule, it is started on boot too
+	RunModeScheduled RunMode = "scheduled"
 )
 
 var PrettyRunMode = map[RunMode]string{
-	RunModeOnce:   "Once",
-	RunModeOnBoot: "🔌 On-boot",
+	RunModeOnce:      "Once",
+	RunModeOnBoot:    "🔌 On-boot",
+	RunModeScheduled: "⏰ Scheduled",
 }
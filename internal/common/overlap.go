@@ -0,0 +1,19 @@
+package common
+
+// OverlapPolicy decides what happens when a scheduled app is due while its previous run is still running
+type OverlapPolicy string
+
+const (
+	// OverlapSkip drops the run
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue starts the run once the previous one exits, runs that are due meanwhile are merged into it
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapAllow starts the run next to the previous one
+	OverlapAllow OverlapPolicy = "allow"
+)
+
+var ValidOverlapPolicies = []OverlapPolicy{
+	OverlapSkip,
+	OverlapQueue,
+	OverlapAllow,
+}
@@ -0,0 +1,15 @@
+package common
+
+type RestartPolicy string
+
+const (
+	RestartPolicyNever     RestartPolicy = "never"
+	RestartPolicyOnFailure RestartPolicy = "on-failure"
+	RestartPolicyAlways    RestartPolicy = "always"
+)
+
+var ValidRestartPolicies = []RestartPolicy{
+	RestartPolicyNever,
+	RestartPolicyOnFailure,
+	RestartPolicyAlways,
+}
@@ -5,13 +5,22 @@ type AppStatus string
 const (
 	AppStatusStarting AppStatus = "starting"
 	AppStatusRunning  AppStatus = "running"
-	AppStatusSuccess  AppStatus = "success"
-	AppStatusFailed   AppStatus = "failed"
+	// AppStatusRestarting the app exited and is waiting for its restart policy backoff
+	AppStatusRestarting AppStatus = "restarting"
+	// AppStatusUnhealthy the app is running but its health check keeps failing
+	AppStatusUnhealthy AppStatus = "unhealthy"
+	// AppStatusScheduled the scheduled app is waiting for its next run
+	AppStatusScheduled AppStatus = "scheduled"
+	AppStatusSuccess   AppStatus = "success"
+	AppStatusFailed    AppStatus = "failed"
 )
 
 var AppStatusPretty = map[AppStatus]string{
-	AppStatusStarting: "Starting",
-	AppStatusRunning:  "Running",
-	AppStatusSuccess:  "Success",
-	AppStatusFailed:   "Failed",
+	AppStatusStarting:   "Starting",
+	AppStatusRunning:    "Running",
+	AppStatusRestarting: "Restarting",
+	AppStatusUnhealthy:  "Unhealthy",
+	AppStatusScheduled:  "Scheduled",
+	AppStatusSuccess:    "Success",
+	AppStatusFailed:     "Failed",
 }
@@ -0,0 +1,19 @@
+package common
+
+// Trigger is what started a run of an app
+type Trigger string
+
+const (
+	// TriggerRun the app was created with run, apply, import or through the API
+	TriggerRun Trigger = "run"
+	// TriggerRestart the app was restarted by the user
+	TriggerRestart Trigger = "restart"
+	TriggerBoot    Trigger = "boot"
+	// TriggerDependency the app was started because an app that depends on it was started
+	TriggerDependency Trigger = "dependency"
+	// TriggerRestartPolicy the previous run exited and the restart policy started the app again
+	TriggerRestartPolicy Trigger = "restart-policy"
+	TriggerSchedule      Trigger = "schedule"
+	// TriggerWatch a watched file changed and the app was restarted
+	TriggerWatch Trigger = "watch"
+)
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"errors"
+	"path"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// the API is JSON over HTTP on a Unix socket, streams (logs, events) are newline delimited JSON
+const (
+	pathPing     = "/v1/ping"
+	pathShutdown = "/v1/shutdown"
+	pathApps     = "/v1/apps"
+	pathEvents   = "/v1/events"
+)
+
+const (
+	codeNotFound       = "not_found"
+	codeNotSupervised  = "not_supervised"
+	codeAlreadyRunning = "already_running"
+	codeBadRequest     = "bad_request"
+	codeInternal       = "internal"
+)
+
+var (
+	ErrNotRunning = errors.New("daemon is not running")
+	// ErrNotSupervised is returned for apps that are running in their own background process instead of the daemon
+	ErrNotSupervised  = errors.New("app is not supervised by the daemon")
+	ErrAlreadyRunning = errors.New("app is already running")
+)
+
+// Info describes the running daemon
+type Info struct {
+	PID       int       `json:"pid"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at"`
+	// Supervised are the names of the apps supervised by the daemon
+	Supervised []string `json:"supervised"`
+}
+
+type errorResponse struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// SocketPath returns the path of the daemon socket
+func SocketPath() (string, error) {
+	stateDir, err := util.StateDirPath()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(stateDir, common.FileDaemonSocket), nil
+}
+
+func appPath(name string, action string) string {
+	if len(action) == 0 {
+		return pathApps + "/" + name
+	}
+	return pathApps + "/" + name + "/" + action
+}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/supervisor"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	pingTimeout = time.Second
+	// log lines are JSON encoded, leave room for the longest line the log files can hold
+	maxStreamLineSize = 16 * 1024 * 1024
+	// the host is ignored, requests always go to the socket
+	baseURL = "http://runapp"
+)
+
+// Client talks to the daemon over its Unix socket
+type Client struct {
+	httpClient *http.Client
+}
+
+func NewClient(socketPath string) *Client {
+	var dialer net.Dialer
+	return &Client{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Connect returns a client of the running daemon, ErrNotRunning if there is none
+func Connect(ctx context.Context) (*Client, error) {
+	socketPath, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(socketPath); err != nil {
+		return nil, ErrNotRunning
+	}
+
+	client := NewClient(socketPath)
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if _, err := client.Ping(pingCtx); err != nil {
+		util.DebugLog("daemon socket exists but the daemon does not respond: %v", err)
+		return nil, ErrNotRunning
+	}
+	return client, nil
+}
+
+func (c *Client) Ping(ctx context.Context) (*Info, error) {
+	var info Info
+	if err := c.do(ctx, http.MethodGet, pathPing, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Shutdown asks the daemon to stop all supervised apps and exit
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, pathShutdown, nil, nil)
+}
+
+func (c *Client) List(ctx context.Context) ([]apps.App, error) {
+	var list []apps.App
+	if err := c.do(ctx, http.MethodGet, pathApps, nil, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) Get(ctx context.Context, name string) (*apps.App, error) {
+	var app apps.App
+	if err := c.do(ctx, http.MethodGet, appPath(name, ""), nil, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// Run creates an app from the template (like runapp run) and starts it in the daemon
+func (c *Client) Run(ctx context.Context, template apps.App) (*apps.App, error) {
+	var app apps.App
+	if err := c.do(ctx, http.MethodPost, pathApps, template, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// Start supervises an existing app that is not running
+func (c *Client) Start(ctx context.Context, name string) (*apps.App, error) {
+	var app apps.App
+	if err := c.do(ctx, http.MethodPost, appPath(name, "start"), nil, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// Kill stops a supervised app and waits until it exits, ErrNotSupervised if the daemon does not supervise it
+func (c *Client) Kill(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, appPath(name, "kill"), nil, nil)
+}
+
+// Restart stops the app (if the daemon supervises it) and starts it again with fresh logs
+func (c *Client) Restart(ctx context.Context, name string) (*apps.App, error) {
+	var app apps.App
+	if err := c.do(ctx, http.MethodPost, appPath(name, "restart"), nil, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// Logs streams the logs of the app, the channel is closed once the stream ends
+func (c *Client) Logs(ctx context.Context, name string, tail int, follow bool) (<-chan logs.Log, error) {
+	query := url.Values{}
+	query.Set("tail", strconv.Itoa(tail))
+	query.Set("follow", strconv.FormatBool(follow))
+	return stream[logs.Log](ctx, c, appPath(name, "logs")+"?"+query.Encode())
+}
+
+// Events streams the state changes of the supervised apps until ctx is done
+func (c *Client) Events(ctx context.Context) (<-chan supervisor.Event, error) {
+	return stream[supervisor.Event](ctx, c, pathEvents)
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		defer closeBody(res)
+		return nil, decodeError(res)
+	}
+	return res, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body any, out any) error {
+	res, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer closeBody(res)
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func stream[T any](ctx context.Context, c *Client, path string) (<-chan T, error) {
+	res, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		defer closeBody(res)
+
+		scanner := bufio.NewScanner(res.Body)
+		scanner.Buffer(make([]byte, 64*1024), maxStreamLineSize)
+		for scanner.Scan() {
+			var value T
+			if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+				util.DebugLog("malformed stream line: %v", err)
+				continue
+			}
+
+			select {
+			case ch <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func decodeError(res *http.Response) error {
+	var body errorResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return fmt.Errorf("daemon returned status %d", res.StatusCode)
+	}
+
+	switch body.Code {
+	case codeNotFound:
+		return apps.ErrNotFound
+	case codeNotSupervised:
+		return ErrNotSupervised
+	case codeAlreadyRunning:
+		return ErrAlreadyRunning
+	}
+	return errors.New(body.Error)
+}
+
+func closeBody(res *http.Response) {
+	if err := res.Body.Close(); err != nil {
+		util.DebugLog("failed to close response body: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"sync"
+
+	"github.com/0xB1a60/runapp/internal/supervisor"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	subscriberBuffer = 100
+)
+
+// hub fans the events of all supervised apps out to the subscribers of the events stream
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan supervisor.Event]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[chan supervisor.Event]struct{})}
+}
+
+// subscribe returns a channel with all future events and a function to stop receiving them
+func (h *hub) subscribe() (<-chan supervisor.Event, func()) {
+	ch := make(chan supervisor.Event, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// publish never blocks the supervisors, events are dropped for subscribers that can't keep up
+func (h *hub) publish(event supervisor.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs {
+		select {
+		case ch <- event:
+		default:
+			util.DebugLog("dropping %s event of %s for a slow subscriber", event.Type, event.App)
+		}
+	}
+}
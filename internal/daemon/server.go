@@ -0,0 +1,528 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/export"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/supervisor"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	shutdownTimeout   = 5 * time.Second
+	statusCheckPeriod = 500 * time.Millisecond
+)
+
+// Server supervises apps in a single long-running process and exposes them over a Unix socket
+type Server struct {
+	version   string
+	startedAt time.Time
+	events    *hub
+
+	mu      sync.Mutex
+	running map[string]*supervised
+	wg      sync.WaitGroup
+
+	// ctx lives as long as the daemon, supervised apps are stopped once it is done
+	ctx      context.Context
+	shutdown context.CancelFunc
+}
+
+type supervised struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewServer(version string) *Server {
+	return &Server{
+		version: version,
+		events:  newHub(),
+		running: make(map[string]*supervised),
+	}
+}
+
+// Serve listens on the socket until ctx is done or a shutdown is requested,
+// all supervised apps are stopped before it returns
+func (s *Server) Serve(ctx context.Context, socketPath string) error {
+	if err := removeStaleSocket(socketPath); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			util.DebugLog("failed to remove socket: %v", err)
+		}
+	}()
+
+	// only the owner can control the apps
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		return err
+	}
+
+	s.ctx, s.shutdown = context.WithCancel(ctx)
+	defer s.shutdown()
+	s.startedAt = time.Now()
+
+	srv := &http.Server{
+		Handler: s.routes(),
+		// streams end together with the daemon
+		BaseContext: func(net.Listener) context.Context {
+			return s.ctx
+		},
+	}
+
+	go func() {
+		<-s.ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			util.DebugLog("failed to shutdown the server: %v", err)
+		}
+	}()
+
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+
+	s.stopAll()
+	return err
+}
+
+// removeStaleSocket removes the socket left behind by a daemon that did not exit cleanly
+func removeStaleSocket(socketPath string) error {
+	if _, err := os.Stat(socketPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		if err := conn.Close(); err != nil {
+			util.DebugLog("failed to close connection: %v", err)
+		}
+		return errors.New("daemon is already running")
+	}
+	return os.Remove(socketPath)
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+pathPing, s.handlePing)
+	mux.HandleFunc("POST "+pathShutdown, s.handleShutdown)
+	mux.HandleFunc("GET "+pathApps, s.handleList)
+	mux.HandleFunc("POST "+pathApps, s.handleRun)
+	mux.HandleFunc("GET "+pathApps+"/{name}", s.withApp(s.handleGet))
+	mux.HandleFunc("POST "+pathApps+"/{name}/start", s.withApp(s.handleStart))
+	mux.HandleFunc("POST "+pathApps+"/{name}/kill", s.withApp(s.handleKill))
+	mux.HandleFunc("POST "+pathApps+"/{name}/restart", s.withApp(s.handleRestart))
+	mux.HandleFunc("GET "+pathApps+"/{name}/logs", s.withApp(s.handleLogs))
+	mux.HandleFunc("GET "+pathEvents, s.handleEvents)
+	return mux
+}
+
+// start supervises an existing app that is not running
+func (s *Server) start(name string) (*apps.App, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.running[name]; ok {
+		return nil, ErrAlreadyRunning
+	}
+
+	app, err := apps.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// started apps are saved as starting, running, restarting or scheduled ones are supervised by another process
+	if app.HasProcess() || app.Status == common.AppStatusRestarting || app.Status == common.AppStatusScheduled {
+		return nil, ErrAlreadyRunning
+	}
+
+	// the supervisor keeps updating the app, the caller gets the state it was started with
+	snapshot := *app
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	sup := &supervised{cancel: cancel, done: make(chan struct{})}
+	s.running[name] = sup
+
+	s.wg.Go(func() {
+		defer close(sup.done)
+		defer cancel()
+
+		if err := supervisor.SuperviseInDaemon(ctx, app, s.events.publish); err != nil {
+			util.DebugLog("failed to supervise %s: %v", name, err)
+		}
+
+		s.mu.Lock()
+		if s.running[name] == sup {
+			delete(s.running, name)
+		}
+		s.mu.Unlock()
+	})
+	return &snapshot, nil
+}
+
+// startDependencies starts the dependencies of the app that are not running in the daemon and waits until they are ready
+func (s *Server) startDependencies(ctx context.Context, name string) error {
+	_, err := supervisor.StartDependencies(ctx, name, func(dep apps.App) error {
+		_, err := s.start(dep.Name)
+		return err
+	})
+	return err
+}
+
+// stop stops a supervised app and waits until it exits
+func (s *Server) stop(ctx context.Context, name string) error {
+	s.mu.Lock()
+	sup, ok := s.running[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrNotSupervised
+	}
+
+	sup.cancel()
+	select {
+	case <-sup.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) stopAll() {
+	s.mu.Lock()
+	for _, sup := range s.running {
+		sup.cancel()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) supervisedNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]string, 0, len(s.running))
+	for name := range s.running {
+		res = append(res, name)
+	}
+	slices.Sort(res)
+	return res
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, Info{
+		PID:        os.Getpid(),
+		Version:    s.version,
+		StartedAt:  s.startedAt,
+		Supervised: s.supervisedNames(),
+	})
+}
+
+func (s *Server) handleShutdown(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+	s.shutdown()
+}
+
+func (s *Server) handleList(w http.ResponseWriter, _ *http.Request) {
+	list, err := apps.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleRun creates an app from the template in the body (like runapp run) and starts it
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	var template apps.App
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Error: err.Error()})
+		return
+	}
+
+	if err := template.ValidateTemplate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Error: err.Error()})
+		return
+	}
+	list, err := apps.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := apps.ValidateDependencies(list, template); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Error: err.Error()})
+		return
+	}
+	if existing, err := apps.Get(template.Name); err == nil && existing.IsRunning() {
+		writeError(w, ErrAlreadyRunning)
+		return
+	}
+
+	created, err := supervisor.Create(template)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// the systemd unit is synced like in runner.Create, the daemon cannot import the runner
+	if err := export.SyncSystemdUnit(*created); err != nil {
+		util.DebugLog("failed to update the systemd unit of %s: %v", created.Name, err)
+	}
+
+	if err := s.startDependencies(r.Context(), created.Name); err != nil {
+		writeError(w, err)
+		return
+	}
+	app, err := s.start(created.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, app)
+}
+
+// withApp validates the name in the path, it is used to build the path of the app directory
+func (s *Server) withApp(handler func(w http.ResponseWriter, r *http.Request, name string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := apps.ValidateName(name); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Error: err.Error()})
+			return
+		}
+		handler(w, r, name)
+	}
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, _ *http.Request, name string) {
+	app, err := apps.Get(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, app)
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request, name string) {
+	if err := s.startDependencies(r.Context(), name); err != nil {
+		writeError(w, err)
+		return
+	}
+	app, err := s.start(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, app)
+}
+
+func (s *Server) handleKill(w http.ResponseWriter, r *http.Request, name string) {
+	if err := s.stop(r.Context(), name); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRestart stops the app if it is supervised by the daemon and starts it again with fresh logs
+func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request, name string) {
+	if err := s.stop(r.Context(), name); err != nil && !errors.Is(err, ErrNotSupervised) {
+		writeError(w, err)
+		return
+	}
+
+	app, err := apps.Get(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if app.IsRunning() {
+		writeError(w, ErrNotSupervised)
+		return
+	}
+
+	if err := supervisor.Reset(app, common.TriggerRestart); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := s.startDependencies(r.Context(), name); err != nil {
+		writeError(w, err)
+		return
+	}
+	app, err = s.start(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, app)
+}
+
+// handleLogs streams the logs as JSON lines, query: tail (number of lines), type (all, stdout, stderr),
+// follow (keep streaming while the app is running) and since (RFC3339 or relative duration)
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request, name string) {
+	app, err := apps.Get(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	opts, err := logOptions(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	write := func(log logs.Log) error {
+		if err := enc.Encode(log); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	if !opts.Follow || !app.IsRunning() {
+		if err := logs.EachLine(*app, opts, write); err != nil {
+			util.DebugLog("failed to write logs of %s: %v", name, err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	logStream, err := logs.Stream(ctx, *app, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ticker := time.NewTicker(statusCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case log := <-logStream:
+			if err := write(log); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if app, err := apps.Get(name); err != nil || !app.IsRunning() {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func logOptions(r *http.Request) (logs.Options, error) {
+	query := r.URL.Query()
+
+	opts := logs.DefaultOptions()
+	opts.Follow = query.Get("follow") != "false"
+
+	if value := query.Get("type"); len(value) != 0 {
+		if !slices.Contains(logs.ValidTypes, value) {
+			return opts, fmt.Errorf("unknown log type: %s", value)
+		}
+		opts.Type = value
+	}
+
+	if value := query.Get("tail"); len(value) != 0 {
+		tail, err := strconv.Atoi(value)
+		if err != nil || tail < logs.TailAll {
+			return opts, fmt.Errorf("invalid tail: %s", value)
+		}
+		opts.Tail = tail
+	}
+
+	if value := query.Get("since"); len(value) != 0 {
+		since, err := util.ParseTimeOrDuration(value, time.Now())
+		if err != nil {
+			return opts, err
+		}
+		opts.Since = since
+	}
+	return opts, nil
+}
+
+// handleEvents streams the state changes of the supervised apps as JSON lines
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if err := enc.Encode(event); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		util.DebugLog("failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, apps.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, errorResponse{Code: codeNotFound, Error: err.Error()})
+	case errors.Is(err, ErrNotSupervised):
+		writeJSON(w, http.StatusConflict, errorResponse{Code: codeNotSupervised, Error: err.Error()})
+	case errors.Is(err, ErrAlreadyRunning):
+		writeJSON(w, http.StatusConflict, errorResponse{Code: codeAlreadyRunning, Error: err.Error()})
+	case errors.Is(err, logs.ErrNoTimestamps), errors.Is(err, apps.ErrDependencyCycle), errors.Is(err, apps.ErrMissingDependency):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Code: codeInternal, Error: err.Error()})
+	}
+}
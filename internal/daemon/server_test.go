@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/supervisor"
+	"github.com/0xB1a60/runapp/internal/testutil"
+)
+
+func startServer(t *testing.T) *Client {
+	t.Helper()
+
+	home, err := os.MkdirTemp("", "runapp")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(home)
+	})
+	testutil.SetupHome(t)
+	t.Setenv("SHELL", "/bin/sh")
+
+	// unix socket paths are short, the test temp dir can be too long
+	socketPath := filepath.Join(home, "d.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewServer("test").Serve(ctx, socketPath)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	client := NewClient(socketPath)
+	require.Eventually(t, func() bool {
+		_, err := client.Ping(context.Background())
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	return client
+}
+
+func TestServer(t *testing.T) {
+	client := startServer(t)
+	ctx := context.Background()
+
+	eventsCtx, cancelEvents := context.WithCancel(ctx)
+	defer cancelEvents()
+	events, err := client.Events(eventsCtx)
+	require.NoError(t, err)
+
+	_, err = client.Run(ctx, apps.App{Name: "hello", Command: "echo hello", Env: os.Environ()})
+	require.NoError(t, err)
+
+	var types []supervisor.EventType
+	for event := range events {
+		require.Equal(t, "hello", event.App)
+		types = append(types, event.Type)
+		if event.Type == supervisor.EventExited {
+			require.Equal(t, 0, *event.ExitCode)
+			break
+		}
+	}
+	require.Equal(t, []supervisor.EventType{supervisor.EventStarted, supervisor.EventExited}, types)
+
+	app, err := client.Get(ctx, "hello")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusSuccess, app.Status)
+	require.Equal(t, common.RunModeOnce, app.Mode)
+
+	logStream, err := client.Logs(ctx, "hello", -1, false)
+	require.NoError(t, err)
+	var lines []string
+	for log := range logStream {
+		lines = append(lines, log.Value)
+	}
+	require.Equal(t, []string{"hello"}, lines)
+
+	list, err := client.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	_, err = client.Get(ctx, "missing")
+	require.ErrorIs(t, err, apps.ErrNotFound)
+
+	_, err = client.Run(ctx, apps.App{Name: "../escape", Command: "true"})
+	require.Error(t, err)
+}
+
+func TestServer_Kill(t *testing.T) {
+	client := startServer(t)
+	ctx := context.Background()
+
+	_, err := client.Run(ctx, apps.App{Name: "sleeper", Command: "sleep 30", Env: os.Environ(), Restart: apps.Restart{Policy: common.RestartPolicyAlways}})
+	require.NoError(t, err)
+
+	_, err = client.Start(ctx, "sleeper")
+	require.ErrorIs(t, err, ErrAlreadyRunning)
+
+	info, err := client.Ping(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sleeper"}, info.Supervised)
+
+	require.NoError(t, client.Kill(ctx, "sleeper"))
+
+	app, err := client.Get(ctx, "sleeper")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, app.Status)
+	require.Equal(t, 137, *app.ExitCode)
+
+	require.ErrorIs(t, client.Kill(ctx, "sleeper"), ErrNotSupervised)
+
+	restarted, err := client.Restart(ctx, "sleeper")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusStarting, restarted.Status)
+}
@@ -0,0 +1,250 @@
+package export
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// Format is an init system the apps can be exported to
+type Format string
+
+const (
+	FormatSystemd     Format = "systemd"
+	FormatOpenRC      Format = "openrc"
+	FormatRunit       Format = "runit"
+	FormatS6          Format = "s6"
+	FormatSupervisord Format = "supervisord"
+)
+
+var Formats = []Format{FormatSystemd, FormatOpenRC, FormatRunit, FormatS6, FormatSupervisord}
+
+//go:embed templates
+var templatesFS embed.FS
+
+var templates = template.Must(template.New("export").Funcs(template.FuncMap{
+	"join":       strings.Join,
+	"squote":     squote,
+	"dquote":     dquote,
+	"iniquote":   iniquote,
+	"inipercent": inipercent,
+}).ParseFS(templatesFS, "templates/*.tpl"))
+
+// envNamePattern matches the variable names a POSIX shell can export, e.g. exported bash functions do not match
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ErrScheduled is returned for scheduled apps, they need a timer instead of a long-running service
+var ErrScheduled = errors.New("scheduled apps cannot be exported")
+
+// File is a file of a service definition, Path is relative to the output directory
+type File struct {
+	Path    string
+	Mode    os.FileMode
+	Content string
+}
+
+// service is what the templates of every format are rendered with
+type service struct {
+	Format Format
+	Name   string
+	// Service is the name of the service of the app, runapp-<app>
+	Service string
+	// Dependencies are the services of the apps the app depends on
+	Dependencies []string
+	CWD          string
+	// Env only has the variables whose name a shell accepts
+	Env     []envVar
+	Shell   string
+	Command string
+	Restart common.RestartPolicy
+	// MaxRetries is the amount of consecutive restarts within ResetWindow, 0 means unlimited
+	MaxRetries int
+	// Delay and ResetWindow are in whole seconds, the init systems do not support fractions
+	Delay       int
+	ResetWindow int
+}
+
+type envVar struct {
+	Key   string
+	Value string
+}
+
+// ParseFormat validates the format name
+func ParseFormat(value string) (Format, error) {
+	format := Format(value)
+	if !slices.Contains(Formats, format) {
+		names := make([]string, 0, len(Formats))
+		for _, format := range Formats {
+			names = append(names, string(format))
+		}
+		return "", fmt.Errorf("format must be one of: %s", strings.Join(names, ", "))
+	}
+	return format, nil
+}
+
+// SupportsMaxRetries reports whether the init system can stop restarting an app after its max retries,
+// runit, s6 and supervisord restart a service for as long as it is up
+func SupportsMaxRetries(format Format) bool {
+	return format == FormatSystemd || format == FormatOpenRC
+}
+
+// ServiceName returns the name of the service of the app, apps of a project are prefixed with the project
+func ServiceName(name string) string {
+	if project := util.Project(); len(project) != 0 {
+		return "runapp-" + project + "." + name
+	}
+	return "runapp-" + name
+}
+
+// Render returns the files of the service definition of the app for the init system
+func Render(format Format, app apps.App) ([]File, error) {
+	if app.Mode == common.RunModeScheduled {
+		return nil, fmt.Errorf("%w: %s", ErrScheduled, app.Name)
+	}
+	svc := newService(format, app)
+
+	switch format {
+	case FormatSystemd:
+		unit, err := SystemdUnit(app)
+		if err != nil {
+			return nil, err
+		}
+		return []File{{Path: SystemdUnitName(app.Name), Mode: 0644, Content: unit}}, nil
+	case FormatOpenRC:
+		return renderFiles(svc, fileTemplate{File{Path: svc.Service, Mode: 0755}, "openrc.tpl"})
+	case FormatRunit:
+		return renderFiles(svc,
+			fileTemplate{File{Path: path.Join(svc.Service, "run"), Mode: 0755}, "run.tpl"},
+			fileTemplate{File{Path: path.Join(svc.Service, "finish"), Mode: 0755}, "runit-finish.tpl"},
+		)
+	case FormatS6:
+		files, err := renderFiles(svc,
+			fileTemplate{File{Path: path.Join(svc.Service, "type"), Mode: 0644}, "s6-type.tpl"},
+			fileTemplate{File{Path: path.Join(svc.Service, "run"), Mode: 0755}, "run.tpl"},
+			fileTemplate{File{Path: path.Join(svc.Service, "finish"), Mode: 0755}, "s6-finish.tpl"},
+		)
+		if err != nil {
+			return nil, err
+		}
+		// s6-rc starts the services listed in dependencies.d first
+		for _, dep := range svc.Dependencies {
+			files = append(files, File{Path: path.Join(svc.Service, "dependencies.d", dep), Mode: 0644})
+		}
+		return files, nil
+	case FormatSupervisord:
+		return renderFiles(svc, fileTemplate{File{Path: svc.Service + ".conf", Mode: 0644}, "supervisord.tpl"})
+	}
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+// fileTemplate is a file of a service definition and the template its content is rendered with
+type fileTemplate struct {
+	File
+	template string
+}
+
+func renderFiles(svc service, templateFiles ...fileTemplate) ([]File, error) {
+	files := make([]File, 0, len(templateFiles))
+	for _, templateFile := range templateFiles {
+		var sb strings.Builder
+		if err := templates.ExecuteTemplate(&sb, templateFile.template, svc); err != nil {
+			return nil, err
+		}
+		file := templateFile.File
+		file.Content = sb.String()
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Write writes the files into dir, existing files are replaced
+func Write(dir string, files []File) error {
+	for _, file := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filePath, []byte(file.Content), file.Mode); err != nil {
+			return err
+		}
+		// WriteFile keeps the mode of an existing file
+		if err := os.Chmod(filePath, file.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newService(format Format, app apps.App) service {
+	svc := service{
+		Format:      format,
+		Name:        app.Name,
+		Service:     ServiceName(app.Name),
+		CWD:         app.CWD,
+		Shell:       "/bin/sh",
+		Command:     app.Command,
+		Restart:     app.Restart.Policy,
+		MaxRetries:  app.Restart.MaxRetries,
+		Delay:       seconds(app.Restart.Backoff(0)),
+		ResetWindow: seconds(app.Restart.ResetWindow),
+	}
+	if len(svc.Restart) == 0 {
+		svc.Restart = common.RestartPolicyNever
+	}
+	if app.Restart.ResetWindow <= 0 {
+		svc.ResetWindow = seconds(apps.DefaultResetWindow)
+	}
+	if shellArgs := util.ShellArgsFromEnv(app.Env); shellArgs != nil {
+		svc.Shell = shellArgs[0]
+	}
+
+	for _, dep := range app.DependsOn {
+		svc.Dependencies = append(svc.Dependencies, ServiceName(dep))
+	}
+	for _, env := range app.Env {
+		key, value, ok := strings.Cut(env, "=")
+		if ok && envNamePattern.MatchString(key) {
+			svc.Env = append(svc.Env, envVar{Key: key, Value: value})
+		}
+	}
+	return svc
+}
+
+// seconds rounds the duration up to whole seconds
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// squote quotes the value for a POSIX shell, nothing is expanded within single quotes
+func squote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// dquote quotes the value for a POSIX shell within double quotes, for values that are evaluated a second time
+func dquote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// inipercent escapes the value for the supervisord options that are not unquoted (e.g. directory), % is doubled
+func inipercent(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// iniquote quotes the value for supervisord, % starts its expressions and is doubled
+func iniquote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%")
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// update rewrites the golden files with the rendered ones: go test ./internal/export -update
+var update = flag.Bool("update", false, "update the golden files")
+
+var goldenApps = map[string]apps.App{
+	"once": {
+		Name:    "api",
+		Mode:    common.RunModeOnce,
+		Command: `./api --greeting "it's 100% $USER"`,
+		CWD:     "/srv/my api",
+		Env:     []string{"SHELL=/bin/bash", "PORT=8080", `QUOTED=say "hi"`, "BASH_FUNC_f%%=() { :; }"},
+	},
+	"on-failure": {
+		Name:      "worker",
+		Mode:      common.RunModeOnBoot,
+		Command:   "./worker --queue default",
+		DependsOn: []string{"db", "cache"},
+		Restart: apps.Restart{
+			Policy:      common.RestartPolicyOnFailure,
+			MaxRetries:  3,
+			BackoffBase: 1500 * time.Millisecond,
+			ResetWindow: 5 * time.Minute,
+		},
+	},
+	"always": {
+		Name:    "db",
+		Mode:    common.RunModeOnBoot,
+		Command: "postgres -D /var/lib/postgres",
+		CWD:     "/var/lib/postgres",
+		Restart: apps.Restart{Policy: common.RestartPolicyAlways},
+	},
+}
+
+func TestRender_Golden(t *testing.T) {
+	t.Setenv(util.ProjectEnv, "")
+
+	for name, app := range goldenApps {
+		for _, format := range Formats {
+			t.Run(name+"/"+string(format), func(t *testing.T) {
+				files, err := Render(format, app)
+				require.NoError(t, err)
+
+				goldenDir := path.Join("testdata", "golden", name, string(format))
+				if *update {
+					require.NoError(t, os.RemoveAll(goldenDir))
+					require.NoError(t, Write(goldenDir, files))
+				}
+
+				rendered := make(map[string]string, len(files))
+				for _, file := range files {
+					rendered[file.Path] = file.Content
+				}
+
+				golden := make(map[string]string)
+				err = filepath.WalkDir(goldenDir, func(filePath string, entry fs.DirEntry, err error) error {
+					if err != nil || entry.IsDir() {
+						return err
+					}
+					content, err := os.ReadFile(filePath)
+					if err != nil {
+						return err
+					}
+					rel, err := filepath.Rel(goldenDir, filePath)
+					if err != nil {
+						return err
+					}
+					golden[filepath.ToSlash(rel)] = string(content)
+					return nil
+				})
+				require.NoError(t, err)
+				require.Equal(t, golden, rendered, "run go test ./internal/export -update to update the golden files")
+			})
+		}
+	}
+}
+
+func TestWrite_Modes(t *testing.T) {
+	t.Setenv(util.ProjectEnv, "")
+	dir := t.TempDir()
+
+	files, err := Render(FormatRunit, goldenApps["once"])
+	require.NoError(t, err)
+	require.NoError(t, Write(dir, files))
+
+	// the run and finish scripts are executed by runsv
+	for _, name := range []string{"run", "finish"} {
+		info, err := os.Stat(path.Join(dir, "runapp-api", name))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+}
+
+func TestRender_Scheduled(t *testing.T) {
+	for _, format := range Formats {
+		_, err := Render(format, apps.App{Name: "backup", Mode: common.RunModeScheduled, Command: "./backup"})
+		require.ErrorIs(t, err, ErrScheduled)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("openrc")
+	require.NoError(t, err)
+	require.Equal(t, FormatOpenRC, format)
+
+	_, err = ParseFormat("upstart")
+	require.Error(t, err)
+}
+
+func TestSupportsMaxRetries(t *testing.T) {
+	for _, format := range []Format{FormatSystemd, FormatOpenRC} {
+		require.True(t, SupportsMaxRetries(format))
+	}
+	for _, format := range []Format{FormatRunit, FormatS6, FormatSupervisord} {
+		require.False(t, SupportsMaxRetries(format))
+	}
+}
@@ -0,0 +1,212 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+var systemdRestart = map[common.RestartPolicy]string{
+	common.RestartPolicyNever:     "no",
+	common.RestartPolicyOnFailure: "on-failure",
+	common.RestartPolicyAlways:    "always",
+}
+
+// DaemonReloadCmd makes the systemd user instance read the changed units
+const DaemonReloadCmd = "systemctl --user daemon-reload"
+
+type systemdUnit struct {
+	Name               string
+	Dependencies       []string
+	StartLimitInterval string
+	StartLimitBurst    int
+	WorkingDirectory   string
+	Environment        []string
+	ExecStart          string
+	Restart            string
+	RestartSec         string
+	// Limits are the resource control and rlimit directives of the app limits, e.g. MemoryMax=536870912
+	Limits []string
+}
+
+// SystemdUnitName returns the name of the unit of the app, apps of a project are prefixed with the project
+func SystemdUnitName(name string) string {
+	return ServiceName(name) + ".service"
+}
+
+// SystemdUnitDir returns the directory of the systemd user units
+func SystemdUnitDir() (string, error) {
+	return util.ResolvePath(common.SystemdPath)
+}
+
+// SystemdUnit renders a systemd user service that runs the command of the app with its working directory,
+// environment, restart policy and dependencies, systemd supervises the process and journald keeps its logs
+func SystemdUnit(app apps.App) (string, error) {
+	if app.Mode == common.RunModeScheduled {
+		return "", fmt.Errorf("%w: %s", ErrScheduled, app.Name)
+	}
+
+	unit := systemdUnit{
+		Name:             app.Name,
+		WorkingDirectory: escapeSpecifiers(app.CWD),
+		ExecStart:        systemdExec(app),
+		Restart:          systemdRestart[app.Restart.Policy],
+		RestartSec:       timespan(app.Restart.Backoff(0)),
+	}
+	if len(unit.Restart) == 0 {
+		unit.Restart = systemdRestart[common.RestartPolicyNever]
+	}
+
+	for _, dep := range app.DependsOn {
+		unit.Dependencies = append(unit.Dependencies, SystemdUnitName(dep))
+	}
+
+	// systemd counts the first start too
+	if app.Restart.MaxRetries > 0 {
+		resetWindow := app.Restart.ResetWindow
+		if resetWindow <= 0 {
+			resetWindow = apps.DefaultResetWindow
+		}
+		unit.StartLimitInterval = timespan(resetWindow)
+		unit.StartLimitBurst = app.Restart.MaxRetries + 1
+	}
+
+	for _, env := range newService(FormatSystemd, app).Env {
+		unit.Environment = append(unit.Environment, quote(escapeSpecifiers(env.Key+"="+env.Value)))
+	}
+
+	if app.Limits != nil {
+		unit.Limits = systemdLimits(*app.Limits)
+	}
+
+	var sb strings.Builder
+	if err := templates.ExecuteTemplate(&sb, "systemd.service.tpl", unit); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// systemdLimits maps the limits to systemd directives, systemd creates the cgroup of the unit itself
+func systemdLimits(limits apps.Limits) []string {
+	var directives []string
+	if limits.MemoryMax != 0 {
+		directives = append(directives, fmt.Sprintf("MemoryMax=%d", limits.MemoryMax))
+	}
+	if limits.CPUQuota != 0 {
+		directives = append(directives, fmt.Sprintf("CPUQuota=%d%%", limits.CPUQuota))
+	}
+	if limits.PidsMax != 0 {
+		directives = append(directives, fmt.Sprintf("TasksMax=%d", limits.PidsMax))
+	}
+	if limits.NoFile != 0 {
+		directives = append(directives, fmt.Sprintf("LimitNOFILE=%d", limits.NoFile))
+	}
+	if limits.Core != nil {
+		core := "infinity"
+		if *limits.Core != apps.Unlimited {
+			core = fmt.Sprintf("%d", *limits.Core)
+		}
+		directives = append(directives, "LimitCORE="+core)
+	}
+	return directives
+}
+
+// WriteSystemdUnit renders the unit of the app into dir, returns the path of the unit file
+func WriteSystemdUnit(dir string, app apps.App) (string, error) {
+	files, err := Render(FormatSystemd, app)
+	if err != nil {
+		return "", err
+	}
+	if err := Write(dir, files); err != nil {
+		return "", err
+	}
+	return path.Join(dir, SystemdUnitName(app.Name)), nil
+}
+
+// SyncSystemdUnit rewrites the unit of an exported app after it was run again, apps without a unit are skipped
+func SyncSystemdUnit(app apps.App) error {
+	dir, err := SystemdUnitDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path.Join(dir, SystemdUnitName(app.Name))); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := WriteSystemdUnit(dir, app); err != nil {
+		return err
+	}
+	daemonReload()
+	return nil
+}
+
+// RemoveSystemdUnit stops, disables and removes the unit of an exported app, apps without a unit are skipped
+func RemoveSystemdUnit(name string) error {
+	dir, err := SystemdUnitDir()
+	if err != nil {
+		return err
+	}
+	unitName := SystemdUnitName(name)
+	unitPath := path.Join(dir, unitName)
+	if _, err := os.Stat(unitPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := util.ExecuteCommand("systemctl --user disable --now "+unitName, true); err != nil {
+		util.DebugLog("failed to disable %s: %v", unitName, err)
+	}
+	if err := os.Remove(unitPath); err != nil {
+		return err
+	}
+	daemonReload()
+	return nil
+}
+
+// daemonReload makes systemd read the changed units, without a systemd user instance they are read on its next start
+func daemonReload() {
+	if err := util.ExecuteCommand(DaemonReloadCmd, true); err != nil {
+		util.DebugLog("failed to reload systemd units: %v", err)
+	}
+}
+
+// systemdExec runs the command with the shell of the app, like the background process does
+func systemdExec(app apps.App) string {
+	shellArgs := util.ShellArgsFromEnv(app.Env)
+	if shellArgs == nil {
+		shellArgs = []string{"/bin/sh", "-c"}
+	}
+	// $ is expanded by systemd in ExecStart, the shell expands it instead
+	command := strings.ReplaceAll(escapeSpecifiers(app.Command), "$", "$$")
+	return strings.Join(shellArgs, " ") + " " + quote(command)
+}
+
+// escapeSpecifiers escapes the % specifiers of systemd
+func escapeSpecifiers(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// quote wraps the value in double quotes with C-style escapes, systemd unquotes it as a single word
+func quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// timespan formats the duration in a unit systemd parses
+func timespan(d time.Duration) string {
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/testutil"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+func TestWriteSystemdUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      apps.App
+		expected []string
+		absent   []string
+	}{
+		{
+			name: "defaults",
+			app: apps.App{
+				Name:    "api",
+				Mode:    common.RunModeOnce,
+				Command: "./api --port 8080",
+				CWD:     "/srv/api",
+				Env:     []string{"SHELL=/bin/bash", "PORT=8080"},
+			},
+			expected: []string{
+				"Description=runapp: api",
+				"After=network.target\n",
+				"WorkingDirectory=/srv/api",
+				`Environment="SHELL=/bin/bash"`,
+				`Environment="PORT=8080"`,
+				`ExecStart=/bin/bash -c "./api --port 8080"`,
+				"Restart=no",
+				"RestartSec=1s",
+				"WantedBy=default.target",
+			},
+			absent: []string{"Wants=", "StartLimitBurst="},
+		},
+		{
+			name: "restart policy and dependencies",
+			app: apps.App{
+				Name:      "worker",
+				Mode:      common.RunModeOnBoot,
+				Command:   "./worker",
+				DependsOn: []string{"db", "cache"},
+				Restart: apps.Restart{
+					Policy:      common.RestartPolicyOnFailure,
+					MaxRetries:  3,
+					BackoffBase: 1500 * time.Millisecond,
+					ResetWindow: 5 * time.Minute,
+				},
+			},
+			expected: []string{
+				"After=network.target runapp-db.service runapp-cache.service",
+				"Wants=runapp-db.service runapp-cache.service",
+				"StartLimitIntervalSec=300s",
+				"StartLimitBurst=4",
+				`ExecStart=/bin/sh -c "./worker"`,
+				"Restart=on-failure",
+				"RestartSec=1500ms",
+			},
+			absent: []string{"WorkingDirectory="},
+		},
+		{
+			name: "escaping",
+			app: apps.App{
+				Name:    "echo",
+				Mode:    common.RunModeOnce,
+				Command: `echo "$HOME" 100% \ok`,
+				Env:     []string{`GREETING=say "hi" 50%`, "INVALID"},
+				Restart: apps.Restart{Policy: common.RestartPolicyAlways},
+			},
+			expected: []string{
+				`ExecStart=/bin/sh -c "echo \"$$HOME\" 100%% \\ok"`,
+				`Environment="GREETING=say \"hi\" 50%%"`,
+				"Restart=always",
+			},
+			absent: []string{"INVALID"},
+		},
+		{
+			name: "limits",
+			app: apps.App{
+				Name:    "api",
+				Mode:    common.RunModeOnce,
+				Command: "./api",
+				Limits: &apps.Limits{
+					MemoryMax: 512 * 1024 * 1024,
+					CPUQuota:  150,
+					PidsMax:   64,
+					NoFile:    1024,
+					Core:      new(apps.Unlimited),
+				},
+			},
+			expected: []string{
+				"MemoryMax=536870912",
+				"CPUQuota=150%",
+				"TasksMax=64",
+				"LimitNOFILE=1024",
+				"LimitCORE=infinity",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			unitPath, err := WriteSystemdUnit(dir, tt.app)
+			require.NoError(t, err)
+			require.Equal(t, path.Join(dir, "runapp-"+tt.app.Name+".service"), unitPath)
+
+			content, err := os.ReadFile(unitPath)
+			require.NoError(t, err)
+			for _, expected := range tt.expected {
+				require.Contains(t, string(content), expected)
+			}
+			for _, absent := range tt.absent {
+				require.NotContains(t, string(content), absent)
+			}
+		})
+	}
+}
+
+func TestWriteSystemdUnit_Scheduled(t *testing.T) {
+	_, err := WriteSystemdUnit(t.TempDir(), apps.App{Name: "backup", Mode: common.RunModeScheduled, Command: "./backup"})
+	require.ErrorIs(t, err, ErrScheduled)
+}
+
+func TestSystemdUnitName_Project(t *testing.T) {
+	t.Setenv(util.ProjectEnv, "shop")
+	require.Equal(t, "runapp-shop.api.service", SystemdUnitName("api"))
+}
+
+func TestSyncSystemdUnit(t *testing.T) {
+	testutil.SetupHome(t)
+	dir, err := SystemdUnitDir()
+	require.NoError(t, err)
+	unitPath := path.Join(dir, "runapp-api.service")
+
+	app := apps.App{Name: "api", Mode: common.RunModeOnce, Command: "./api"}
+
+	// apps that were not exported get no unit
+	require.NoError(t, SyncSystemdUnit(app))
+	require.NoFileExists(t, unitPath)
+
+	_, err = WriteSystemdUnit(dir, app)
+	require.NoError(t, err)
+
+	app.Command = "./api --verbose"
+	require.NoError(t, SyncSystemdUnit(app))
+	content, err := os.ReadFile(unitPath)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"./api --verbose"`)
+
+	require.NoError(t, RemoveSystemdUnit(app.Name))
+	require.NoFileExists(t, unitPath)
+	require.NoError(t, RemoveSystemdUnit(app.Name))
+}
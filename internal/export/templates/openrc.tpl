@@ -0,0 +1,31 @@
+#!/sbin/openrc-run
+# Generated by runapp export --format openrc
+
+description={{ squote (print "runapp: " .Name) }}
+command={{ squote .Shell }}
+command_args={{ dquote (print "-c " (squote .Command)) }}
+{{- if .CWD }}
+directory={{ squote .CWD }}
+{{- end }}
+{{- if eq .Restart "never" }}
+command_background="yes"
+pidfile="/run/${RC_SVCNAME}.pid"
+{{- else }}
+{{- if eq .Restart "on-failure" }}
+# supervise-daemon restarts the app whatever its exit code
+{{- end }}
+supervisor="supervise-daemon"
+respawn_delay={{ .Delay }}
+respawn_max={{ .MaxRetries }}
+respawn_period={{ .ResetWindow }}
+{{- end }}
+{{- range .Env }}
+export {{ .Key }}={{ squote .Value }}
+{{- end }}
+
+depend() {
+	need net
+{{- range .Dependencies }}
+	need {{ . }}
+{{- end }}
+}
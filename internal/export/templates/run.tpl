@@ -0,0 +1,15 @@
+#!/bin/sh
+# Generated by runapp export --format {{ .Format }}
+exec 2>&1
+{{- if eq .Format "runit" }}
+{{- range .Dependencies }}
+sv start {{ . }} || exit 1
+{{- end }}
+{{- end }}
+{{- if .CWD }}
+cd {{ squote .CWD }} || exit 1
+{{- end }}
+{{- range .Env }}
+export {{ .Key }}={{ squote .Value }}
+{{- end }}
+exec {{ .Shell }} -c {{ squote .Command }}
@@ -0,0 +1,11 @@
+#!/bin/sh
+# Generated by runapp export --format runit
+# $1 is the exit code of run, -1 when it was killed by a signal, sv down stops the restarts
+{{- if eq .Restart "never" }}
+exec sv down .
+{{- else }}
+{{- if eq .Restart "on-failure" }}
+[ "$1" = 0 ] && exec sv down .
+{{- end }}
+exec sleep {{ .Delay }}
+{{- end }}
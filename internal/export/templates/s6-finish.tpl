@@ -0,0 +1,11 @@
+#!/bin/sh
+# Generated by runapp export --format s6
+# $1 is the exit code of run, 256 when it was killed by a signal, exiting with 125 stops the restarts
+{{- if eq .Restart "never" }}
+exit 125
+{{- else if eq .Restart "on-failure" }}
+[ "$1" = 0 ] && exit 125
+exit 0
+{{- else }}
+exit 0
+{{- end }}
@@ -0,0 +1 @@
+longrun
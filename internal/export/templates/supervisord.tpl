@@ -0,0 +1,26 @@
+; Generated by runapp export --format supervisord
+{{- if .Dependencies }}
+; supervisord does not order programs, start {{ join .Dependencies ", " }} first
+{{- end }}
+[program:{{ .Service }}]
+command={{ .Shell }} -c {{ iniquote .Command }}
+{{- if .CWD }}
+directory={{ inipercent .CWD }}
+{{- end }}
+{{- if .Env }}
+environment={{ range $i, $env := .Env }}{{ if $i }},{{ end }}{{ $env.Key }}={{ iniquote $env.Value }}{{ end }}
+{{- end }}
+autostart=true
+{{- if eq .Restart "always" }}
+autorestart=true
+{{- else if eq .Restart "on-failure" }}
+autorestart=unexpected
+exitcodes=0
+{{- else }}
+autorestart=false
+{{- end }}
+{{- if .MaxRetries }}
+; supervisord does not limit the restarts after a crash, startretries only counts failed starts, max retries {{ .MaxRetries }} is not applied
+{{- end }}
+stopasgroup=true
+killasgroup=true
@@ -0,0 +1,29 @@
+# Generated by runapp export, runapp run and runapp remove keep the units of the systemd user directory in sync with the app
+[Unit]
+Description=runapp: {{ .Name }}
+After=network.target{{ range .Dependencies }} {{ . }}{{ end }}
+{{- if .Dependencies }}
+Wants={{ join .Dependencies " " }}
+{{- end }}
+{{- if .StartLimitBurst }}
+StartLimitIntervalSec={{ .StartLimitInterval }}
+StartLimitBurst={{ .StartLimitBurst }}
+{{- end }}
+
+[Service]
+Type=simple
+{{- if .WorkingDirectory }}
+WorkingDirectory={{ .WorkingDirectory }}
+{{- end }}
+{{- range .Environment }}
+Environment={{ . }}
+{{- end }}
+ExecStart={{ .ExecStart }}
+Restart={{ .Restart }}
+RestartSec={{ .RestartSec }}
+{{- range .Limits }}
+{{ . }}
+{{- end }}
+
+[Install]
+WantedBy=default.target
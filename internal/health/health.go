@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	// maxOutputLength limits the output of a failed command kept in the error
+	maxOutputLength  = 200
+	commandWaitDelay = time.Second
+)
+
+// ChangeFunc is called when the app becomes unhealthy (err is the last failed check) and when it recovers (err is nil)
+type ChangeFunc func(err error)
+
+// Check probes the app once, the error explains why the app is not healthy
+func Check(ctx context.Context, check apps.HealthCheck, app apps.App) error {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	switch {
+	case len(check.HTTP) != 0:
+		return checkHTTP(ctx, check.HTTP, check.ExpectStatus)
+	case len(check.TCP) != 0:
+		return checkTCP(ctx, check.TCP)
+	case len(check.Command) != 0:
+		return checkCommand(ctx, check.Command, app)
+	}
+	return errors.New("health check has no probe")
+}
+
+// Monitor checks the app every interval until ctx is done, the app becomes unhealthy after
+// FailureThreshold consecutive failed checks and healthy again after the first successful one
+func Monitor(ctx context.Context, check apps.HealthCheck, app apps.App, onChange ChangeFunc) {
+	check = check.WithDefaults()
+
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	healthy := true
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := Check(ctx, check, app)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			failures = 0
+			if !healthy {
+				healthy = true
+				onChange(nil)
+			}
+			continue
+		}
+
+		failures++
+		util.DebugLog("health check of %s failed (%d/%d): %v", app.Name, failures, check.FailureThreshold, err)
+		if healthy && failures >= check.FailureThreshold {
+			healthy = false
+			onChange(err)
+		}
+	}
+}
+
+func checkHTTP(ctx context.Context, url string, expectStatus int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(body io.ReadCloser) {
+		// drain the body so the connection can be reused by the next check
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			util.DebugLog("failed to read health check response: %v", err)
+		}
+		if err := body.Close(); err != nil {
+			util.DebugLog("failed to close health check response: %v", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != expectStatus {
+		return fmt.Errorf("GET %s returned %d, expected %d", url, res.StatusCode, expectStatus)
+	}
+	return nil
+}
+
+func checkTCP(ctx context.Context, addr string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkCommand(ctx context.Context, command string, app apps.App) error {
+	cmdArgs := append(util.GetShellArgs(), command)
+
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	cmd.Env = app.Env
+	cmd.Dir = app.CWD
+	// a timed out check must not leave its children behind
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = commandWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s timed out", command)
+	}
+
+	out := strings.TrimSpace(string(output))
+	if len(out) > maxOutputLength {
+		out = out[:maxOutputLength] + "..."
+	}
+	if len(out) == 0 {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return fmt.Errorf("%s: %w: %s", command, err, out)
+}
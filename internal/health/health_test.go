@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+func TestCheck(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	// a port that was just released is very likely closed
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closed.Addr().String()
+	require.NoError(t, closed.Close())
+
+	tests := []struct {
+		name    string
+		check   apps.HealthCheck
+		wantErr string
+	}{
+		{name: "http ok", check: apps.HealthCheck{HTTP: server.URL + "/health"}},
+		{name: "http unexpected status", check: apps.HealthCheck{HTTP: server.URL + "/broken"}, wantErr: "returned 500, expected 200"},
+		{name: "http expected status", check: apps.HealthCheck{HTTP: server.URL + "/broken", ExpectStatus: 500}},
+		{name: "tcp open", check: apps.HealthCheck{TCP: listener.Addr().String()}},
+		{name: "tcp closed", check: apps.HealthCheck{TCP: closedAddr}, wantErr: "refused"},
+		{name: "command ok", check: apps.HealthCheck{Command: "true"}},
+		{name: "command failed", check: apps.HealthCheck{Command: "echo db is down; exit 2"}, wantErr: "exit status 2: db is down"},
+		{name: "command timeout", check: apps.HealthCheck{Command: "sleep 5", Timeout: 50 * time.Millisecond}, wantErr: "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(context.Background(), tt.check.WithDefaults(), apps.App{Env: os.Environ()})
+			if len(tt.wantErr) != 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+
+	// the app is healthy while the file exists
+	healthyFile := filepath.Join(t.TempDir(), "healthy")
+	check := apps.HealthCheck{
+		Command:          "test -f " + healthyFile,
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 3,
+	}
+
+	var mu sync.Mutex
+	var changes []bool
+	onChange := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, err == nil)
+	}
+	changed := func(expected ...bool) func() bool {
+		return func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(changes) == len(expected) && (len(expected) == 0 || changes[len(changes)-1] == expected[len(expected)-1])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Monitor(ctx, check, apps.App{Env: os.Environ()}, onChange)
+
+	require.Eventually(t, changed(false), 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(healthyFile, nil, 0644))
+	require.Eventually(t, changed(false, true), 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(healthyFile))
+	require.Eventually(t, changed(false, true, false), 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// WaitReady blocks until every readiness probe of the app passes or ctx is done,
+// logMatched must be closed once a line of the app matched the log pattern
+func WaitReady(ctx context.Context, readiness apps.Readiness, app apps.App, logMatched <-chan struct{}) error {
+	readiness = readiness.WithDefaults()
+
+	if len(readiness.LogPattern) != 0 {
+		select {
+		case <-logMatched:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for {
+		err := CheckReady(ctx, readiness, app)
+		if err == nil {
+			return nil
+		}
+		util.DebugLog("%s is not ready: %v", app.Name, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readiness.Interval):
+		}
+	}
+}
+
+// CheckReady runs the TCP, HTTP and file probes once, the log pattern is matched by the supervisor
+func CheckReady(ctx context.Context, readiness apps.Readiness, app apps.App) error {
+	ctx, cancel := context.WithTimeout(ctx, apps.DefaultHealthTimeout)
+	defer cancel()
+
+	if len(readiness.TCP) != 0 {
+		if err := checkTCP(ctx, readiness.TCP); err != nil {
+			return err
+		}
+	}
+	if len(readiness.HTTP) != 0 {
+		if err := checkHTTP(ctx, readiness.HTTP, apps.DefaultHealthExpectStatus); err != nil {
+			return err
+		}
+	}
+	if len(readiness.File) != 0 {
+		path := readiness.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(app.CWD, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
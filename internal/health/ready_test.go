@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+func TestWaitReady(t *testing.T) {
+	cwd := t.TempDir()
+	readiness := apps.Readiness{LogPattern: "ready", File: "app.pid", Interval: 10 * time.Millisecond}
+	app := apps.App{Name: "app", CWD: cwd}
+
+	logMatched := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- WaitReady(context.Background(), readiness, app, logMatched)
+	}()
+
+	// the file alone is not enough while the log pattern did not match
+	require.NoError(t, os.WriteFile(filepath.Join(cwd, "app.pid"), nil, 0644))
+	select {
+	case <-done:
+		t.Fatal("expected the app to wait for the log pattern")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(logMatched)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the app to be ready")
+	}
+}
+
+func TestWaitReady_Timeout(t *testing.T) {
+	readiness := apps.Readiness{File: "missing", Interval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := WaitReady(ctx, readiness, apps.App{Name: "app", CWD: t.TempDir()}, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
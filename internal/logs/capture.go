@@ -0,0 +1,109 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// Record is a single line of the combined log
+type Record struct {
+	Time   time.Time `json:"ts"`
+	Stream LogType   `json:"stream"`
+	Line   string    `json:"line"`
+}
+
+// Capture writes every complete line of the app output as a Record into the combined log,
+// a single mutex keeps the stdout and stderr lines in the order they were received
+type Capture struct {
+	mu       sync.Mutex
+	combined io.Writer
+	now      func() time.Time
+	onLine   func(line string)
+}
+
+func NewCapture(combined io.Writer) *Capture {
+	return &Capture{combined: combined, now: time.Now}
+}
+
+// Writer returns a writer for the given stream that passes the raw output to raw
+// and records the complete lines in the combined log
+func (c *Capture) Writer(raw io.Writer, stream LogType) *LineWriter {
+	return &LineWriter{capture: c, raw: raw, stream: stream}
+}
+
+// OnLine calls fn with every complete line of both streams until it is replaced, nil removes it
+func (c *Capture) OnLine(fn func(line string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onLine = fn
+}
+
+func (c *Capture) record(stream LogType, line []byte) {
+	b, err := json.Marshal(Record{Time: c.now(), Stream: stream, Line: string(line)})
+	if err != nil {
+		util.DebugLog("failed to marshal log record: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.combined.Write(append(b, '\n')); err != nil {
+		util.DebugLog("failed to write combined log: %v", err)
+	}
+	if c.onLine != nil {
+		c.onLine(string(line))
+	}
+}
+
+type LineWriter struct {
+	capture *Capture
+	raw     io.Writer
+	stream  LogType
+	partial []byte
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	n, err := w.raw.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	data := p
+	for {
+		idx := bytes.IndexByte(data, '\n')
+		if idx == -1 {
+			break
+		}
+
+		line := data[:idx]
+		if len(w.partial) != 0 {
+			line = append(w.partial, line...)
+			w.partial = nil
+		}
+		w.capture.record(w.stream, bytes.TrimSuffix(line, []byte("\r")))
+		data = data[idx+1:]
+	}
+
+	if len(data) != 0 {
+		w.partial = append(w.partial, data...)
+		if len(w.partial) >= maxBufferCapacity {
+			w.Flush()
+		}
+	}
+	return n, nil
+}
+
+// Flush records the pending line that was not terminated by a newline
+func (w *LineWriter) Flush() {
+	if len(w.partial) == 0 {
+		return
+	}
+	w.capture.record(w.stream, w.partial)
+	w.partial = nil
+}
@@ -0,0 +1,113 @@
+package logs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClock returns a clock that advances by a second on every call
+func fixedClock(start time.Time) func() time.Time {
+	current := start.Add(-time.Second)
+	return func() time.Time {
+		current = current.Add(time.Second)
+		return current
+	}
+}
+
+func TestCapture(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var combined, rawOut, rawErr bytes.Buffer
+	capture := NewCapture(&combined)
+	capture.now = fixedClock(start)
+
+	var lines []string
+	capture.OnLine(func(line string) {
+		lines = append(lines, line)
+	})
+
+	stdout := capture.Writer(&rawOut, OutLogs)
+	stderr := capture.Writer(&rawErr, ErrLogs)
+
+	_, err := stdout.Write([]byte("first\nsec"))
+	require.NoError(t, err)
+	_, err = stderr.Write([]byte("oops\r\n"))
+	require.NoError(t, err)
+	_, err = stdout.Write([]byte("ond\nno newline"))
+	require.NoError(t, err)
+	stdout.Flush()
+	stderr.Flush()
+
+	require.Equal(t, "first\nsecond\nno newline", rawOut.String(), "expected raw output to be passed through untouched")
+	require.Equal(t, "oops\r\n", rawErr.String())
+
+	require.Equal(t, strings.Join([]string{
+		`{"ts":"2025-01-01T10:00:00Z","stream":"stdout","line":"first"}`,
+		`{"ts":"2025-01-01T10:00:01Z","stream":"stderr","line":"oops"}`,
+		`{"ts":"2025-01-01T10:00:02Z","stream":"stdout","line":"second"}`,
+		`{"ts":"2025-01-01T10:00:03Z","stream":"stdout","line":"no newline"}`,
+	}, "\n")+"\n", combined.String())
+	require.Equal(t, []string{"first", "oops", "second", "no newline"}, lines)
+}
+
+func TestPrintCombined(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "combined.log")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join([]string{
+		`{"ts":"2025-01-01T10:00:00Z","stream":"stdout","line":"out-1"}`,
+		`{"ts":"2025-01-01T10:00:01Z","stream":"stderr","line":"err-1"}`,
+		`not json`,
+		`{"ts":"2025-01-01T10:00:02Z","stream":"stdout","line":"out-2"}`,
+	}, "\n")+"\n"), 0644))
+
+	tests := []struct {
+		name        string
+		opts        Options
+		expectedOut string
+		expectedErr string
+	}{
+		{
+			name:        "all",
+			opts:        Options{Type: AllLogs},
+			expectedOut: "out-1\nout-2\n",
+			expectedErr: "\x1b[0m\x1b[31merr-1\x1b[39m\x1b[0m\n",
+		},
+		{
+			name:        "stdout only",
+			opts:        Options{Type: OutLogs},
+			expectedOut: "out-1\nout-2\n",
+		},
+		{
+			name:        "since",
+			opts:        Options{Type: AllLogs, Since: time.Date(2025, 1, 1, 10, 0, 1, 0, time.UTC)},
+			expectedOut: "out-2\n",
+			expectedErr: "\x1b[0m\x1b[31merr-1\x1b[39m\x1b[0m\n",
+		},
+		{
+			name:        "until",
+			opts:        Options{Type: AllLogs, Until: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)},
+			expectedOut: "out-1\n",
+		},
+		{
+			name:        "timestamps",
+			opts:        Options{Type: OutLogs, Timestamps: true, Since: time.Date(2025, 1, 1, 10, 0, 2, 0, time.UTC)},
+			expectedOut: "\x1b[0m\x1b[90m2025-01-01T10:00:02.000Z\x1b[39m \x1b[0mout-2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			opts := tt.opts
+			opts.Tail = TailAll
+			require.NoError(t, printSource(&out, &errOut, combinedSource(path, opts), opts))
+			require.Equal(t, tt.expectedOut, out.String())
+			require.Equal(t, tt.expectedErr, errOut.String())
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package logs
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/liamg/tml"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// hasCombined reports whether the app was started with a combined (timestamped) log
+func hasCombined(app apps.App) bool {
+	if len(app.CombinedPath) == 0 {
+		return false
+	}
+	_, err := os.Stat(app.CombinedPath)
+	return err == nil
+}
+
+// FormatLog formats a log line for the terminal, errors are red, grep matches highlighted and timestamps dimmed
+func FormatLog(log Log, timestamps bool) string {
+	value := formatValue(log.Value, log.IsErr)
+	if len(log.Matches) != 0 {
+		var b strings.Builder
+		prev := 0
+		for _, match := range log.Matches {
+			if match[0] > prev {
+				b.WriteString(formatValue(log.Value[prev:match[0]], log.IsErr))
+			}
+			b.WriteString(tml.Sprintf("<bold><yellow>%s</yellow></bold>", log.Value[match[0]:match[1]]))
+			prev = match[1]
+		}
+		if prev < len(log.Value) {
+			b.WriteString(formatValue(log.Value[prev:], log.IsErr))
+		}
+		value = b.String()
+	}
+	if timestamps && !log.Time.IsZero() {
+		value = tml.Sprintf("<darkgrey>%s</darkgrey> ", log.Time.Format(TimestampFormat)) + value
+	}
+	return value
+}
+
+func formatValue(value string, isErr bool) string {
+	if isErr {
+		return tml.Sprintf("<red>%s</red>", value)
+	}
+	return value
+}
+
+func decodeRecord(line string) (Record, bool) {
+	var record Record
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		util.DebugLog("malformed combined log line: %v", err)
+		return Record{}, false
+	}
+	return record, true
+}
+
+// parseRecord decodes a combined log line, returns false for malformed lines or lines that are filtered out
+func parseRecord(line string, opts Options) (Log, bool) {
+	record, ok := decodeRecord(line)
+	if !ok || !opts.matchesStream(record.Stream) || !opts.inRange(record.Time) {
+		return Log{}, false
+	}
+	return Log{Value: record.Line, IsErr: record.Stream == ErrLogs, Time: record.Time}, true
+}
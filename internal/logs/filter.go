@@ -0,0 +1,151 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Level is the severity of a log line, detected from its text
+type Level int
+
+const (
+	// LevelUnknown is used for lines without a recognizable level, as a filter it shows every line
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelTrace: "trace",
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+	LevelFatal: "fatal",
+}
+
+var ValidLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseLevel parses a level name as used by --level
+func ParseLevel(value string) (Level, error) {
+	for level, name := range levelNames {
+		if strings.EqualFold(name, value) {
+			return level, nil
+		}
+	}
+	return LevelUnknown, fmt.Errorf("level must be one of: %s", strings.Join(ValidLevels, ", "))
+}
+
+// matches the common spellings like INFO, [warn], level=error, "level":"debug"
+var levelRegex = regexp.MustCompile(`(?i)\b(trace|debug|dbg|info|notice|warn|warning|error|err|fatal|panic|crit|critical)\b`)
+
+// detectLevel returns the level of the first level-like word in the line
+func detectLevel(line string) Level {
+	match := levelRegex.FindString(line)
+	switch strings.ToLower(match) {
+	case "trace":
+		return LevelTrace
+	case "debug", "dbg":
+		return LevelDebug
+	case "info", "notice":
+		return LevelInfo
+	case "warn", "warning":
+		return LevelWarn
+	case "error", "err":
+		return LevelError
+	case "fatal", "panic", "crit", "critical":
+		return LevelFatal
+	}
+	return LevelUnknown
+}
+
+// filter applies Grep, Invert, Level and the context lines of Options to the logs of a single source,
+// it keeps state between lines so the same filter must be used for the whole source
+type filter struct {
+	opts Options
+	// before holds up to opts.Before lines that were not shown, in case the next line matches
+	before []Log
+	// afterLeft is the amount of lines still shown after the last match
+	afterLeft int
+	// lastLevel is used for lines without a level, e.g. the lines of a stack trace
+	lastLevel Level
+}
+
+func newFilter(opts Options) *filter {
+	return &filter{opts: opts}
+}
+
+func (o Options) hasFilter() bool {
+	return o.Grep != nil || o.Level != LevelUnknown
+}
+
+// push processes a log and calls emit for every log that should be shown, in order
+func (f *filter) push(log Log, emit func(log Log) error) error {
+	if !f.opts.hasFilter() {
+		return emit(log)
+	}
+
+	if matched, ok := f.match(log); ok {
+		for _, ctxLog := range f.before {
+			if err := emit(ctxLog); err != nil {
+				return err
+			}
+		}
+		f.before = f.before[:0]
+		f.afterLeft = f.opts.After
+		return emit(matched)
+	}
+
+	if f.afterLeft > 0 {
+		f.afterLeft--
+		return emit(log)
+	}
+
+	if f.opts.Before > 0 {
+		if len(f.before) == f.opts.Before {
+			f.before = append(f.before[:0], f.before[1:]...)
+		}
+		f.before = append(f.before, log)
+	}
+	return nil
+}
+
+// match returns the log with the grep matches set and whether it passes the filter
+func (f *filter) match(log Log) (Log, bool) {
+	level := detectLevel(log.Value)
+	if level == LevelUnknown {
+		level = f.lastLevel
+	}
+	f.lastLevel = level
+
+	if f.opts.Level != LevelUnknown && level < f.opts.Level {
+		return Log{}, false
+	}
+
+	if f.opts.Grep == nil {
+		return log, true
+	}
+
+	matches := f.opts.Grep.FindAllStringIndex(log.Value, -1)
+	if f.opts.Invert {
+		return log, len(matches) == 0
+	}
+	if len(matches) == 0 {
+		return Log{}, false
+	}
+
+	log.Matches = matches
+	return log, true
+}
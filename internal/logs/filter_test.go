@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func runFilter(t *testing.T, opts Options, lines ...string) []string {
+	t.Helper()
+
+	f := newFilter(opts)
+	var res []string
+	for _, line := range lines {
+		require.NoError(t, f.push(Log{Value: line}, func(log Log) error {
+			res = append(res, log.Value)
+			return nil
+		}))
+	}
+	return res
+}
+
+func TestDetectLevel(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Level
+	}{
+		{line: "2025-01-02 INFO server started", expected: LevelInfo},
+		{line: "[warn] disk almost full", expected: LevelWarn},
+		{line: `{"level":"error","msg":"boom"}`, expected: LevelError},
+		{line: "time=now level=debug msg=tick", expected: LevelDebug},
+		{line: "panic: runtime error", expected: LevelFatal},
+		{line: "INFO: more information about errors", expected: LevelInfo},
+		{line: "informational", expected: LevelUnknown},
+		{line: "plain line", expected: LevelUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			require.Equal(t, tt.expected, detectLevel(tt.line))
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	require.NoError(t, err)
+	require.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("loud")
+	require.Error(t, err)
+}
+
+func TestFilter_Grep(t *testing.T) {
+	lines := []string{"a1", "b2", "a3", "b4"}
+
+	opts := DefaultOptions()
+	opts.Grep = regexp.MustCompile(`^a`)
+	require.Equal(t, []string{"a1", "a3"}, runFilter(t, opts, lines...))
+
+	opts.Invert = true
+	require.Equal(t, []string{"b2", "b4"}, runFilter(t, opts, lines...))
+}
+
+func TestFilter_Matches(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Grep = regexp.MustCompile(`o`)
+
+	f := newFilter(opts)
+	var res []Log
+	require.NoError(t, f.push(Log{Value: "foo bar"}, func(log Log) error {
+		res = append(res, log)
+		return nil
+	}))
+	require.Len(t, res, 1)
+	require.Equal(t, [][]int{{1, 2}, {2, 3}}, res[0].Matches)
+}
+
+func TestFilter_Context(t *testing.T) {
+	lines := []string{"1", "2", "3", "match-4", "5", "6", "7", "8", "match-9", "10"}
+
+	opts := DefaultOptions()
+	opts.Grep = regexp.MustCompile(`match`)
+	opts.Before = 2
+	opts.After = 1
+	require.Equal(t, []string{"2", "3", "match-4", "5", "7", "8", "match-9", "10"}, runFilter(t, opts, lines...))
+
+	opts.Before = 5
+	opts.After = 0
+	require.Equal(t, []string{"1", "2", "3", "match-4", "5", "6", "7", "8", "match-9"}, runFilter(t, opts, lines...), "expected lines to not be shown twice")
+}
+
+func TestFilter_Level(t *testing.T) {
+	lines := []string{
+		"DEBUG tick",
+		"ERROR request failed",
+		"  at handler.go:10",
+		"INFO served",
+		"WARN slow request",
+	}
+
+	opts := DefaultOptions()
+	opts.Level = LevelWarn
+	require.Equal(t, []string{"ERROR request failed", "  at handler.go:10", "WARN slow request"}, runFilter(t, opts, lines...),
+		"expected lines without a level to follow the previous line")
+
+	opts.Grep = regexp.MustCompile(`request`)
+	require.Equal(t, []string{"ERROR request failed", "WARN slow request"}, runFilter(t, opts, lines...))
+}
+
+func TestFormatLog_Matches(t *testing.T) {
+	log := Log{Value: "foo bar", Matches: [][]int{{4, 7}}}
+	require.Equal(t, "foo \x1b[0m\x1b[1m\x1b[33mbar\x1b[39m\x1b[0m\x1b[39m\x1b[0m", FormatLog(log, false))
+
+	log.IsErr = true
+	require.Equal(t, "\x1b[0m\x1b[31mfoo \x1b[39m\x1b[0m\x1b[0m\x1b[1m\x1b[33mbar\x1b[39m\x1b[0m\x1b[39m\x1b[0m", FormatLog(log, false),
+		"expected the rest of an error line to stay red")
+}
@@ -1,5 +1,11 @@
 package logs
 
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
 type LogType = string
 
 const (
@@ -17,3 +23,56 @@ var ValidTypes = []LogType{
 const (
 	maxBufferCapacity = 10 * 1024 * 1024 // 10MB
 )
+
+const (
+	TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+var (
+	ErrNoTimestamps = errors.New("timestamps are not available for this app, restart it to capture them")
+)
+
+// Options select and format the shown logs, start from DefaultOptions
+type Options struct {
+	Type LogType
+	// Tail only shows the last Tail lines, TailAll shows everything
+	Tail int
+	// Follow keeps streaming new lines of running apps
+	Follow bool
+	// Timestamps prefixes every line with the time it was captured
+	Timestamps bool
+	// Since and Until limit the lines to the ones captured in the range, zero values are unbounded
+	Since time.Time
+	Until time.Time
+	// Grep only shows lines matching the expression (or not matching it with Invert), applied after Tail
+	Grep   *regexp.Regexp
+	Invert bool
+	// Before and After are the amount of lines shown around the matching lines
+	Before int
+	After  int
+	// Level only shows lines logged at this level or above, lines without a level share the level of the previous line
+	Level Level
+}
+
+// DefaultOptions shows every line of stdout and stderr
+func DefaultOptions() Options {
+	return Options{Type: AllLogs, Tail: TailAll, Follow: true}
+}
+
+func (o Options) hasRange() bool {
+	return !o.Since.IsZero() || !o.Until.IsZero()
+}
+
+func (o Options) inRange(t time.Time) bool {
+	if !o.Since.IsZero() && t.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && t.After(o.Until) {
+		return false
+	}
+	return true
+}
+
+func (o Options) matchesStream(stream LogType) bool {
+	return o.Type == AllLogs || o.Type == stream
+}
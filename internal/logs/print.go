@@ -6,34 +6,105 @@ import (
 	"io"
 	"os"
 
-	"github.com/liamg/tml"
-
 	"github.com/0xB1a60/runapp/internal/apps"
 	"github.com/0xB1a60/runapp/internal/util"
 )
 
-func PrintLines(app apps.App, logType LogType) error {
-	if logType == OutLogs || logType == AllLogs {
-		if err := printFile(os.Stdout, app.StdoutPath, false); err != nil {
+// PrintLines prints the logs of the app, in capture order when the app has a combined log
+func PrintLines(app apps.App, opts Options) error {
+	list, err := sources(app, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, src := range list {
+		if err := printSource(os.Stdout, os.Stderr, src, opts); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// EachLine calls fn for every log of the app selected by opts (ignoring opts.Follow),
+// in capture order when the app has a combined log
+func EachLine(app apps.App, opts Options, fn func(log Log) error) error {
+	list, err := sources(app, opts)
+	if err != nil {
+		return err
+	}
 
-	if logType == ErrLogs || logType == AllLogs {
-		if err := printFile(os.Stderr, app.StderrPath, true); err != nil {
+	for _, src := range list {
+		if err := eachSourceLine(src, opts, fn); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// printFile prints the contents of a file to the given writer
+// printSource prints the lines of the source (all of them or only the last opts.Tail),
+// stdout logs go to out and stderr logs to errOut
+func printSource(out io.Writer, errOut io.Writer, src source, opts Options) error {
+	return eachSourceLine(src, opts, func(log Log) error {
+		w := out
+		if log.IsErr {
+			w = errOut
+		}
+		_, err := fmt.Fprintln(w, FormatLog(log, opts.Timestamps))
+		return err
+	})
+}
+
+// eachSourceLine calls fn for the logs of the source (all of them or only the last opts.Tail) that pass the filters
+func eachSourceLine(src source, opts Options, fn func(log Log) error) error {
+	f := newFilter(opts)
+	parse := func(line string) error {
+		log, ok := src.parse(line)
+		if !ok {
+			return nil
+		}
+		return f.push(log, fn)
+	}
+
+	if opts.Tail != TailAll {
+		lines, _, err := lastLines(src.path, opts.Tail, src.keep)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := parse(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	segments, err := Segments(src.path)
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if err := scanSegment(segment, parse); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printFile prints the contents of a file (gzipped or not) to the given writer
 func printFile(w io.Writer, filename string, asError bool) error {
-	file, err := os.Open(filename)
+	return scanSegment(filename, func(line string) error {
+		_, err := fmt.Fprintln(w, FormatLog(Log{Value: line, IsErr: asError}, false))
+		return err
+	})
+}
+
+// scanSegment calls fn for every line of a log segment (gzipped or not)
+func scanSegment(filename string, fn func(line string) error) error {
+	file, err := openSegment(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", filename, err)
 	}
-	defer func(file *os.File) {
+	defer func(file io.ReadCloser) {
 		if err := file.Close(); err != nil {
 			util.DebugLog("Failed to close file %s: %s", filename, err)
 		}
@@ -46,13 +117,7 @@ func printFile(w io.Writer, filename string, asError bool) error {
 	scanner.Buffer(buf, maxBufferCapacity)
 
 	for scanner.Scan() {
-		if asError {
-			if _, err := fmt.Fprintln(w, tml.Sprintf("<red>%s</red>", scanner.Text())); err != nil {
-				return err
-			}
-			continue
-		}
-		if _, err := fmt.Fprintln(w, scanner.Text()); err != nil {
+		if err := fn(scanner.Text()); err != nil {
 			return err
 		}
 	}
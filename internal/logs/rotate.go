@@ -0,0 +1,280 @@
+package logs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	gzipExt = ".gz"
+)
+
+// RotatingFile is a log file that is rotated into path.1, path.2... (optionally gzipped)
+// once it grows over the max size or gets older than the max age
+type RotatingFile struct {
+	mu sync.Mutex
+
+	path string
+	cfg  apps.LogRotation
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// compressing tracks the compression of the last rotated segment, it runs without the lock so a write never waits for it
+	compressing sync.WaitGroup
+}
+
+// CreateRotating truncates the log file at path and opens it for appending
+func CreateRotating(path string, cfg apps.LogRotation) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, cfg: cfg}
+	if err := r.open(os.O_TRUNC); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open(flag int) error {
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY|flag, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			util.DebugLog("failed to rotate %s: %v", r.path, err)
+			if r.file == nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) shouldRotate(incoming int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.cfg.MaxSize > 0 && r.size+incoming > int64(r.cfg.MaxSize) {
+		return true
+	}
+	return r.cfg.MaxAge > 0 && time.Since(r.openedAt) >= r.cfg.MaxAge
+}
+
+// rotate shifts the existing segments by one, drops the ones over the limit and starts a new file
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		util.DebugLog("failed to close %s: %v", r.path, err)
+	}
+	r.file = nil
+
+	// the segment being compressed must not be shifted under the compression
+	r.compressing.Wait()
+
+	keep := r.cfg.Keep()
+
+	segments, err := Segments(r.path)
+	if err != nil {
+		return err
+	}
+	// oldest first, so shifting never overwrites a segment that was not moved yet
+	for _, segment := range segments {
+		index, compressed, ok := segmentIndex(r.path, segment)
+		if !ok || index == 0 {
+			continue
+		}
+		if index >= keep {
+			if err := os.Remove(segment); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(segment, segmentPath(r.path, index+1, compressed)); err != nil {
+			return err
+		}
+	}
+
+	rotated := segmentPath(r.path, 1, false)
+	if err := os.Rename(r.path, rotated); err != nil {
+		return errors.Join(err, r.open(0))
+	}
+
+	if r.cfg.Compress {
+		r.compressing.Go(func() {
+			if err := compressFile(rotated); err != nil {
+				util.DebugLog("failed to compress %s: %v", rotated, err)
+			}
+		})
+	}
+	return r.open(os.O_TRUNC)
+}
+
+// Close closes the log file once the last rotated segment is compressed
+func (r *RotatingFile) Close() error {
+	r.compressing.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func(src *os.File) {
+		if err := src.Close(); err != nil {
+			util.DebugLog("Failed to close file %s: %s", path, err)
+		}
+	}(src)
+
+	// the temporary name is not a segment, the readers keep reading the uncompressed one until the rename
+	tmpPath := path + gzipExt + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		return errors.Join(err, gz.Close(), dst.Close(), os.Remove(tmpPath))
+	}
+	if err := errors.Join(gz.Close(), dst.Close()); err != nil {
+		return errors.Join(err, os.Remove(tmpPath))
+	}
+	if err := os.Rename(tmpPath, path+gzipExt); err != nil {
+		return errors.Join(err, os.Remove(tmpPath))
+	}
+	return os.Remove(path)
+}
+
+// Segments returns the rotated segments of the log file from the oldest to the newest,
+// the log file itself is always the last entry
+func Segments(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	type segment struct {
+		path  string
+		index int
+	}
+	rotated := make([]segment, 0, len(matches))
+	for _, match := range matches {
+		if index, _, ok := segmentIndex(path, match); ok {
+			rotated = append(rotated, segment{path: match, index: index})
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotated[i].index > rotated[j].index
+	})
+
+	res := make([]string, 0, len(rotated)+1)
+	for _, s := range rotated {
+		res = append(res, s.path)
+	}
+	return append(res, path), nil
+}
+
+// RemoveSegments removes the rotated segments of the log file, the log file itself is kept
+func RemoveSegments(path string) error {
+	segments, err := Segments(path)
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments[:len(segments)-1] {
+		if err := os.Remove(segment); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func segmentPath(path string, index int, compressed bool) string {
+	res := fmt.Sprintf("%s.%d", path, index)
+	if compressed {
+		res += gzipExt
+	}
+	return res
+}
+
+// segmentIndex parses path.N or path.N.gz, the log file itself has index 0
+func segmentIndex(path string, segment string) (int, bool, bool) {
+	if segment == path {
+		return 0, false, true
+	}
+
+	suffix, ok := strings.CutPrefix(segment, path+".")
+	if !ok {
+		return 0, false, false
+	}
+
+	suffix, compressed := strings.CutSuffix(suffix, gzipExt)
+	index, err := strconv.Atoi(suffix)
+	if err != nil || index <= 0 {
+		return 0, false, false
+	}
+	return index, compressed, true
+}
+
+// openSegment opens a log segment, transparently decompressing gzipped ones
+func openSegment(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipExt) {
+		return file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	return &gzipReadCloser{Reader: gz, file: file}, nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipReadCloser) Close() error {
+	return errors.Join(g.Reader.Close(), g.file.Close())
+}
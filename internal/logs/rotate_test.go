@@ -0,0 +1,128 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+
+	r, err := CreateRotating(path, apps.LogRotation{MaxSize: 10, MaxFiles: 2})
+	require.NoError(t, err)
+
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		_, err := r.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+
+	segments, err := Segments(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{path + ".2", path + ".1", path}, segments, "expected the oldest segment to be dropped")
+
+	var buf bytes.Buffer
+	require.NoError(t, printSource(&buf, &buf, rawSource(path, false), DefaultOptions()))
+	require.Equal(t, "line-2\nline-3\nline-4\n", buf.String())
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stderr.log")
+
+	r, err := CreateRotating(path, apps.LogRotation{MaxSize: 10, MaxFiles: 5, Compress: true})
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := r.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+
+	segments, err := Segments(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{path + ".2.gz", path + ".1.gz", path}, segments)
+
+	var buf bytes.Buffer
+	require.NoError(t, printSource(&buf, &buf, rawSource(path, false), DefaultOptions()))
+	require.Equal(t, "first\nsecond\nthird\n", buf.String())
+
+	require.NoError(t, RemoveSegments(path))
+	segments, err = Segments(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{path}, segments)
+	require.FileExists(t, path)
+}
+
+func TestRotatingFile_CompressRepeated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+
+	r, err := CreateRotating(path, apps.LogRotation{MaxSize: 10, MaxFiles: 3, Compress: true})
+	require.NoError(t, err)
+
+	for i := range 20 {
+		_, err := fmt.Fprintf(r, "line-%02d\n", i)
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+
+	segments, err := Segments(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{path + ".3.gz", path + ".2.gz", path + ".1.gz", path}, segments, "expected every rotated segment to be compressed once")
+
+	var buf bytes.Buffer
+	require.NoError(t, printSource(&buf, &buf, rawSource(path, false), DefaultOptions()))
+	require.Equal(t, "line-16\nline-17\nline-18\nline-19\n", buf.String())
+}
+
+func TestRotatingFile_Disabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	require.NoError(t, os.WriteFile(path, []byte("previous run\n"), 0644))
+
+	r, err := CreateRotating(path, apps.LogRotation{})
+	require.NoError(t, err)
+
+	_, err = r.Write(bytes.Repeat([]byte("a"), 1024))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	segments, err := Segments(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{path}, segments)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, content, 1024, "expected the log file to be truncated on create")
+}
+
+func TestSegmentIndex(t *testing.T) {
+	tests := []struct {
+		segment    string
+		index      int
+		compressed bool
+		ok         bool
+	}{
+		{segment: "/logs/stdout.log", index: 0, ok: true},
+		{segment: "/logs/stdout.log.1", index: 1, ok: true},
+		{segment: "/logs/stdout.log.12.gz", index: 12, compressed: true, ok: true},
+		{segment: "/logs/stdout.log.bak", ok: false},
+		{segment: "/logs/stdout.log.1.gz.tmp", ok: false},
+		{segment: "/logs/stdout.log.0", ok: false},
+		{segment: "/logs/stderr.log.1", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			index, compressed, ok := segmentIndex("/logs/stdout.log", tt.segment)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.index, index)
+			require.Equal(t, tt.compressed, compressed)
+		})
+	}
+}
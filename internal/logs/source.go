@@ -0,0 +1,61 @@
+package logs
+
+import (
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+// source is a log file together with the way its lines are turned into logs
+type source struct {
+	path string
+	// parse returns false for lines that are malformed or filtered out
+	parse func(line string) (Log, bool)
+	keep  keepFunc
+}
+
+// sources returns the combined log of the app or, for apps started without one, its stdout and stderr files
+func sources(app apps.App, opts Options) ([]source, error) {
+	if hasCombined(app) {
+		return []source{combinedSource(app.CombinedPath, opts)}, nil
+	}
+
+	if opts.hasRange() {
+		return nil, ErrNoTimestamps
+	}
+
+	var res []source
+	if opts.matchesStream(OutLogs) {
+		res = append(res, rawSource(app.StdoutPath, false))
+	}
+	if opts.matchesStream(ErrLogs) {
+		res = append(res, rawSource(app.StderrPath, true))
+	}
+	return res, nil
+}
+
+func rawSource(path string, isErr bool) source {
+	return source{
+		path: path,
+		parse: func(line string) (Log, bool) {
+			return Log{Value: line, IsErr: isErr}, true
+		},
+		keep: keepAll,
+	}
+}
+
+func combinedSource(path string, opts Options) source {
+	return source{
+		path: path,
+		parse: func(line string) (Log, bool) {
+			return parseRecord(line, opts)
+		},
+		keep: func(line string) (bool, bool) {
+			record, ok := decodeRecord(line)
+			if !ok {
+				return false, true
+			}
+			// the combined log is chronological, nothing before since can match
+			more := opts.Since.IsZero() || !record.Time.Before(opts.Since)
+			return opts.matchesStream(record.Stream) && opts.inRange(record.Time), more
+		},
+	}
+}
@@ -2,20 +2,44 @@ package logs
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/nxadm/tail"
 
 	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
 )
 
 type Log struct {
-	Value string
-	IsErr bool
+	Value string `json:"value"`
+	IsErr bool   `json:"is_err"`
+	// Time is when the line was captured, zero when the app has no combined log
+	Time time.Time `json:"time,omitzero"`
+	// Matches are the byte ranges of Value matched by Options.Grep, highlighted by FormatLog
+	Matches [][]int `json:"matches,omitempty"`
 }
 
-// Stream reads the logs from the given app's stdout and stderr files
-func Stream(ctx context.Context, app apps.App, logType LogType) (<-chan Log, error) {
-	tailCfg := tail.Config{
+// Stream reads the logs from the given app's combined log or, for apps started without one,
+// from the stdout and stderr files, the history (all of it or only the last opts.Tail lines) is sent first,
+// then the current files are followed
+func Stream(ctx context.Context, app apps.App, opts Options) (<-chan Log, error) {
+	list, err := sources(app, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Log, 100)
+	for _, src := range list {
+		if err := followSource(ctx, src, opts, ch); err != nil {
+			return nil, err
+		}
+	}
+	return ch, nil
+}
+
+func tailConfig() tail.Config {
+	return tail.Config{
 		Follow:        true,
 		ReOpen:        true,
 		MustExist:     false,
@@ -23,80 +47,81 @@ func Stream(ctx context.Context, app apps.App, logType LogType) (<-chan Log, err
 		MaxLineSize:   maxBufferCapacity,
 		Logger:        tail.DiscardingLogger, // ignore logs from tail itself
 	}
+}
 
-	ch := make(chan Log, 100)
+func followSource(ctx context.Context, src source, opts Options, ch chan<- Log) error {
+	tailCfg := tailConfig()
 
-	if logType == OutLogs {
-		outTail, err := tail.TailFile(app.StdoutPath, tailCfg)
+	var history []string
+	var segments []string
+	if opts.Tail != TailAll {
+		lines, end, err := lastLines(src.path, opts.Tail, src.keep)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		history = lines
+		tailCfg.Location = &tail.SeekInfo{Offset: end, Whence: io.SeekStart}
+	} else {
+		all, err := Segments(src.path)
+		if err != nil {
+			return err
 		}
+		segments = all[:len(all)-1]
+	}
 
-		go func() {
-			for {
-				select {
-				case outLine := <-outTail.Lines:
-					if outLine == nil {
-						return
-					}
-					ch <- Log{Value: outLine.Text, IsErr: false}
-				case <-ctx.Done():
-					return
-				}
-			}
-		}()
-		return ch, nil
+	t, err := tail.TailFile(src.path, tailCfg)
+	if err != nil {
+		return err
 	}
 
-	if logType == ErrLogs {
-		errTail, err := tail.TailFile(app.StderrPath, tailCfg)
-		if err != nil {
-			return nil, err
+	go func() {
+		defer t.Cleanup()
+
+		f := newFilter(opts)
+		emit := func(log Log) error {
+			select {
+			case ch <- log:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		send := func(line string) error {
+			log, ok := src.parse(line)
+			if !ok {
+				return nil
+			}
+			return f.push(log, emit)
+		}
+
+		for _, line := range history {
+			if send(line) != nil {
+				return
+			}
 		}
 
-		go func() {
-			for {
-				select {
-				case errLine := <-errTail.Lines:
-					if errLine == nil {
-						return
-					}
-					ch <- Log{Value: errLine.Text, IsErr: true}
-				case <-ctx.Done():
+		for _, segment := range segments {
+			if err := scanSegment(segment, send); err != nil {
+				util.DebugLog("failed to read segment %s: %v", segment, err)
+				if ctx.Err() != nil {
 					return
 				}
 			}
-		}()
-		return ch, nil
-	}
-
-	outTail, err := tail.TailFile(app.StdoutPath, tailCfg)
-	if err != nil {
-		return nil, err
-	}
-
-	errTail, err := tail.TailFile(app.StderrPath, tailCfg)
-	if err != nil {
-		return nil, err
-	}
+		}
 
-	go func() {
 		for {
 			select {
-			case outLine := <-outTail.Lines:
-				if outLine == nil {
+			case line := <-t.Lines:
+				if line == nil {
 					return
 				}
-				ch <- Log{Value: outLine.Text, IsErr: false}
-			case errLine := <-errTail.Lines:
-				if errLine == nil {
+				if send(line.Text) != nil {
 					return
 				}
-				ch <- Log{Value: errLine.Text, IsErr: true}
 			case <-ctx.Done():
 				return
 			}
 		}
 	}()
-	return ch, nil
+	return nil
 }
@@ -0,0 +1,193 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	// TailAll shows every line instead of only the last N
+	TailAll = -1
+
+	reverseChunkSize = 64 * 1024
+)
+
+// keepFunc decides whether a line is included and whether reading further back is still useful
+type keepFunc func(line string) (include bool, more bool)
+
+func keepAll(string) (bool, bool) {
+	return true, true
+}
+
+// lastLines returns up to n lines (oldest first) of the log file and its rotated segments that satisfy keep,
+// plain files are read backwards from the end so multi-GB logs are not scanned from the start,
+// end is the offset in the log file right after the last complete line, following continues from there
+func lastLines(path string, n int, keep keepFunc) ([]string, int64, error) {
+	segments, err := Segments(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := make([]string, 0, max(n, 0))
+	var end int64
+	more := true
+
+	// newest first
+	for i := len(segments) - 1; i >= 0 && more; i-- {
+		segment := segments[i]
+		// the log file itself is always opened to find where following continues
+		if segment != path && len(res) >= n {
+			break
+		}
+
+		if strings.HasSuffix(segment, gzipExt) {
+			lines, err := lastCompressedLines(segment, n-len(res), keep)
+			if err != nil {
+				return nil, 0, err
+			}
+			slices.Reverse(lines)
+			res = append(res, lines...)
+			continue
+		}
+
+		file, err := os.Open(segment)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, 0, fmt.Errorf("failed to open file %s: %w", segment, err)
+		}
+
+		size, err := completeSize(file)
+		if err == nil {
+			if segment == path {
+				end = size
+			}
+			if len(res) < n {
+				err = reverseLines(file, size, func(line string) bool {
+					include, keepReading := keep(line)
+					if include {
+						res = append(res, line)
+					}
+					more = keepReading
+					return keepReading && len(res) < n
+				})
+			}
+		}
+
+		if closeErr := file.Close(); closeErr != nil {
+			util.DebugLog("Failed to close file %s: %s", segment, closeErr)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading file %s: %w", segment, err)
+		}
+	}
+
+	slices.Reverse(res)
+	return res, end, nil
+}
+
+// lastCompressedLines scans a gzipped segment from the start (it can't be read backwards)
+// and returns its last n lines that satisfy keep
+func lastCompressedLines(segment string, n int, keep keepFunc) ([]string, error) {
+	file, err := openSegment(segment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", segment, err)
+	}
+	defer func(file io.ReadCloser) {
+		if err := file.Close(); err != nil {
+			util.DebugLog("Failed to close file %s: %s", segment, err)
+		}
+	}(file)
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, maxBufferCapacity)
+	scanner.Buffer(buf, maxBufferCapacity)
+
+	res := make([]string, 0, n)
+	for scanner.Scan() {
+		if include, _ := keep(scanner.Text()); !include {
+			continue
+		}
+		if len(res) == n {
+			res = append(res[1:], scanner.Text())
+			continue
+		}
+		res = append(res, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", segment, err)
+	}
+	return res, nil
+}
+
+// completeSize returns the size of the file up to and including its last newline,
+// a trailing partial line is still being written and is left for the follower
+func completeSize(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	offset := info.Size()
+	for offset > 0 {
+		readSize := min(reverseChunkSize, offset)
+		buf := make([]byte, readSize)
+		if _, err := file.ReadAt(buf, offset-readSize); err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+
+		if idx := bytes.LastIndexByte(buf, '\n'); idx != -1 {
+			return offset - readSize + int64(idx) + 1, nil
+		}
+		offset -= readSize
+	}
+	return 0, nil
+}
+
+// reverseLines calls fn for every line in r[0:size] from the last to the first until fn returns false,
+// size must point right after a newline (or be 0)
+func reverseLines(r io.ReaderAt, size int64, fn func(line string) bool) error {
+	if size == 0 {
+		return nil
+	}
+
+	// the last line is terminated by the newline at size-1
+	var carry []byte
+	offset := size - 1
+
+	for offset > 0 {
+		readSize := min(reverseChunkSize, offset)
+		offset -= readSize
+
+		buf := make([]byte, readSize, readSize+int64(len(carry)))
+		if _, err := r.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		buf = append(buf, carry...)
+
+		for {
+			idx := bytes.LastIndexByte(buf, '\n')
+			if idx == -1 {
+				break
+			}
+			if !fn(string(buf[idx+1:])) {
+				return nil
+			}
+			buf = buf[:idx]
+		}
+		carry = buf
+	}
+
+	fn(string(carry))
+	return nil
+}
@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+func TestReverseLines(t *testing.T) {
+	content := "a\nbb\n\nccc\n"
+
+	var got []string
+	require.NoError(t, reverseLines(strings.NewReader(content), int64(len(content)), func(line string) bool {
+		got = append(got, line)
+		return true
+	}))
+	require.Equal(t, []string{"ccc", "", "bb", "a"}, got)
+
+	got = nil
+	require.NoError(t, reverseLines(strings.NewReader(content), int64(len(content)), func(line string) bool {
+		got = append(got, line)
+		return len(got) < 2
+	}))
+	require.Equal(t, []string{"ccc", ""}, got, "expected reading to stop early")
+}
+
+func TestReverseLines_LongLines(t *testing.T) {
+	long := strings.Repeat("x", reverseChunkSize+10)
+	content := "first\n" + long + "\nlast\n"
+
+	var got []string
+	require.NoError(t, reverseLines(strings.NewReader(content), int64(len(content)), func(line string) bool {
+		got = append(got, line)
+		return true
+	}))
+	require.Equal(t, []string{"last", long, "first"}, got)
+}
+
+func TestLastLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+
+	r, err := CreateRotating(path, apps.LogRotation{MaxSize: 10, MaxFiles: 5, Compress: true})
+	require.NoError(t, err)
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		_, err := r.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+
+	// a line that is still being written
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("partial")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	lines, end, err := lastLines(path, 3, keepAll)
+	require.NoError(t, err)
+	require.Equal(t, []string{"line-2", "line-3", "line-4"}, lines, "expected lines from rotated segments")
+	require.Equal(t, int64(len("line-4\n")), end, "expected the partial line to be left for following")
+
+	lines, _, err = lastLines(path, 10, keepAll)
+	require.NoError(t, err)
+	require.Equal(t, []string{"line-1", "line-2", "line-3", "line-4"}, lines)
+
+	lines, _, err = lastLines(path, 0, keepAll)
+	require.NoError(t, err)
+	require.Empty(t, lines)
+}
+
+func TestLastLines_StopsEarly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	require.NoError(t, os.WriteFile(path, []byte("old-1\nold-2\nnew-1\nnew-2\n"), 0644))
+
+	lines, _, err := lastLines(path, 10, func(line string) (bool, bool) {
+		isNew := strings.HasPrefix(line, "new")
+		return isNew, isNew
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"new-1", "new-2"}, lines)
+}
@@ -0,0 +1,220 @@
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeFiles are the names of a compose file in the order docker compose looks for them
+var ComposeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Command     stringOrList `yaml:"command"`
+	Environment composeEnv   `yaml:"environment"`
+	EnvFile     stringOrList `yaml:"env_file"`
+	DependsOn   composeDeps  `yaml:"depends_on"`
+}
+
+// stringOrList is a compose value that is either a plain string or a list of strings
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = []string{value.Value}
+		return nil
+	}
+
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// composeDeps is the depends_on section, either a list of services or a mapping of services to their conditions,
+// the conditions are ignored since runapp always waits for a dependency to be ready
+type composeDeps []string
+
+func (d *composeDeps) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var list []string
+		if err := value.Decode(&list); err != nil {
+			return err
+		}
+		*d = list
+		return nil
+	}
+
+	var values map[string]yaml.Node
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+	res := make([]string, 0, len(values))
+	for name := range values {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	*d = res
+	return nil
+}
+
+// composeEnv is the environment section, either a KEY: VALUE mapping or a list of KEY=VALUE entries,
+// entries without a value are passed through from the host by compose and are skipped here
+type composeEnv map[string]string
+
+func (e *composeEnv) UnmarshalYAML(value *yaml.Node) error {
+	res := make(composeEnv)
+
+	if value.Kind == yaml.SequenceNode {
+		var list []string
+		if err := value.Decode(&list); err != nil {
+			return err
+		}
+		for _, entry := range list {
+			if key, val, ok := strings.Cut(entry, "="); ok {
+				res[key] = val
+			}
+		}
+		*e = res
+		return nil
+	}
+
+	var values map[string]*string
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+	for key, val := range values {
+		if val != nil {
+			res[key] = *val
+		}
+	}
+	*e = res
+	return nil
+}
+
+// FindComposeFile returns the first compose file of ComposeFiles that exists in dir
+func FindComposeFile(dir string) (string, error) {
+	for _, name := range ComposeFiles {
+		filePath := filepath.Join(dir, name)
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath, nil
+		}
+	}
+	return "", fmt.Errorf("no compose file found, looked for %s", strings.Join(ComposeFiles, ", "))
+}
+
+// LoadCompose reads the command entries of a docker-compose file,
+// returns the names of the services that were skipped because they have no command
+func LoadCompose(path string) (*Manifest, []string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var compose composeFile
+	if err := yaml.Unmarshal(content, &compose); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse compose file %s: %w", path, err)
+	}
+	specs, skipped := compose.specs()
+
+	baseDir := filepath.Dir(path)
+	dotEnv, err := loadDotEnv(filepath.Join(baseDir, DefaultDotEnv))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// precedence from low to high: .env, env_file entries, environment
+	for i := range specs {
+		env := make(map[string]string)
+		maps.Copy(env, dotEnv)
+
+		for _, envFile := range compose.Services[specs[i].Name].EnvFile {
+			if !filepath.IsAbs(envFile) {
+				envFile = filepath.Join(baseDir, envFile)
+			}
+			fileEnv, err := loadDotEnv(envFile)
+			if err != nil {
+				return nil, nil, err
+			}
+			maps.Copy(env, fileEnv)
+		}
+
+		maps.Copy(env, specs[i].Env)
+		specs[i].Env = env
+	}
+
+	m, err := finalize(path, specs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, skipped, nil
+}
+
+// specs converts every service with a command into an app spec, sorted by service name,
+// returns the names of the services that were skipped because they have no command
+func (compose composeFile) specs() ([]AppSpec, []string) {
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var res []AppSpec
+	var skipped []string
+	for _, name := range names {
+		service := compose.Services[name]
+		if len(service.Command) == 0 {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		command := service.Command[0]
+		if len(service.Command) > 1 {
+			command = shellJoin(service.Command)
+		}
+
+		// services without a command are not imported, so they cannot be waited for
+		var deps []string
+		for _, dep := range service.DependsOn {
+			if dependency, ok := compose.Services[dep]; ok && len(dependency.Command) != 0 {
+				deps = append(deps, dep)
+			}
+		}
+
+		res = append(res, AppSpec{
+			Name:      name,
+			Command:   command,
+			Env:       service.Environment,
+			DependsOn: deps,
+		})
+	}
+	return res, skipped
+}
+
+// shellJoin quotes the exec form of a command so it can be run by the shell
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if len(arg) != 0 && strings.IndexFunc(arg, isShellSpecial) == -1 {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+func isShellSpecial(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@%+", r))
+}
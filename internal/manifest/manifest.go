@@ -0,0 +1,264 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+const (
+	DefaultFile = "runapp.yaml"
+)
+
+// Manifest declares the apps that should exist, usually loaded from runapp.yaml
+type Manifest struct {
+	Apps []AppSpec `yaml:"apps"`
+}
+
+// AppSpec is the declarative part of an app, everything else is runtime state
+type AppSpec struct {
+	Name    string            `yaml:"name"`
+	Command string            `yaml:"command"`
+	CWD     string            `yaml:"cwd"`
+	Env     map[string]string `yaml:"env"`
+	Labels  map[string]string `yaml:"labels"`
+	Mode    common.RunMode    `yaml:"mode"`
+	Restart apps.Restart      `yaml:"restart"`
+	Logs    apps.LogRotation  `yaml:"logs"`
+	History apps.History      `yaml:"history"`
+	// Readiness is optional, the app is ready as soon as it is started without it
+	Readiness *apps.Readiness `yaml:"readiness"`
+	// HealthCheck is optional, the unset interval, timeout and threshold use the defaults
+	HealthCheck *apps.HealthCheck `yaml:"health_check"`
+	// Schedule runs the app at the times of a cron expression, the mode defaults to scheduled with it
+	Schedule *apps.Schedule `yaml:"schedule"`
+	// Watch restarts the app when files under its cwd change, the unset debounce uses the default
+	Watch *apps.Watch `yaml:"watch"`
+	// Limits are the memory, cpu and pids limits and the rlimits of the app, e.g. memory_max: 512MB
+	Limits *apps.Limits `yaml:"limits"`
+	// DependsOn names apps of the manifest or existing apps that are started before this app
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// Load reads and validates the manifest at the given path,
+// relative cwd values are resolved against the manifest directory
+func Load(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Dir(absPath)
+
+	for i := range m.Apps {
+		m.Apps[i].normalize(baseDir)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if err := m.sortByDependencies(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+func (spec *AppSpec) normalize(baseDir string) {
+	if len(spec.Mode) == 0 {
+		spec.Mode = common.RunModeOnce
+		if spec.Schedule != nil {
+			spec.Mode = common.RunModeScheduled
+		}
+	}
+	if len(spec.Restart.Policy) == 0 {
+		spec.Restart.Policy = common.RestartPolicyNever
+	}
+	if spec.History.MaxRuns == 0 {
+		spec.History.MaxRuns = apps.DefaultHistoryMaxRuns
+	}
+	if spec.Readiness != nil {
+		spec.Readiness = new(spec.Readiness.WithDefaults())
+	}
+	if spec.HealthCheck != nil {
+		spec.HealthCheck = new(spec.HealthCheck.WithDefaults())
+	}
+	if spec.Schedule != nil {
+		spec.Schedule = new(spec.Schedule.WithDefaults())
+	}
+	if spec.Watch != nil {
+		spec.Watch = new(spec.Watch.WithDefaults())
+	}
+	if spec.Limits != nil && spec.Limits.IsEmpty() {
+		spec.Limits = nil
+	}
+	if len(spec.CWD) == 0 {
+		spec.CWD = baseDir
+	} else if !filepath.IsAbs(spec.CWD) {
+		spec.CWD = filepath.Join(baseDir, spec.CWD)
+	}
+}
+
+func (m *Manifest) Validate() error {
+	names := make(map[string]struct{}, len(m.Apps))
+	for _, spec := range m.Apps {
+		if len(spec.Name) == 0 {
+			return errors.New("app name must not be empty")
+		}
+		if _, ok := names[spec.Name]; ok {
+			return fmt.Errorf("app: %s is declared more than once", spec.Name)
+		}
+		names[spec.Name] = struct{}{}
+
+		if len(spec.Command) == 0 {
+			return fmt.Errorf("app: %s has no command", spec.Name)
+		}
+		if _, ok := common.PrettyRunMode[spec.Mode]; !ok {
+			return fmt.Errorf("app: %s has unknown mode: %s", spec.Name, spec.Mode)
+		}
+		for key, value := range spec.Labels {
+			if err := apps.ValidateLabel(key, value); err != nil {
+				return fmt.Errorf("app: %s: %w", spec.Name, err)
+			}
+		}
+		if !slices.Contains(common.ValidRestartPolicies, spec.Restart.Policy) {
+			return fmt.Errorf("app: %s has unknown restart policy: %s", spec.Name, spec.Restart.Policy)
+		}
+		if spec.History.MaxRuns < 0 {
+			return fmt.Errorf("app: %s has a negative history max_runs", spec.Name)
+		}
+		if spec.Readiness != nil {
+			if err := spec.Readiness.Validate(); err != nil {
+				return fmt.Errorf("app: %s: %w", spec.Name, err)
+			}
+		}
+		if spec.HealthCheck != nil {
+			if err := spec.HealthCheck.Validate(); err != nil {
+				return fmt.Errorf("app: %s: %w", spec.Name, err)
+			}
+		}
+		if err := apps.ValidateSchedule(spec.Mode, spec.Schedule, spec.Restart); err != nil {
+			return fmt.Errorf("app: %s: %w", spec.Name, err)
+		}
+		if err := apps.ValidateWatch(spec.Watch, spec.Schedule); err != nil {
+			return fmt.Errorf("app: %s: %w", spec.Name, err)
+		}
+		if spec.Limits != nil {
+			if err := spec.Limits.Validate(); err != nil {
+				return fmt.Errorf("app: %s: %w", spec.Name, err)
+			}
+		}
+		if slices.Contains(spec.DependsOn, spec.Name) {
+			return fmt.Errorf("app: %s depends on itself", spec.Name)
+		}
+	}
+
+	if _, err := m.startOrder(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// startOrder returns the apps with dependencies before their dependents, otherwise in declaration order.
+// Dependencies on apps that are not declared are left to be resolved against the existing apps when starting
+func (m *Manifest) startOrder() ([]AppSpec, error) {
+	idx := make(map[string]AppSpec, len(m.Apps))
+	for _, spec := range m.Apps {
+		idx[spec.Name] = spec
+	}
+
+	list := make([]apps.App, 0, len(m.Apps))
+	names := make([]string, 0, len(m.Apps))
+	for _, spec := range m.Apps {
+		app := apps.App{Name: spec.Name}
+		for _, dep := range spec.DependsOn {
+			if _, ok := idx[dep]; ok {
+				app.DependsOn = append(app.DependsOn, dep)
+			}
+		}
+		list = append(list, app)
+		names = append(names, spec.Name)
+	}
+
+	order, err := apps.StartOrder(list, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]AppSpec, 0, len(order))
+	for _, app := range order {
+		res = append(res, idx[app.Name])
+	}
+	return res, nil
+}
+
+// sortByDependencies orders the apps so that creating them one by one starts dependencies first
+func (m *Manifest) sortByDependencies() error {
+	specs, err := m.startOrder()
+	if err != nil {
+		return err
+	}
+	m.Apps = specs
+	return nil
+}
+
+// ToApp builds the app template for createAndRunApp, the spec env is layered on top of baseEnv
+func (spec AppSpec) ToApp(baseEnv []string) apps.App {
+	return apps.App{
+		Name:        spec.Name,
+		Mode:        spec.Mode,
+		Command:     spec.Command,
+		CWD:         spec.CWD,
+		Env:         mergeEnv(baseEnv, spec.Env),
+		ManifestEnv: slices.Sorted(maps.Keys(spec.Env)),
+		Labels:      spec.Labels,
+		Restart:     spec.Restart,
+		LogRotation: spec.Logs,
+		History:     spec.History,
+		Readiness:   spec.Readiness,
+		HealthCheck: spec.HealthCheck,
+		Schedule:    spec.Schedule,
+		Watch:       spec.Watch,
+		Limits:      spec.Limits,
+		DependsOn:   spec.DependsOn,
+	}
+}
+
+func mergeEnv(baseEnv []string, overrides map[string]string) []string {
+	res := make([]string, 0, len(baseEnv)+len(overrides))
+	for _, entry := range baseEnv {
+		key, _, _ := cutEnv(entry)
+		if _, ok := overrides[key]; ok {
+			continue
+		}
+		res = append(res, entry)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		res = append(res, key+"="+overrides[key])
+	}
+	return res
+}
@@ -0,0 +1,257 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFile)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeManifest(t, `
+apps:
+  - name: api
+    command: go run ./cmd/api
+    cwd: api
+    labels:
+      tier: backend
+    env:
+      PORT: "8080"
+    restart:
+      policy: on-failure
+      max_retries: 3
+      backoff_base: 2s
+    readiness:
+      tcp: localhost:8080
+    health_check:
+      http: http://localhost:8080/health
+      interval: 30s
+      restart_on_unhealthy: true
+  - name: worker
+    command: ./worker
+    mode: on-boot
+    logs:
+      max_size: 10MB
+      max_age: 24h
+      compress: true
+  - name: backup
+    command: ./backup.sh
+    schedule:
+      cron: 0 3 * * *
+`)
+
+	m, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, m.Apps, 3)
+
+	api := m.Apps[0]
+	require.Equal(t, "api", api.Name)
+	require.Equal(t, filepath.Join(filepath.Dir(path), "api"), api.CWD)
+	require.Equal(t, common.RunModeOnce, api.Mode)
+	require.Equal(t, map[string]string{"PORT": "8080"}, api.Env)
+	require.Equal(t, map[string]string{"tier": "backend"}, api.Labels)
+	require.Equal(t, apps.Restart{Policy: common.RestartPolicyOnFailure, MaxRetries: 3, BackoffBase: 2 * time.Second}, api.Restart)
+	require.Equal(t, &apps.Readiness{TCP: "localhost:8080", Interval: apps.DefaultReadinessInterval}, api.Readiness)
+	require.Equal(t, &apps.HealthCheck{
+		HTTP:               "http://localhost:8080/health",
+		ExpectStatus:       apps.DefaultHealthExpectStatus,
+		Interval:           30 * time.Second,
+		Timeout:            apps.DefaultHealthTimeout,
+		FailureThreshold:   apps.DefaultHealthFailureThreshold,
+		RestartOnUnhealthy: true,
+	}, api.HealthCheck)
+
+	worker := m.Apps[1]
+	require.Equal(t, filepath.Dir(path), worker.CWD)
+	require.Equal(t, common.RunModeOnBoot, worker.Mode)
+	require.Equal(t, common.RestartPolicyNever, worker.Restart.Policy)
+	require.Equal(t, apps.LogRotation{MaxSize: 10 * 1024 * 1024, MaxAge: 24 * time.Hour, Compress: true}, worker.Logs)
+	require.Nil(t, worker.HealthCheck)
+	require.Nil(t, worker.Schedule)
+
+	backup := m.Apps[2]
+	require.Equal(t, common.RunModeScheduled, backup.Mode, "expected a schedule to default to the scheduled mode")
+	require.Equal(t, &apps.Schedule{Cron: "0 3 * * *", Overlap: common.OverlapSkip}, backup.Schedule)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "missing command",
+			content:  "apps:\n  - name: api\n",
+			expected: "app: api has no command",
+		},
+		{
+			name:     "duplicate name",
+			content:  "apps:\n  - name: api\n    command: a\n  - name: api\n    command: b\n",
+			expected: "app: api is declared more than once",
+		},
+		{
+			name:     "unknown mode",
+			content:  "apps:\n  - name: api\n    command: a\n    mode: sometimes\n",
+			expected: "app: api has unknown mode: sometimes",
+		},
+		{
+			name:     "unknown restart policy",
+			content:  "apps:\n  - name: api\n    command: a\n    restart:\n      policy: maybe\n",
+			expected: "app: api has unknown restart policy: maybe",
+		},
+		{
+			name:     "invalid schedule",
+			content:  "apps:\n  - name: backup\n    command: a\n    schedule:\n      cron: 0 25 * * *\n",
+			expected: "app: backup: invalid schedule: invalid hour: value 25 out of range 0-23",
+		},
+		{
+			name:     "scheduled mode without schedule",
+			content:  "apps:\n  - name: backup\n    command: a\n    mode: scheduled\n",
+			expected: "app: backup: scheduled mode needs a schedule",
+		},
+		{
+			name:     "scheduled with restart policy",
+			content:  "apps:\n  - name: backup\n    command: a\n    schedule:\n      cron: '@daily'\n    restart:\n      policy: always\n",
+			expected: "app: backup: scheduled apps cannot have the always restart policy",
+		},
+		{
+			name:     "depends on itself",
+			content:  "apps:\n  - name: api\n    command: a\n    depends_on: [api]\n",
+			expected: "app: api depends on itself",
+		},
+		{
+			name:     "dependency cycle",
+			content:  "apps:\n  - name: api\n    command: a\n    depends_on: [db]\n  - name: db\n    command: b\n    depends_on: [api]\n",
+			expected: "dependency cycle: api -> db -> api",
+		},
+		{
+			name:     "health check without probe",
+			content:  "apps:\n  - name: api\n    command: a\n    health_check:\n      interval: 5s\n",
+			expected: "app: api: health check needs exactly one of http, tcp or command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeManifest(t, tt.content))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestLoad_DependencyOrder(t *testing.T) {
+	path := writeManifest(t, `
+apps:
+  - name: web
+    command: ./web
+    depends_on: [api]
+  - name: api
+    command: ./api
+    depends_on: [db, shared-cache]
+  - name: db
+    command: ./db
+  - name: cron
+    command: ./cron
+`)
+
+	m, err := Load(path)
+	require.NoError(t, err)
+
+	order := make([]string, 0, len(m.Apps))
+	for _, spec := range m.Apps {
+		order = append(order, spec.Name)
+	}
+	require.Equal(t, []string{"db", "api", "web", "cron"}, order, "expected dependencies first, otherwise the declared order")
+	require.Equal(t, []string{"db", "shared-cache"}, m.Apps[1].DependsOn, "expected dependencies outside the manifest to be kept")
+}
+
+func TestToApp(t *testing.T) {
+	spec := AppSpec{
+		Name:    "api",
+		Command: "./api",
+		CWD:     "/srv/api",
+		Mode:    common.RunModeOnce,
+		Env:     map[string]string{"PORT": "8080", "DEBUG": "true"},
+	}
+
+	app := spec.ToApp([]string{"PATH=/usr/bin", "PORT=1"})
+	require.Equal(t, []string{"PATH=/usr/bin", "DEBUG=true", "PORT=8080"}, app.Env)
+	require.Equal(t, []string{"DEBUG", "PORT"}, app.ManifestEnv)
+	require.Equal(t, "/srv/api", app.CWD)
+}
+
+func TestPlan(t *testing.T) {
+	m := &Manifest{Apps: []AppSpec{
+		{Name: "new", Command: "./new", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}},
+		{Name: "same", Command: "./same", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Env: map[string]string{"A": "1"}},
+		{Name: "changed", Command: "./changed --v2", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Env: map[string]string{"A": "2"}},
+		{Name: "stopped", Command: "./stopped", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}},
+		{Name: "done", Command: "./done", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}},
+		{Name: "relabeled", Command: "./relabeled", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Labels: map[string]string{"tier": "web"}, DependsOn: []string{"same"}, History: apps.History{MaxRuns: 5}},
+		{Name: "checked", Command: "./checked", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}, HealthCheck: &apps.HealthCheck{TCP: "localhost:80"}},
+	}}
+
+	existing := []apps.App{
+		{Name: "same", Command: "./same", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusRunning, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Env: []string{"A=1"}},
+		{Name: "changed", Command: "./changed", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusRunning, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Env: []string{"A=1"}},
+		{Name: "stopped", Command: "./stopped", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusFailed, Restart: apps.Restart{Policy: common.RestartPolicyNever}},
+		{Name: "done", Command: "./done", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusSuccess, Restart: apps.Restart{Policy: common.RestartPolicyNever}},
+		{Name: "relabeled", Command: "./relabeled", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusRunning, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Labels: map[string]string{"tier": "api"}},
+		{Name: "checked", Command: "./checked", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusRunning, Restart: apps.Restart{Policy: common.RestartPolicyNever}},
+		{Name: "orphan", Command: "./orphan", Status: common.AppStatusRunning},
+	}
+
+	t.Run("without prune", func(t *testing.T) {
+		changes := Plan(m, existing, false)
+		require.Len(t, changes, 7)
+
+		require.Equal(t, ActionCreate, changes[0].Action)
+		require.Equal(t, "new", changes[0].Name)
+
+		require.Equal(t, ActionUnchanged, changes[1].Action)
+
+		require.Equal(t, ActionRestart, changes[2].Action)
+		require.Equal(t, "command changed, env A changed", changes[2].Reason)
+
+		require.Equal(t, ActionRestart, changes[3].Action)
+		require.Equal(t, "failed", changes[3].Reason)
+
+		require.Equal(t, ActionUnchanged, changes[4].Action, "expected successfully completed apps to be left alone")
+
+		require.Equal(t, ActionUpdate, changes[5].Action, "expected label changes to not restart the app")
+		require.Equal(t, "labels changed, dependencies changed, history changed", changes[5].Reason)
+
+		require.Equal(t, ActionRestart, changes[6].Action)
+		require.Equal(t, "health check changed", changes[6].Reason)
+	})
+
+	t.Run("removed env", func(t *testing.T) {
+		spec := AppSpec{Name: "api", Command: "./api", CWD: "/srv", Mode: common.RunModeOnce, Restart: apps.Restart{Policy: common.RestartPolicyNever}, Env: map[string]string{"A": "1"}}
+		app := apps.App{Name: "api", Command: "./api", CWD: "/srv", Mode: common.RunModeOnce, Status: common.AppStatusRunning, Restart: apps.Restart{Policy: common.RestartPolicyNever},
+			Env: []string{"PATH=/usr/bin", "A=1", "B=2"}, ManifestEnv: []string{"A", "B"}}
+
+		changes := Plan(&Manifest{Apps: []AppSpec{spec}}, []apps.App{app}, false)
+		require.Equal(t, ActionRestart, changes[0].Action)
+		require.Equal(t, "env B removed", changes[0].Reason)
+	})
+
+	t.Run("with prune", func(t *testing.T) {
+		changes := Plan(m, existing, true)
+		require.Len(t, changes, 8)
+		require.Equal(t, Change{Action: ActionPrune, Name: "orphan"}, changes[7])
+	})
+}
@@ -0,0 +1,170 @@
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionRestart   Action = "restart"
+	ActionUpdate    Action = "update"
+	ActionPrune     Action = "prune"
+	ActionUnchanged Action = "unchanged"
+)
+
+type Change struct {
+	Action Action
+	Name   string
+	// Spec is nil for pruned apps
+	Spec *AppSpec
+	// Reason explains why a restart or update is needed
+	Reason string
+}
+
+// Plan compares the manifest with the existing apps and returns the changes needed to converge,
+// apps missing from the manifest are only pruned when prune is set
+func Plan(m *Manifest, existing []apps.App, prune bool) []Change {
+	idx := make(map[string]apps.App, len(existing))
+	for _, app := range existing {
+		idx[app.Name] = app
+	}
+
+	res := make([]Change, 0, len(m.Apps)+len(existing))
+	declared := make(map[string]struct{}, len(m.Apps))
+
+	for i := range m.Apps {
+		spec := &m.Apps[i]
+		declared[spec.Name] = struct{}{}
+
+		app, ok := idx[spec.Name]
+		if !ok {
+			res = append(res, Change{Action: ActionCreate, Name: spec.Name, Spec: spec})
+			continue
+		}
+
+		if reason := diff(*spec, app); len(reason) != 0 {
+			res = append(res, Change{Action: ActionRestart, Name: spec.Name, Spec: spec, Reason: reason})
+			continue
+		}
+
+		// apps that completed successfully are left alone, otherwise one-off jobs would re-run on every apply
+		if app.Status == common.AppStatusFailed {
+			res = append(res, Change{Action: ActionRestart, Name: spec.Name, Spec: spec, Reason: "failed"})
+			continue
+		}
+
+		// labels, dependencies and the history are only used by runapp itself, no need to restart the app
+		var updates []string
+		if !maps.Equal(spec.Labels, app.Labels) {
+			updates = append(updates, "labels changed")
+		}
+		if !slices.Equal(spec.DependsOn, app.DependsOn) {
+			updates = append(updates, "dependencies changed")
+		}
+		// apps created before the history existed keep the default amount of runs
+		if spec.History.Limit() != app.History.Limit() || spec.History.ArchiveLogs != app.History.ArchiveLogs {
+			updates = append(updates, "history changed")
+		}
+		if len(updates) != 0 {
+			res = append(res, Change{Action: ActionUpdate, Name: spec.Name, Spec: spec, Reason: strings.Join(updates, ", ")})
+			continue
+		}
+
+		res = append(res, Change{Action: ActionUnchanged, Name: spec.Name, Spec: spec})
+	}
+
+	if prune {
+		pruned := make([]string, 0)
+		for _, app := range existing {
+			if _, ok := declared[app.Name]; !ok {
+				pruned = append(pruned, app.Name)
+			}
+		}
+		sort.Strings(pruned)
+
+		for _, name := range pruned {
+			res = append(res, Change{Action: ActionPrune, Name: name})
+		}
+	}
+	return res
+}
+
+// diff returns a human readable list of differences between the spec and the app, empty if none
+func diff(spec AppSpec, app apps.App) string {
+	var reasons []string
+	if spec.Command != app.Command {
+		reasons = append(reasons, "command changed")
+	}
+	if spec.CWD != app.CWD {
+		reasons = append(reasons, "cwd changed")
+	}
+	if spec.Mode != app.Mode {
+		reasons = append(reasons, "mode changed")
+	}
+	if spec.Restart != app.Restart {
+		reasons = append(reasons, "restart policy changed")
+	}
+	if spec.Logs != app.LogRotation {
+		reasons = append(reasons, "log rotation changed")
+	}
+	if !equalPtr(spec.Readiness, app.Readiness) {
+		reasons = append(reasons, "readiness changed")
+	}
+	if !equalPtr(spec.HealthCheck, app.HealthCheck) {
+		reasons = append(reasons, "health check changed")
+	}
+	if !equalPtr(spec.Schedule, app.Schedule) {
+		reasons = append(reasons, "schedule changed")
+	}
+	if (spec.Watch == nil) != (app.Watch == nil) || (spec.Watch != nil && !spec.Watch.Equal(*app.Watch)) {
+		reasons = append(reasons, "watch changed")
+	}
+	if (spec.Limits == nil) != (app.Limits == nil) || (spec.Limits != nil && !spec.Limits.Equal(*app.Limits)) {
+		reasons = append(reasons, "limits changed")
+	}
+
+	current := make(map[string]string, len(app.Env))
+	for _, entry := range app.Env {
+		key, value, _ := cutEnv(entry)
+		current[key] = value
+	}
+
+	keys := make([]string, 0, len(spec.Env))
+	for key := range spec.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if value, ok := current[key]; !ok || value != spec.Env[key] {
+			reasons = append(reasons, fmt.Sprintf("env %s changed", key))
+		}
+	}
+	// only the variables the manifest set before can be removed, the others come from the environment of apply
+	for _, key := range app.ManifestEnv {
+		if _, ok := spec.Env[key]; !ok {
+			reasons = append(reasons, fmt.Sprintf("env %s removed", key))
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func equalPtr[T comparable](a *T, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func cutEnv(entry string) (string, string, bool) {
+	return strings.Cut(entry, "=")
+}
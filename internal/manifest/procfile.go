@@ -0,0 +1,179 @@
+package manifest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	DefaultProcfile = "Procfile"
+	DefaultDotEnv   = ".env"
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9_-]+")
+
+// LoadProcfile reads a Procfile, the .env file next to it (if any) is used as the env of every process
+func LoadProcfile(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			util.DebugLog("Failed to close file %s: %s", path, err)
+		}
+	}(file)
+
+	specs, err := ParseProcfile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse procfile %s: %w", path, err)
+	}
+
+	env, err := loadDotEnv(filepath.Join(filepath.Dir(path), DefaultDotEnv))
+	if err != nil {
+		return nil, err
+	}
+	for i := range specs {
+		specs[i].Env = env
+	}
+	return finalize(path, specs)
+}
+
+// ParseProcfile parses "name: command" lines, blank lines and # comments are ignored
+func ParseProcfile(r io.Reader) ([]AppSpec, error) {
+	var res []AppSpec
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, command, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected <name>: <command>", lineNumber)
+		}
+
+		name = strings.TrimSpace(name)
+		command = strings.TrimSpace(command)
+		if len(name) == 0 || len(command) == 0 {
+			return nil, fmt.Errorf("line %d: expected <name>: <command>", lineNumber)
+		}
+		res = append(res, AppSpec{Name: name, Command: command})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ParseDotEnv parses KEY=VALUE lines, supports comments, "export " prefixes and quoted values
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	res := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		res[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func loadDotEnv(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			util.DebugLog("Failed to close file %s: %s", path, err)
+		}
+	}(file)
+
+	env, err := ParseDotEnv(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return env, nil
+}
+
+// SanitizeName turns a process or service key into a valid app name
+func SanitizeName(value string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+// WithPrefix prefixes every app name, e.g. per project, so imports from several repos do not clash
+func (m *Manifest) WithPrefix(prefix string) {
+	if len(prefix) == 0 {
+		return
+	}
+	for i := range m.Apps {
+		m.Apps[i].Name = SanitizeName(prefix) + "-" + m.Apps[i].Name
+		for j := range m.Apps[i].DependsOn {
+			m.Apps[i].DependsOn[j] = SanitizeName(prefix) + "-" + m.Apps[i].DependsOn[j]
+		}
+	}
+}
+
+func finalize(path string, specs []AppSpec) (*Manifest, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Dir(absPath)
+
+	m := &Manifest{Apps: specs}
+	for i := range m.Apps {
+		m.Apps[i].Name = SanitizeName(m.Apps[i].Name)
+		for j := range m.Apps[i].DependsOn {
+			m.Apps[i].DependsOn[j] = SanitizeName(m.Apps[i].DependsOn[j])
+		}
+		m.Apps[i].normalize(baseDir)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if err := m.sortByDependencies(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return m, nil
+}
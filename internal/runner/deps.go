@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+// StopDependents stops the running apps that depend on the app, dependents are stopped before their dependencies.
+// It returns the names of the stopped apps
+func StopDependents(ctx context.Context, app *apps.App) ([]string, error) {
+	list, err := apps.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var stopped []string
+	for _, dependent := range apps.Dependents(list, app.Name) {
+		if !dependent.IsRunning() {
+			continue
+		}
+		if err := Stop(ctx, &dependent); err != nil {
+			return stopped, fmt.Errorf("failed to stop dependent %s: %w", dependent.Name, err)
+		}
+		stopped = append(stopped, dependent.Name)
+	}
+	return stopped, nil
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/daemon"
+	"github.com/0xB1a60/runapp/internal/export"
+	"github.com/0xB1a60/runapp/internal/supervisor"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	softKillTimeout = 10 * time.Second
+)
+
+// Started describes who supervises a started app
+type Started struct {
+	// ByDaemon is set when the app is supervised by the runapp daemon
+	ByDaemon bool
+	// PID of the background process supervising the app, 0 when ByDaemon is set
+	PID int
+	// Dependencies are the dependencies that were not running and were started before the app,
+	// the daemon starts the dependencies of the apps it supervises itself and does not report them
+	Dependencies []string
+}
+
+// Start starts supervising a created (or reset) app, in the daemon when it is running or in a new background process otherwise.
+// Dependencies of the app that are not running are started first and waited for until they are ready
+func Start(ctx context.Context, app apps.App) (Started, error) {
+	if client, err := daemon.Connect(ctx); err == nil {
+		if _, err := client.Start(ctx, app.Name); err != nil {
+			return Started{}, err
+		}
+		return Started{ByDaemon: true}, nil
+	}
+
+	deps, err := supervisor.StartDependencies(ctx, app.Name, func(dep apps.App) error {
+		_, err := startBackground(dep)
+		return err
+	})
+	if err != nil {
+		return Started{Dependencies: deps}, err
+	}
+
+	started, err := startBackground(app)
+	started.Dependencies = deps
+	return started, err
+}
+
+// startBackground starts a background process supervising the app
+func startBackground(app apps.App) (Started, error) {
+	cmd := exec.Command(os.Args[0], "background", app.Name)
+	cmd.Env = os.Environ()
+
+	if util.IsDebug() {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		cmd.Stdout = nil
+		cmd.Stderr = nil
+	}
+
+	cmd.Stdin = nil
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true, // start new session
+	}
+
+	if err := cmd.Start(); err != nil {
+		return Started{}, err
+	}
+	return Started{PID: cmd.Process.Pid}, nil
+}
+
+// Create creates a fresh app from the template (replacing a stopped app with the same name) and starts it,
+// the systemd unit the app was exported to gets the command, env and restart policy of the new app.
+// Started is set when the app could not be started too, it has the dependencies that were started
+func Create(ctx context.Context, template apps.App) (*apps.App, Started, error) {
+	app, err := supervisor.Create(template)
+	if err != nil {
+		return nil, Started{}, err
+	}
+
+	if err := export.SyncSystemdUnit(*app); err != nil {
+		util.DebugLog("failed to update the systemd unit of %s: %v", app.Name, err)
+	}
+
+	started, err := Start(ctx, *app)
+	if err != nil {
+		return nil, started, err
+	}
+	return app, started, nil
+}
+
+// Restart stops the app if it is running and starts it again with fresh logs
+func Restart(ctx context.Context, app *apps.App) (Started, error) {
+	if app.IsRunning() {
+		if err := Stop(ctx, app); err != nil {
+			return Started{}, err
+		}
+	}
+
+	if err := supervisor.Reset(app, common.TriggerRestart); err != nil {
+		return Started{}, err
+	}
+	return Start(ctx, *app)
+}
+
+// Remove stops the app if it is running and removes it with its logs and the systemd unit it was exported to
+func Remove(ctx context.Context, app *apps.App) error {
+	if app.IsRunning() {
+		if err := Stop(ctx, app); err != nil {
+			return err
+		}
+	}
+	if err := export.RemoveSystemdUnit(app.Name); err != nil {
+		return err
+	}
+	return app.RemoveFiles()
+}
+
+// Stop stops the app without applying its restart policy, through the daemon when it supervises the app,
+// otherwise the background process is sent SIGTERM and killed if it does not exit in time.
+// The daemon supervises other apps too, when it cannot stop the app its error is returned instead of killing it
+func Stop(ctx context.Context, app *apps.App) error {
+	client, err := daemon.Connect(ctx)
+	if err == nil {
+		err := client.Kill(ctx, app.Name)
+		if err == nil {
+			util.DebugLog("app stopped by the daemon")
+			return nil
+		}
+		if !errors.Is(err, daemon.ErrNotSupervised) {
+			return fmt.Errorf("failed to stop app with the daemon: %w", err)
+		}
+	} else if app.DaemonPID > 0 && util.PidExists(app.DaemonPID) {
+		return fmt.Errorf("failed to stop app, the daemon supervising it is not reachable: %w", err)
+	}
+
+	// kill the background wrapper together with the app, otherwise the wrapper would apply the restart policy
+	pid := app.PID
+	if app.WrapperPID > 0 && util.PidExists(app.WrapperPID) {
+		pid = app.WrapperPID
+	}
+	if pid <= 0 {
+		util.DebugLog("app has no process to stop")
+		return nil
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- util.SoftKill(pid)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			util.DebugLog("Failed to stop app: %v", err)
+		}
+		util.DebugLog("app stopped")
+	case <-time.After(softKillTimeout):
+		util.DebugLog("force killing app")
+		if err := util.ForceKill(pid); err != nil {
+			return fmt.Errorf("failed to force kill app: %w", err)
+		}
+		util.DebugLog("app force killed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
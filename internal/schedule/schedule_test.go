@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// a wednesday
+	from := time.Date(2026, time.March, 4, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "* * * * *", expected: time.Date(2026, time.March, 4, 10, 18, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", expected: time.Date(2026, time.March, 4, 10, 30, 0, 0, time.UTC)},
+		{expr: "5/20 * * * *", expected: time.Date(2026, time.March, 4, 10, 25, 0, 0, time.UTC)},
+		{expr: "0 3 * * *", expected: time.Date(2026, time.March, 5, 3, 0, 0, 0, time.UTC)},
+		{expr: "30 9-17 * * mon-fri", expected: time.Date(2026, time.March, 4, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 0 * * sat,sun", expected: time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", expected: time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1 jan *", expected: time.Date(2027, time.January, 1, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", expected: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// both day fields restricted, either one matches
+		{expr: "0 0 15 * fri", expected: time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", expected: time.Date(2026, time.March, 4, 11, 0, 0, 0, time.UTC)},
+		{expr: "@daily", expected: time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{expr: "@weekly", expected: time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", expected: time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "@every 10m", expected: time.Date(2026, time.March, 4, 10, 27, 30, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			sched, err := Parse(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, sched.Next(from))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"30 2 31 2 *",
+		"@sometimes",
+		"@every soon",
+		"@every 10ms",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+const (
+	// DependencyReadyTimeout bounds how long a dependency may take to become ready
+	DependencyReadyTimeout = time.Minute
+)
+
+// StartFunc starts supervising a reset app, in the daemon or in a new background process
+type StartFunc func(app apps.App) error
+
+// StartDependencies starts the dependencies of the app that are not running in dependency order with start
+// and waits for every dependency to be ready. It returns the names of the started dependencies
+func StartDependencies(ctx context.Context, name string, start StartFunc) ([]string, error) {
+	list, err := apps.List()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := apps.StartOrder(list, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var started []string
+	for _, dep := range order[:len(order)-1] {
+		if !dep.IsRunning() {
+			if err := Reset(&dep, common.TriggerDependency); err != nil {
+				return started, err
+			}
+			if err := start(dep); err != nil {
+				return started, fmt.Errorf("failed to start dependency %s: %w", dep.Name, err)
+			}
+			started = append(started, dep.Name)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, DependencyReadyTimeout)
+		_, err := Wait(waitCtx, dep.Name, WaitForReady)
+		cancel()
+		if err != nil {
+			return started, fmt.Errorf("dependency %s is not ready: %w", dep.Name, err)
+		}
+	}
+	return started, nil
+}
@@ -0,0 +1,152 @@
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"path"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// Create replaces any previous app with the same name with a fresh one built from the given template
+// (name, mode, command, cwd, env, labels, restart policy and log rotation), the app is saved as starting,
+// the run history of the previous app is kept
+func Create(app apps.App) (*apps.App, error) {
+	homeDir, err := util.HomeDirPath()
+	if err != nil {
+		return nil, err
+	}
+	stateDir, err := util.StateDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	configDir := path.Join(homeDir, app.Name)
+	runDir := path.Join(stateDir, app.Name)
+	if err := removeExceptHistory(runDir); err != nil {
+		return nil, err
+	}
+	// with RUNAPP_HOME the config and the logs share the directory
+	if configDir != runDir {
+		if err := os.RemoveAll(configDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(configDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(runDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	stdoutPath := path.Join(runDir, common.FileStdOut)
+	if err := createEmpty(stdoutPath); err != nil {
+		return nil, err
+	}
+
+	stdErrPath := path.Join(runDir, common.FileStdErr)
+	if err := createEmpty(stdErrPath); err != nil {
+		return nil, err
+	}
+
+	combinedPath := path.Join(runDir, common.FileCombined)
+	if err := createEmpty(combinedPath); err != nil {
+		return nil, err
+	}
+
+	if len(app.TriggeredBy) == 0 {
+		app.TriggeredBy = common.TriggerRun
+	}
+	app.Status = common.AppStatusStarting
+	app.PID = -1
+	app.ConfigPath = configDir
+	app.StatePath = runDir
+	app.StderrPath = stdErrPath
+	app.StdoutPath = stdoutPath
+	app.CombinedPath = combinedPath
+
+	if err := app.SaveToFile(); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// removeExceptHistory removes the previous app, its run history and archived logs are kept
+func removeExceptHistory(runDir string) error {
+	entries, err := os.ReadDir(runDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == common.FileHistory || entry.Name() == common.DirRuns {
+			continue
+		}
+		if err := os.RemoveAll(path.Join(runDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createEmpty(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Reset prepares a stopped app to be started again by the trigger, the logs and restart count of the previous run are removed.
+// The app is updated with the stored config, it may have changed since the caller read it
+func Reset(app *apps.App, trigger common.Trigger) error {
+	updated, err := apps.Update(app.Name, func(stored *apps.App) error {
+		// apps created before the combined log existed get one from now on
+		if len(stored.CombinedPath) == 0 {
+			stored.CombinedPath = path.Join(stored.StateDir(), common.FileCombined)
+		}
+
+		stored.Status = common.AppStatusStarting
+		stored.TriggeredBy = trigger
+		stored.ExitCode = nil
+		stored.PID = -1
+		stored.RestartCount = 0
+		stored.NextRestartAt = nil
+		stored.NextRunAt = nil
+		stored.ReadyAt = nil
+		stored.HealthError = ""
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*app = *updated
+
+	if err := os.Remove(app.StderrPath); err != nil {
+		return err
+	}
+
+	if err := os.Remove(app.StdoutPath); err != nil {
+		return err
+	}
+
+	if err := logs.RemoveSegments(app.StderrPath); err != nil {
+		return err
+	}
+
+	if err := logs.RemoveSegments(app.StdoutPath); err != nil {
+		return err
+	}
+
+	if err := os.Remove(app.CombinedPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return logs.RemoveSegments(app.CombinedPath)
+}
@@ -0,0 +1,146 @@
+package supervisor
+
+import (
+	"io"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+// historyMu serializes the updates of the history, overlapping scheduled runs finish concurrently
+var historyMu sync.Mutex
+
+// offsets are the sizes of the logs when a run started, the run wrote everything past them
+type offsets map[string]int64
+
+// logOffsets returns the current size of every log of the app
+func logOffsets(app apps.App) offsets {
+	result := make(offsets)
+	for _, logPath := range []string{app.StdoutPath, app.StderrPath, app.CombinedPath} {
+		if len(logPath) == 0 {
+			continue
+		}
+		if info, err := os.Stat(logPath); err == nil {
+			result[logPath] = info.Size()
+		}
+	}
+	return result
+}
+
+// recordRun appends the finished run to the history of the app, archiving its logs when enabled
+// and removing the oldest runs above the limit
+func recordRun(app apps.App, startedAt time.Time, exit childExit, before offsets) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	runs, err := apps.ReadHistory(app)
+	if err != nil {
+		writeStdErr(app.StderrPath, err)
+		return
+	}
+
+	// the child starts after the run was prepared, StartedAt is only older when the command failed to start
+	if app.StartedAt != nil && app.StartedAt.After(startedAt) {
+		startedAt = *app.StartedAt
+	}
+	finishedAt := time.Now()
+
+	run := apps.Run{
+		ID:          1,
+		StartedAt:   startedAt,
+		FinishedAt:  finishedAt,
+		Duration:    finishedAt.Sub(startedAt),
+		ExitCode:    exit.code,
+		Signal:      exit.signal,
+		OOMKilled:   app.OOMKilled,
+		TriggeredBy: app.TriggeredBy,
+	}
+	if len(runs) != 0 {
+		run.ID = runs[len(runs)-1].ID + 1
+	}
+	if exit.killed {
+		run.ExitCode = 137
+	}
+
+	if app.History.ArchiveLogs {
+		if err := archiveLogs(app, run.ID, before); err != nil {
+			writeStdErr(app.StderrPath, err)
+		} else {
+			run.LogsArchived = true
+		}
+	}
+
+	runs = append(runs, run)
+	if limit := app.History.Limit(); len(runs) > limit {
+		for _, pruned := range runs[:len(runs)-limit] {
+			if err := os.RemoveAll(app.RunDir(pruned.ID)); err != nil {
+				writeStdErr(app.StderrPath, err)
+			}
+		}
+		runs = runs[len(runs)-limit:]
+	}
+
+	if err := apps.WriteHistory(app, runs); err != nil {
+		writeStdErr(app.StderrPath, err)
+	}
+}
+
+// archiveLogs copies what the run wrote to the logs into its run directory
+func archiveLogs(app apps.App, id int, before offsets) error {
+	dir := app.RunDir(id)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		app.StdoutPath:   common.FileStdOut,
+		app.StderrPath:   common.FileStdErr,
+		app.CombinedPath: common.FileCombined,
+	}
+	for logPath, name := range files {
+		if len(logPath) == 0 {
+			continue
+		}
+		if err := copyFrom(logPath, path.Join(dir, name), before[logPath]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFrom copies src past offset into dst, the whole file is copied when it was rotated during the run
+func copyFrom(src string, dst string, offset int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return createEmpty(dst)
+		}
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := in.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,84 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func TestSupervise_History(t *testing.T) {
+	setupHome(t)
+
+	template := apps.App{
+		Name:    "counter",
+		Mode:    common.RunModeOnce,
+		Command: `echo "run $$"; exit 2`,
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyOnFailure, MaxRetries: 2, BackoffBase: 10 * time.Millisecond, BackoffCap: time.Second},
+		History: apps.History{MaxRuns: 2, ArchiveLogs: true},
+	}
+	app, err := Create(template)
+	require.NoError(t, err)
+	require.NoError(t, Supervise(context.Background(), app, nil))
+
+	runs, err := apps.ReadHistory(*app)
+	require.NoError(t, err)
+	require.Len(t, runs, 2, "expected the oldest run to be pruned")
+
+	require.Equal(t, 2, runs[0].ID)
+	require.Equal(t, 3, runs[1].ID)
+	for _, run := range runs {
+		require.Equal(t, 2, run.ExitCode)
+		require.Empty(t, run.Signal)
+		require.Equal(t, common.TriggerRestartPolicy, run.TriggeredBy)
+		require.True(t, run.LogsArchived)
+		require.False(t, run.FinishedAt.Before(run.StartedAt))
+	}
+	require.NoDirExists(t, app.RunDir(1), "expected the logs of the pruned run to be removed")
+
+	first, err := os.ReadFile(app.WithRunLogs(runs[0]).StdoutPath)
+	require.NoError(t, err)
+	second, err := os.ReadFile(app.WithRunLogs(runs[1]).StdoutPath)
+	require.NoError(t, err)
+	require.Regexp(t, `^run \d+\n$`, string(first), "expected only the output of the run to be archived")
+	require.NotEqual(t, string(first), string(second))
+
+	// the history is kept when the app is created again
+	app, err = Create(template)
+	require.NoError(t, err)
+	require.Equal(t, common.TriggerRun, app.TriggeredBy)
+	require.FileExists(t, path.Join(app.StateDir(), common.FileHistory))
+
+	runs, err = apps.ReadHistory(*app)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+}
+
+func TestSupervise_HistorySignal(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:    "terminated",
+		Mode:    common.RunModeOnce,
+		Command: "kill -TERM $$",
+		Env:     os.Environ(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, Supervise(context.Background(), app, nil))
+
+	runs, err := apps.ReadHistory(*app)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, 1, runs[0].ID)
+	require.Equal(t, "SIGTERM", runs[0].Signal)
+	require.Equal(t, common.TriggerRun, runs[0].TriggeredBy)
+	require.False(t, runs[0].LogsArchived)
+	require.NoDirExists(t, app.RunDir(1))
+}
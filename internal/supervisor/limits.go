@@ -0,0 +1,98 @@
+package supervisor
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/cgroup"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// ExecLimitsCmd is the hidden command runapp runs itself with to set the rlimits of an app before it execs the app command,
+// Go cannot run code between fork and exec
+const ExecLimitsCmd = "exec-limits"
+
+// commandArgs returns the arguments the app command is started with, apps with rlimits are started through runapp exec-limits
+func commandArgs(app apps.App) ([]string, error) {
+	cmdArgs := append(util.GetShellArgs(), app.Command)
+	if app.Limits == nil || !app.Limits.HasRlimits() {
+		return cmdArgs, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{self, ExecLimitsCmd}
+	if app.Limits.NoFile != 0 {
+		args = append(args, "--nofile", strconv.FormatUint(app.Limits.NoFile, 10))
+	}
+	if app.Limits.Core != nil {
+		args = append(args, "--core", app.Limits.Core.String())
+	}
+	return append(append(args, "--"), cmdArgs...), nil
+}
+
+// ExecWithLimits sets the rlimits of the flags and replaces the process with the command after --,
+// the limits are both the soft and the hard limit, so the app cannot raise them
+func ExecWithLimits(args []string) error {
+	flags := flag.NewFlagSet(ExecLimitsCmd, flag.ContinueOnError)
+	noFile := flags.Uint64("nofile", 0, "max open files")
+	core := flags.String("core", "", "max size of core dumps")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("missing command")
+	}
+
+	if *noFile != 0 {
+		if err := unix.Setrlimit(unix.RLIMIT_NOFILE, &unix.Rlimit{Cur: *noFile, Max: *noFile}); err != nil {
+			return fmt.Errorf("failed to set nofile to %d: %w", *noFile, err)
+		}
+	}
+
+	if len(*core) != 0 {
+		size, err := apps.ParseLimitSize(*core)
+		if err != nil {
+			return err
+		}
+		value := uint64(unix.RLIM_INFINITY)
+		if size != apps.Unlimited {
+			value = uint64(size)
+		}
+		if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("failed to set core to %s: %w", size, err)
+		}
+	}
+
+	command, err := exec.LookPath(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(command, flags.Args(), os.Environ())
+}
+
+// createCgroup returns the cgroup with the memory, cpu and pids limits of the app,
+// without a delegated cgroup v2 subtree the app runs without them
+func createCgroup(app *apps.App, stderr io.Writer) *cgroup.Group {
+	if app.Limits == nil || !app.Limits.HasCgroup() {
+		return nil
+	}
+
+	group, err := cgroup.Create(app.Name, *app.Limits)
+	if err != nil {
+		fmt.Fprintf(stderr, "[runapp] memory, cpu and pids limits are not applied: %v\n", err) // no lint // handling this error is not needed
+		return nil
+	}
+	return group
+}
@@ -0,0 +1,215 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/schedule"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// runResult is the state of a scheduled run once it exited
+type runResult struct {
+	id  int
+	app apps.App
+}
+
+// activeRuns tracks the started events of the runs that did not exit yet, with the allow overlap policy
+// the app is still running once one of several runs exited
+type activeRuns struct {
+	mu      sync.Mutex
+	started map[int]Event
+}
+
+func (a *activeRuns) set(id int, event Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.started[id] = event
+}
+
+// remove forgets the run and returns the started event of the most recently started run that is still running
+func (a *activeRuns) remove(id int) (Event, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.started, id)
+
+	var latest Event
+	found := false
+	for _, event := range a.started {
+		if !found || event.Time.After(latest.Time) {
+			latest = event
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// superviseScheduled runs the app at every activation of its schedule until ctx is done,
+// a run that is due while the previous one is still running is skipped, queued or started next to it
+// according to the overlap policy
+func superviseScheduled(ctx context.Context, app *apps.App, capture *logs.Capture, stdoutFile io.Writer, stderrFile io.Writer, onEvent EventFunc) error {
+	sched, err := schedule.Parse(app.Schedule.Cron)
+	if err != nil {
+		writeStdErr(app.StderrPath, err)
+		return err
+	}
+
+	finished := make(chan runResult)
+	active := &activeRuns{started: make(map[int]Event)}
+	running := 0
+	runID := 0
+	queued := false
+
+	// every run works on its own copy of the app, the scheduler merges the result once it exited
+	startRun := func() {
+		running++
+		runID++
+		id := runID
+		run := *app
+		run.FinishedAt = nil
+		run.TriggeredBy = common.TriggerSchedule
+		go func() {
+			startedAt := time.Now()
+			offsets := logOffsets(run)
+
+			stdout := capture.Writer(stdoutFile, logs.OutLogs)
+			stderr := capture.Writer(stderrFile, logs.ErrLogs)
+			exit := runChild(ctx, &run, capture, stdout, stderr, func(event Event) {
+				if event.Type == EventStarted {
+					active.set(id, event)
+				}
+				onEvent(event)
+			})
+			stdout.Flush()
+			stderr.Flush()
+			recordRun(run, startedAt, exit, offsets)
+			finished <- runResult{id: id, app: run}
+		}()
+	}
+
+	next := sched.Next(time.Now())
+	if next.IsZero() {
+		util.DebugLog("schedule of %s has no next run", app.Name)
+		return nil
+	}
+	if err := setScheduled(app, next, running, onEvent); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			app.NextRunAt = nil
+			if running == 0 && app.ExitCode != nil {
+				// nothing was killed, the app keeps the outcome of its last run
+				app.Status = common.AppStatusFailed
+				if *app.ExitCode == 0 {
+					app.Status = common.AppStatusSuccess
+				}
+				app.PID = -1
+				if err := app.SaveStatus(); err != nil {
+					writeStdErr(app.StderrPath, err)
+				}
+				onEvent(Event{Type: EventStopped, App: app.Name, Time: time.Now(), ExitCode: app.ExitCode})
+				return nil
+			}
+
+			// the runs are stopped by the same ctx
+			for running > 0 {
+				<-finished
+				running--
+			}
+			setKilledStatus(app, onEvent)
+			return nil
+		case result := <-finished:
+			running--
+			mergeRun(app, result.app)
+
+			// the exited run saved its own status, the app is still running with the other runs
+			if latest, ok := active.remove(result.id); ok {
+				app.Status = common.AppStatusRunning
+				app.PID = latest.PID
+				app.StartedAt = new(latest.Time)
+				app.FinishedAt = nil
+			} else if running > 0 {
+				app.Status = common.AppStatusStarting
+				app.PID = -1
+			}
+
+			if queued && running == 0 {
+				queued = false
+				startRun()
+				continue
+			}
+			if err := setScheduled(app, next, running, onEvent); err != nil {
+				return err
+			}
+		case <-timer.C:
+			now := time.Now()
+			// the activations missed while the machine was suspended are not caught up
+			from := next
+			if now.After(from) {
+				from = now
+			}
+			next = sched.Next(from)
+			if next.IsZero() {
+				util.DebugLog("schedule of %s has no next run", app.Name)
+			} else {
+				timer.Reset(time.Until(next))
+			}
+			app.NextRunAt = new(next)
+
+			switch {
+			case running == 0, app.Schedule.Overlap == common.OverlapAllow:
+				startRun()
+			case app.Schedule.Overlap == common.OverlapQueue:
+				util.DebugLog("queueing run of %s, the previous run is still running", app.Name)
+				queued = true
+			default:
+				util.DebugLog("skipping run of %s, the previous run is still running", app.Name)
+				onEvent(Event{Type: EventSkipped, App: app.Name, Time: now})
+			}
+		}
+	}
+}
+
+// setScheduled saves the app as waiting for its next run, unless other runs are still running
+func setScheduled(app *apps.App, next time.Time, running int, onEvent EventFunc) error {
+	if !next.IsZero() {
+		app.NextRunAt = new(next)
+	}
+	if running > 0 {
+		return app.SaveStatus()
+	}
+
+	app.Status = common.AppStatusScheduled
+	app.PID = -1
+	if err := app.SaveStatus(); err != nil {
+		writeStdErr(app.StderrPath, err)
+		return err
+	}
+	if !next.IsZero() {
+		onEvent(Event{Type: EventScheduled, App: app.Name, Time: time.Now(), Delay: time.Until(next)})
+	}
+	return nil
+}
+
+// mergeRun keeps the outcome of the exited run as the last run of the app
+func mergeRun(app *apps.App, run apps.App) {
+	app.Status = run.Status
+	app.PID = run.PID
+	app.ExitCode = run.ExitCode
+	app.StartedAt = run.StartedAt
+	app.ReadyAt = run.ReadyAt
+	app.FinishedAt = run.FinishedAt
+	app.HealthError = run.HealthError
+	app.TriggeredBy = run.TriggeredBy
+}
@@ -0,0 +1,123 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func TestSupervise_Scheduled(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:     "backup",
+		Mode:     common.RunModeScheduled,
+		Command:  "echo backup; exit 3",
+		Env:      os.Environ(),
+		Schedule: &apps.Schedule{Cron: "@every 1s", Overlap: common.OverlapSkip},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var events []EventType
+	scheduled := 0
+	rescheduled := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- Supervise(ctx, app, func(event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event.Type)
+			if event.Type == EventScheduled {
+				scheduled++
+				// scheduled again after the first run
+				if scheduled == 2 {
+					close(rescheduled)
+				}
+			}
+		})
+	}()
+
+	select {
+	case <-rescheduled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the app to run once")
+	}
+
+	saved, err := apps.Get("backup")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusScheduled, saved.Status)
+	require.True(t, saved.IsRunning(), "expected a scheduled app to be running")
+	require.Equal(t, 3, *saved.ExitCode)
+	require.NotNil(t, saved.NextRunAt)
+	require.True(t, saved.NextRunAt.After(*saved.StartedAt))
+
+	cancel()
+	require.NoError(t, <-done)
+
+	stopped, err := apps.Get("backup")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, stopped.Status)
+	require.Equal(t, 3, *stopped.ExitCode, "expected the app to keep the exit code of its last run when stopped between runs")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []EventType{EventScheduled, EventStarted, EventExited, EventScheduled, EventStopped}, events)
+
+	stdout, err := os.ReadFile(saved.StdoutPath)
+	require.NoError(t, err)
+	require.Equal(t, "backup\n", string(stdout))
+}
+
+func TestSupervise_ScheduledOverlap(t *testing.T) {
+	// runs are due at 1s and 2s, the first run lasts until 2.5s
+	tests := []struct {
+		overlap         common.OverlapPolicy
+		expectedStarted int
+		expectedSkipped int
+	}{
+		{overlap: common.OverlapSkip, expectedStarted: 1, expectedSkipped: 1},
+		// the queued run starts at 2.5s
+		{overlap: common.OverlapQueue, expectedStarted: 2},
+		{overlap: common.OverlapAllow, expectedStarted: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.overlap), func(t *testing.T) {
+			setupHome(t)
+
+			app, err := Create(apps.App{
+				Name:     "sync",
+				Mode:     common.RunModeScheduled,
+				Command:  "sleep 1.5",
+				Env:      os.Environ(),
+				Schedule: &apps.Schedule{Cron: "@every 1s", Overlap: tt.overlap},
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2750*time.Millisecond)
+			defer cancel()
+
+			var mu sync.Mutex
+			counts := make(map[EventType]int)
+			require.NoError(t, Supervise(ctx, app, func(event Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				counts[event.Type]++
+			}))
+
+			require.Equal(t, tt.expectedStarted, counts[EventStarted])
+			require.Equal(t, tt.expectedSkipped, counts[EventSkipped])
+		})
+	}
+}
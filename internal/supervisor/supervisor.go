@@ -0,0 +1,618 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/health"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/util"
+	"github.com/0xB1a60/runapp/internal/watch"
+)
+
+const (
+	outputWaitDelay = 5 * time.Second
+	// stopTimeout is how long a stopped app gets to exit after SIGTERM before it is killed
+	stopTimeout = 10 * time.Second
+)
+
+type EventType string
+
+const (
+	EventStarted    EventType = "started"
+	EventExited     EventType = "exited"
+	EventRestarting EventType = "restarting"
+	EventStopped    EventType = "stopped"
+	EventUnhealthy  EventType = "unhealthy"
+	EventHealthy    EventType = "healthy"
+	// EventReady is only sent for apps with readiness probes, other apps are ready once started
+	EventReady EventType = "ready"
+	// EventScheduled is sent when a scheduled app waits for its next run, Delay is the time until the run
+	EventScheduled EventType = "scheduled"
+	// EventSkipped is sent when a run of a scheduled app is skipped because the previous run is still running
+	EventSkipped EventType = "skipped"
+)
+
+// Event is a state change of a supervised app
+type Event struct {
+	Type EventType `json:"type"`
+	App  string    `json:"app"`
+	Time time.Time `json:"time"`
+	// PID is set for started events
+	PID int `json:"pid,omitempty"`
+	// ExitCode is set for exited and stopped events
+	ExitCode *int `json:"exit_code,omitempty"`
+	// Delay until the next start, set for restarting and scheduled events
+	Delay time.Duration `json:"delay,omitempty"`
+	// Error is the failed health check, set for unhealthy events
+	Error string `json:"error,omitempty"`
+}
+
+// EventFunc is called on every state change of the app, it must not block
+type EventFunc func(event Event)
+
+// Supervise runs the app command, writes its logs and applies the restart policy until the app exits for good,
+// cancelling ctx stops the app and marks it as killed. It is called by the background process started for the app
+func Supervise(ctx context.Context, app *apps.App, onEvent EventFunc) error {
+	app.WrapperPID = os.Getpid()
+	app.DaemonPID = 0
+	return supervise(ctx, app, onEvent)
+}
+
+// SuperviseInDaemon is Supervise for the daemon, the app records the daemon PID so stopping the app never kills the daemon
+func SuperviseInDaemon(ctx context.Context, app *apps.App, onEvent EventFunc) error {
+	app.WrapperPID = 0
+	app.DaemonPID = os.Getpid()
+	return supervise(ctx, app, onEvent)
+}
+
+func supervise(ctx context.Context, app *apps.App, onEvent EventFunc) error {
+	if onEvent == nil {
+		onEvent = func(Event) {}
+	}
+
+	stdoutFile, err := logs.CreateRotating(app.StdoutPath, app.LogRotation)
+	if err != nil {
+		writeStdErr(app.StderrPath, err)
+		return err
+	}
+	defer func(stdoutFile *logs.RotatingFile) {
+		if err := stdoutFile.Close(); err != nil {
+			fmt.Println("failed to close stdout", err)
+		}
+	}(stdoutFile)
+
+	stderrFile, err := logs.CreateRotating(app.StderrPath, app.LogRotation)
+	if err != nil {
+		writeStdErr(app.StderrPath, err)
+		return err
+	}
+	defer func(stderrFile *logs.RotatingFile) {
+		if err := stderrFile.Close(); err != nil {
+			fmt.Println("failed to close stderr", err)
+		}
+	}(stderrFile)
+
+	var combined io.Writer = io.Discard
+	if len(app.CombinedPath) != 0 {
+		combinedFile, err := logs.CreateRotating(app.CombinedPath, app.LogRotation)
+		if err != nil {
+			writeStdErr(app.StderrPath, err)
+			return err
+		}
+		defer func(combinedFile *logs.RotatingFile) {
+			if err := combinedFile.Close(); err != nil {
+				fmt.Println("failed to close combined log", err)
+			}
+		}(combinedFile)
+		combined = combinedFile
+	}
+
+	capture := logs.NewCapture(combined)
+	stdout := capture.Writer(stdoutFile, logs.OutLogs)
+	stderr := capture.Writer(stderrFile, logs.ErrLogs)
+
+	if app.Mode == common.RunModeScheduled && app.Schedule != nil {
+		return superviseScheduled(ctx, app, capture, stdoutFile, stderrFile, onEvent)
+	}
+
+	// the watcher lives as long as the app is supervised, it only restarts a running app
+	var changes <-chan string
+	if app.Watch != nil {
+		changes, err = watch.Changes(ctx, app.CWD, *app.Watch)
+		if err != nil {
+			writeStdErr(app.StderrPath, err)
+			return err
+		}
+	}
+
+	for {
+		startedAt := time.Now()
+		offsets := logOffsets(*app)
+
+		exit, changed := runUntilChange(ctx, app, capture, stdout, stderr, onEvent, changes)
+		stdout.Flush()
+		stderr.Flush()
+		recordRun(*app, startedAt, exit, offsets)
+
+		if len(changed) != 0 {
+			if err := restartOnChange(app, changed, stdout, onEvent); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if exit.killed {
+			setKilledStatus(app, onEvent)
+			return nil
+		}
+
+		if !app.Restart.ShouldRestart(exit.code) {
+			if changes == nil {
+				return nil
+			}
+			if restarted, err := waitForChange(ctx, app, changes, stdout, onEvent); !restarted {
+				return err
+			}
+			continue
+		}
+
+		if app.Restart.IsStable(time.Since(startedAt)) {
+			app.RestartCount = 0
+		}
+
+		if !app.Restart.CanRetry(app.RestartCount) {
+			util.DebugLog("max retries (%d) reached", app.Restart.MaxRetries)
+			if changes == nil {
+				return nil
+			}
+			if restarted, err := waitForChange(ctx, app, changes, stdout, onEvent); !restarted {
+				return err
+			}
+			continue
+		}
+
+		delay := app.Restart.Backoff(app.RestartCount)
+		app.RestartCount++
+		app.Status = common.AppStatusRestarting
+		app.TriggeredBy = common.TriggerRestartPolicy
+		app.NextRestartAt = new(time.Now().Add(delay))
+
+		if err := app.SaveStatus(); err != nil {
+			writeStdErr(app.StderrPath, err)
+			return err
+		}
+		onEvent(Event{Type: EventRestarting, App: app.Name, Time: time.Now(), Delay: delay})
+
+		util.DebugLog("restarting in %s (attempt %d)", delay, app.RestartCount)
+
+		select {
+		case <-ctx.Done():
+			util.DebugLog("stopped while waiting for restart")
+			app.NextRestartAt = nil
+			setKilledStatus(app, onEvent)
+			return nil
+		case <-time.After(delay):
+		}
+
+		app.NextRestartAt = nil
+		app.FinishedAt = nil
+	}
+}
+
+// childExit is how a run of the app command ended
+type childExit struct {
+	code int
+	// signal is the name of the signal that terminated the command, empty if it exited by itself
+	signal string
+	// killed is set when the app was stopped by cancelling ctx
+	killed bool
+}
+
+// runChild starts the app command once and blocks until it exits
+func runChild(ctx context.Context, app *apps.App, capture *logs.Capture, stdout io.Writer, stderr io.Writer, onEvent EventFunc) childExit {
+	app.OOMKilled = false
+
+	// apps with rlimits are started through runapp, they are not started without them
+	cmdArgs, err := commandArgs(*app)
+	if err != nil {
+		return startFailed(app, err, onEvent)
+	}
+
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Env = app.Env
+	cmd.Dir = app.CWD
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// output is copied through pipes, do not wait forever for descendants that inherited them
+	cmd.WaitDelay = outputWaitDelay
+	// signals sent to the supervisor (e.g. CTRL+C on a foreground daemon) must not reach the app directly
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	group := createCgroup(app, stderr)
+	if group != nil {
+		defer group.Close()
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = group.FD()
+	}
+
+	// the pattern is watched before the start, the first lines must not be missed
+	logMatched := watchLogPattern(app, capture)
+	defer capture.OnLine(nil)
+
+	if err := cmd.Start(); err != nil {
+		return startFailed(app, err, onEvent)
+	}
+
+	// only now do we know the real PID of the spawned process —
+	// persist that instead of this wrapper's own PID,
+	// apps with readiness probes stay starting until the probes pass
+	app.Status = common.AppStatusStarting
+	if app.Readiness == nil {
+		app.Status = common.AppStatusRunning
+	}
+	app.PID = cmd.Process.Pid
+	app.ExitCode = nil
+	app.StartedAt = new(time.Now())
+	app.ReadyAt = nil
+	if app.Readiness == nil {
+		app.ReadyAt = app.StartedAt
+	}
+	app.HealthError = ""
+
+	if err := app.SaveStatus(); err != nil {
+		fmt.Println("error saving cfg", err)
+		writeStdErr(app.StderrPath, err)
+	}
+	onEvent(Event{Type: EventStarted, App: app.Name, Time: time.Now(), PID: app.PID})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	// the readiness probes and the health check only live as long as this run of the app
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+
+	var ready <-chan struct{}
+	var healthChanges <-chan error
+	if app.Readiness == nil {
+		healthChanges = monitorHealth(runCtx, *app)
+	} else {
+		ready = waitReady(runCtx, *app, logMatched)
+	}
+
+	ctxDone := ctx.Done()
+	var forceKill <-chan time.Time
+	killed := false
+	// unhealthy is set once the app is stopped because of its health check, it exits as failed
+	unhealthy := false
+	for {
+		select {
+		case <-ready:
+			ready = nil
+			app.Status = common.AppStatusRunning
+			app.ReadyAt = new(time.Now())
+			if err := app.SaveStatus(); err != nil {
+				writeStdErr(app.StderrPath, err)
+			}
+			onEvent(Event{Type: EventReady, App: app.Name, Time: time.Now()})
+
+			// an app is only checked for health once it is ready
+			healthChanges = monitorHealth(runCtx, *app)
+		case healthErr := <-healthChanges:
+			if healthErr == nil {
+				app.Status = common.AppStatusRunning
+				app.HealthError = ""
+				if err := app.SaveStatus(); err != nil {
+					writeStdErr(app.StderrPath, err)
+				}
+				onEvent(Event{Type: EventHealthy, App: app.Name, Time: time.Now()})
+				continue
+			}
+
+			app.Status = common.AppStatusUnhealthy
+			app.HealthError = healthErr.Error()
+			if err := app.SaveStatus(); err != nil {
+				writeStdErr(app.StderrPath, err)
+			}
+			onEvent(Event{Type: EventUnhealthy, App: app.Name, Time: time.Now(), Error: app.HealthError})
+
+			if app.HealthCheck.RestartOnUnhealthy && !killed {
+				util.DebugLog("stopping unhealthy app: %s", app.Name)
+				unhealthy = true
+				healthChanges = nil
+				forceKill = time.After(stopTimeout)
+				if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM); err != nil {
+					fmt.Println("failed to send SIGTERM", err)
+				}
+			}
+		case <-ctxDone:
+			util.DebugLog("stopping app: %s", app.Name)
+			ctxDone = nil
+			killed = true
+			forceKill = time.After(stopTimeout)
+			// the app runs in its own process group, signal its descendants too
+			if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM); err != nil {
+				fmt.Println("failed to send SIGTERM", err)
+			}
+		case <-forceKill:
+			util.DebugLog("force killing app: %s", app.Name)
+			if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+				fmt.Println("failed to send SIGKILL", err)
+			}
+		case err := <-done:
+			signal := exitSignal(err)
+			app.OOMKilled = group != nil && group.OOMKilled()
+			if unhealthy && !killed {
+				exitCode := unhealthyExitCode(err)
+				app.ExitCode = new(exitCode)
+				app.Status = common.AppStatusFailed
+				app.FinishedAt = new(time.Now())
+
+				if err := app.SaveStatus(); err != nil {
+					writeStdErr(app.StderrPath, err)
+				}
+				onEvent(Event{Type: EventExited, App: app.Name, Time: time.Now(), ExitCode: app.ExitCode})
+				return childExit{code: exitCode, signal: signal}
+			}
+
+			if err == nil {
+				// an app that exits cleanly on SIGTERM was still killed, supervise marks it as killed
+				if !killed {
+					app.ExitCode = new(0)
+					app.Status = common.AppStatusSuccess
+					app.FinishedAt = new(time.Now())
+
+					if err := app.SaveStatus(); err != nil {
+						writeStdErr(app.StderrPath, err)
+					}
+					onEvent(Event{Type: EventExited, App: app.Name, Time: time.Now(), ExitCode: app.ExitCode})
+				}
+				return childExit{code: 0, killed: killed}
+			}
+
+			exitCode := 255
+			var exitError *exec.ExitError
+			if errors.As(err, &exitError) {
+				if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+					exitCode = status.ExitStatus()
+					if !killed {
+						app.ExitCode = new(exitCode)
+						app.Status = common.AppStatusFailed
+						app.FinishedAt = new(time.Now())
+
+						if err := app.SaveStatus(); err != nil {
+							writeStdErr(app.StderrPath, err)
+						}
+						onEvent(Event{Type: EventExited, App: app.Name, Time: time.Now(), ExitCode: app.ExitCode})
+					}
+				}
+			}
+
+			writeStdErr(app.StderrPath, err)
+			return childExit{code: exitCode, signal: signal, killed: killed}
+		}
+	}
+}
+
+// startFailed marks the app as failed when its command could not be started
+func startFailed(app *apps.App, err error, onEvent EventFunc) childExit {
+	app.ExitCode = new(255)
+	app.Status = common.AppStatusFailed
+	app.FinishedAt = new(time.Now())
+
+	if err := app.SaveStatus(); err != nil {
+		writeStdErr(app.StderrPath, err)
+	}
+
+	writeStdErr(app.StderrPath, err)
+	onEvent(Event{Type: EventExited, App: app.Name, Time: time.Now(), ExitCode: app.ExitCode})
+	return childExit{code: 255}
+}
+
+// monitorHealth runs the health check of the app until ctx is done, the returned channel receives the failed check
+// when the app becomes unhealthy and nil when it recovers, it is nil for apps without health check
+func monitorHealth(ctx context.Context, app apps.App) <-chan error {
+	if app.HealthCheck == nil {
+		return nil
+	}
+
+	ch := make(chan error)
+	go health.Monitor(ctx, *app.HealthCheck, app, func(err error) {
+		select {
+		case ch <- err:
+		case <-ctx.Done():
+		}
+	})
+	return ch
+}
+
+// watchLogPattern returns a channel that is closed once a line of the app matches its readiness log pattern
+func watchLogPattern(app *apps.App, capture *logs.Capture) <-chan struct{} {
+	matched := make(chan struct{})
+	if app.Readiness == nil || len(app.Readiness.LogPattern) == 0 {
+		return matched
+	}
+
+	pattern, err := regexp.Compile(app.Readiness.LogPattern)
+	if err != nil {
+		// validated when the app is created, the app never becomes ready
+		writeStdErr(app.StderrPath, err)
+		return matched
+	}
+
+	var once sync.Once
+	capture.OnLine(func(line string) {
+		if pattern.MatchString(line) {
+			once.Do(func() {
+				close(matched)
+			})
+		}
+	})
+	return matched
+}
+
+// waitReady returns a channel that is closed once every readiness probe of the app passed
+func waitReady(ctx context.Context, app apps.App, logMatched <-chan struct{}) <-chan struct{} {
+	ready := make(chan struct{})
+	go func() {
+		if err := health.WaitReady(ctx, *app.Readiness, app, logMatched); err != nil {
+			return
+		}
+		close(ready)
+	}()
+	return ready
+}
+
+// runUntilChange runs the app once, a change of a watched file stops the run and is returned
+func runUntilChange(ctx context.Context, app *apps.App, capture *logs.Capture, stdout io.Writer, stderr io.Writer, onEvent EventFunc, changes <-chan string) (childExit, string) {
+	if changes == nil {
+		return runChild(ctx, app, capture, stdout, stderr, onEvent), ""
+	}
+
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+
+	changed := make(chan string, 1)
+	go func() {
+		select {
+		case name := <-changes:
+			changed <- name
+			cancelRun()
+		case <-runCtx.Done():
+		}
+	}()
+
+	exit := runChild(runCtx, app, capture, stdout, stderr, onEvent)
+	cancelRun()
+
+	// the app is killed for good when ctx is done, whatever changed meanwhile
+	if ctx.Err() != nil {
+		return exit, ""
+	}
+	select {
+	case name := <-changed:
+		return exit, name
+	default:
+		return exit, ""
+	}
+}
+
+// restartOnChange logs the change into the app output and saves the app as restarting
+func restartOnChange(app *apps.App, changed string, stdout *logs.LineWriter, onEvent EventFunc) error {
+	util.DebugLog("restarting %s due to change in %s", app.Name, changed)
+	fmt.Fprintf(stdout, "[runapp] restarting due to change in %s\n", changed) // no lint // handling this error is not needed
+	stdout.Flush()
+
+	app.Status = common.AppStatusRestarting
+	app.TriggeredBy = common.TriggerWatch
+	app.FinishedAt = nil
+	if err := app.SaveStatus(); err != nil {
+		writeStdErr(app.StderrPath, err)
+		return err
+	}
+	onEvent(Event{Type: EventRestarting, App: app.Name, Time: time.Now()})
+	return nil
+}
+
+// waitForChange keeps a watched app that exited for good as restarting until a watched file changes,
+// returns whether the app is restarted, it is marked as killed when ctx is done meanwhile
+func waitForChange(ctx context.Context, app *apps.App, changes <-chan string, stdout *logs.LineWriter, onEvent EventFunc) (bool, error) {
+	fmt.Fprintf(stdout, "[runapp] exited, restarting on the next change\n") // no lint // handling this error is not needed
+	stdout.Flush()
+
+	app.Status = common.AppStatusRestarting
+	if err := app.SaveStatus(); err != nil {
+		writeStdErr(app.StderrPath, err)
+		return false, err
+	}
+	onEvent(Event{Type: EventRestarting, App: app.Name, Time: time.Now()})
+
+	select {
+	case <-ctx.Done():
+		util.DebugLog("stopped while waiting for a change")
+		setKilledStatus(app, onEvent)
+		return false, nil
+	case changed := <-changes:
+		if err := restartOnChange(app, changed, stdout, onEvent); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+// exitSignal returns the name of the signal that terminated the command, empty if it exited by itself
+func exitSignal(err error) string {
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) {
+		return ""
+	}
+	status, ok := exitError.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	return unix.SignalName(status.Signal())
+}
+
+// unhealthyExitCode is the exit code of an app stopped because it was unhealthy,
+// an app that exits cleanly on SIGTERM is still reported as failed so the on-failure policy restarts it
+func unhealthyExitCode(err error) int {
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) {
+		return 128 + int(syscall.SIGTERM)
+	}
+
+	status, ok := exitError.Sys().(syscall.WaitStatus)
+	if !ok {
+		return 255
+	}
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	if status.ExitStatus() == 0 {
+		return 128 + int(syscall.SIGTERM)
+	}
+	return status.ExitStatus()
+}
+
+func writeStdErr(path string, err error) {
+	stderrFile, stdErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if stdErr != nil {
+		fmt.Println("failed to open stderr", stdErr)
+		return
+	}
+	defer func(stderrFile *os.File) {
+		if err := stderrFile.Close(); err != nil {
+			fmt.Println("failed to close stderr", err)
+		}
+	}(stderrFile)
+
+	if _, err := stderrFile.Write([]byte(err.Error())); err != nil {
+		fmt.Println("failed to write stderr", err)
+	}
+}
+
+func setKilledStatus(app *apps.App, onEvent EventFunc) {
+	app.ExitCode = new(137)
+	app.Status = common.AppStatusFailed
+	app.FinishedAt = new(time.Now())
+
+	if err := app.SaveStatus(); err != nil {
+		writeStdErr(app.StderrPath, err)
+	}
+	onEvent(Event{Type: EventStopped, App: app.Name, Time: time.Now(), ExitCode: app.ExitCode})
+}
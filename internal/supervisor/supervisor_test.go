@@ -0,0 +1,407 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	// apps with rlimits are started through the runapp binary, the test binary stands in for it
+	if len(os.Args) > 1 && os.Args[1] == ExecLimitsCmd {
+		if err := ExecWithLimits(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err) // no lint // handling this error is not needed
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func setupHome(t *testing.T) {
+	t.Helper()
+	testutil.SetupHome(t)
+	t.Setenv("SHELL", "/bin/sh")
+}
+
+func collectEvents(events *[]EventType) EventFunc {
+	return func(event Event) {
+		*events = append(*events, event.Type)
+	}
+}
+
+func TestSupervise_RestartPolicy(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:    "flaky",
+		Mode:    common.RunModeOnce,
+		Command: "echo out; exit 3",
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyOnFailure, MaxRetries: 2, BackoffBase: 10 * time.Millisecond, BackoffCap: time.Second},
+	})
+	require.NoError(t, err)
+
+	var events []EventType
+	require.NoError(t, Supervise(context.Background(), app, collectEvents(&events)))
+
+	require.Equal(t, []EventType{
+		EventStarted, EventExited, EventRestarting,
+		EventStarted, EventExited, EventRestarting,
+		EventStarted, EventExited,
+	}, events)
+
+	saved, err := apps.Get("flaky")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, saved.Status)
+	require.Equal(t, 3, *saved.ExitCode)
+	require.Equal(t, 2, saved.RestartCount)
+
+	stdout, err := os.ReadFile(saved.StdoutPath)
+	require.NoError(t, err)
+	require.Equal(t, "out\nout\nout\n", string(stdout))
+}
+
+func TestSupervise_Stop(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:    "sleeper",
+		Mode:    common.RunModeOnce,
+		Command: "sleep 30 & sleep 30",
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyAlways},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	var events []EventType
+	done := make(chan error)
+	go func() {
+		done <- Supervise(ctx, app, func(event Event) {
+			events = append(events, event.Type)
+			if event.Type == EventStarted {
+				close(started)
+			}
+		})
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the app to stop")
+	}
+	require.Equal(t, []EventType{EventStarted, EventStopped}, events, "expected a stopped app to not be restarted")
+
+	saved, err := apps.Get("sleeper")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, saved.Status)
+	require.Equal(t, 137, *saved.ExitCode)
+}
+
+func TestSupervise_StopCleanExit(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:    "graceful",
+		Mode:    common.RunModeOnce,
+		Command: "trap 'exit 0' TERM; while true; do sleep 0.1; done",
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyAlways},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	var events []EventType
+	done := make(chan error)
+	go func() {
+		done <- Supervise(ctx, app, func(event Event) {
+			events = append(events, event.Type)
+			if event.Type == EventStarted {
+				close(started)
+			}
+		})
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the app to stop")
+	}
+	require.Equal(t, []EventType{EventStarted, EventStopped}, events, "expected an app that exits with 0 on SIGTERM to not be restarted")
+
+	saved, err := apps.Get("graceful")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, saved.Status)
+	require.Equal(t, 137, *saved.ExitCode)
+}
+
+func TestReset(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{Name: "done", Mode: common.RunModeOnce, Command: "true"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(app.StdoutPath, []byte("previous run\n"), 0644))
+	require.NoError(t, os.WriteFile(app.StdoutPath+".1", []byte("older run\n"), 0644))
+	app.RestartCount = 4
+
+	// the app changed since it was read, e.g. by runapp apply
+	_, err = apps.Update("done", func(stored *apps.App) error {
+		stored.Labels = map[string]string{"tier": "batch"}
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, Reset(app, common.TriggerRestart))
+	require.NoFileExists(t, app.StdoutPath)
+	require.NoFileExists(t, app.StdoutPath+".1")
+	require.Equal(t, map[string]string{"tier": "batch"}, app.Labels)
+
+	saved, err := apps.Get("done")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusStarting, saved.Status)
+	require.Equal(t, common.TriggerRestart, saved.TriggeredBy)
+	require.Equal(t, map[string]string{"tier": "batch"}, saved.Labels)
+	require.Zero(t, saved.RestartCount)
+}
+
+func TestSupervise_RestartOnUnhealthy(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:    "stuck",
+		Mode:    common.RunModeOnce,
+		Command: "sleep 30",
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyOnFailure, MaxRetries: 1, BackoffBase: 10 * time.Millisecond, BackoffCap: time.Second},
+		HealthCheck: &apps.HealthCheck{
+			Command:            "echo not ready; exit 1",
+			Interval:           20 * time.Millisecond,
+			FailureThreshold:   2,
+			RestartOnUnhealthy: true,
+		},
+	})
+	require.NoError(t, err)
+
+	var events []EventType
+	require.NoError(t, Supervise(context.Background(), app, collectEvents(&events)))
+
+	require.Equal(t, []EventType{
+		EventStarted, EventUnhealthy, EventExited, EventRestarting,
+		EventStarted, EventUnhealthy, EventExited,
+	}, events)
+
+	saved, err := apps.Get("stuck")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, saved.Status)
+	require.Equal(t, 143, *saved.ExitCode)
+	require.Contains(t, saved.HealthError, "not ready")
+}
+
+func TestSupervise_Readiness(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:      "server",
+		Mode:      common.RunModeOnce,
+		Command:   "echo booting; sleep 0.3; echo listening on 8080; sleep 0.3",
+		Env:       os.Environ(),
+		Restart:   apps.Restart{Policy: common.RestartPolicyNever},
+		Readiness: &apps.Readiness{LogPattern: `listening on \d+`, Interval: 10 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	var events []EventType
+	var startedStatus common.AppStatus
+	require.NoError(t, Supervise(context.Background(), app, func(event Event) {
+		events = append(events, event.Type)
+		if event.Type == EventStarted {
+			saved, err := apps.Get("server")
+			require.NoError(t, err)
+			startedStatus = saved.Status
+		}
+	}))
+
+	require.Equal(t, []EventType{EventStarted, EventReady, EventExited}, events)
+	require.Equal(t, common.AppStatusStarting, startedStatus, "expected the app to be starting until it is ready")
+
+	saved, err := apps.Get("server")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusSuccess, saved.Status)
+	require.NotNil(t, saved.ReadyAt)
+	require.GreaterOrEqual(t, saved.ReadyAt.Sub(*saved.StartedAt), 300*time.Millisecond)
+}
+
+func TestSupervise_Watch(t *testing.T) {
+	setupHome(t)
+	cwd := t.TempDir()
+
+	app, err := Create(apps.App{
+		Name:    "dev",
+		Mode:    common.RunModeOnce,
+		Command: "echo serving; sleep 30",
+		CWD:     cwd,
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyNever},
+		Watch:   &apps.Watch{Patterns: []string{"**/*.go"}, Debounce: 50 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 2)
+
+	var events []EventType
+	done := make(chan error)
+	go func() {
+		done <- Supervise(ctx, app, func(event Event) {
+			events = append(events, event.Type)
+			if event.Type == EventStarted {
+				started <- struct{}{}
+			}
+		})
+	}()
+
+	<-started
+	require.NoError(t, os.WriteFile(path.Join(cwd, "main.go"), []byte("package main\n"), 0644))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the app to be restarted after the change")
+	}
+	require.Eventually(t, func() bool {
+		stdout, err := os.ReadFile(app.StdoutPath)
+		return err == nil && string(stdout) == "serving\n[runapp] restarting due to change in main.go\nserving\n"
+	}, 5*time.Second, 10*time.Millisecond, "expected the restart to be logged between the runs")
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Equal(t, []EventType{EventStarted, EventRestarting, EventStarted, EventStopped}, events)
+
+	runs, err := apps.ReadHistory(*app)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, common.TriggerRun, runs[0].TriggeredBy)
+	require.Equal(t, common.TriggerWatch, runs[1].TriggeredBy)
+}
+
+func TestSupervise_WatchAfterExit(t *testing.T) {
+	setupHome(t)
+	cwd := t.TempDir()
+
+	app, err := Create(apps.App{
+		Name:    "dev",
+		Mode:    common.RunModeOnce,
+		Command: "echo crashed; exit 1",
+		CWD:     cwd,
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyNever},
+		Watch:   &apps.Watch{Patterns: []string{"**/*.go"}, Debounce: 50 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 2)
+
+	done := make(chan error)
+	go func() {
+		done <- Supervise(ctx, app, func(event Event) {
+			if event.Type == EventStarted {
+				started <- struct{}{}
+			}
+		})
+	}()
+
+	<-started
+	waiting := "crashed\n[runapp] exited, restarting on the next change\n"
+	require.Eventually(t, func() bool {
+		stdout, err := os.ReadFile(app.StdoutPath)
+		return err == nil && string(stdout) == waiting
+	}, 5*time.Second, 10*time.Millisecond, "expected the crashed app to wait for a change")
+
+	stored, err := apps.Get(app.Name)
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusRestarting, stored.Status)
+
+	require.NoError(t, os.WriteFile(path.Join(cwd, "main.go"), []byte("package main\n"), 0644))
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the crashed app to be restarted after the change")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestSupervise_Rlimits(t *testing.T) {
+	setupHome(t)
+
+	app, err := Create(apps.App{
+		Name:    "limited",
+		Mode:    common.RunModeOnce,
+		Command: "ulimit -n; ulimit -Hn; ulimit -c",
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyNever},
+		Limits:  &apps.Limits{NoFile: 256, Core: new(apps.LimitSize(0))},
+	})
+	require.NoError(t, err)
+	require.NoError(t, Supervise(context.Background(), app, nil))
+
+	stdout, err := os.ReadFile(app.StdoutPath)
+	require.NoError(t, err)
+	require.Equal(t, "256\n256\n0\n", string(stdout))
+
+	saved, err := apps.Get("limited")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusSuccess, saved.Status)
+	require.False(t, saved.OOMKilled)
+}
+
+func TestSupervise_RlimitsFailed(t *testing.T) {
+	setupHome(t)
+
+	// the hard limit of nofile cannot be raised above the kernel maximum, the app is not started without its limits
+	app, err := Create(apps.App{
+		Name:    "limited",
+		Mode:    common.RunModeOnce,
+		Command: "echo started",
+		Env:     os.Environ(),
+		Restart: apps.Restart{Policy: common.RestartPolicyNever},
+		Limits:  &apps.Limits{NoFile: 1 << 40},
+	})
+	require.NoError(t, err)
+	require.NoError(t, Supervise(context.Background(), app, nil))
+
+	stdout, err := os.ReadFile(app.StdoutPath)
+	require.NoError(t, err)
+	require.Empty(t, string(stdout))
+
+	stderr, err := os.ReadFile(app.StderrPath)
+	require.NoError(t, err)
+	require.Contains(t, string(stderr), "failed to set nofile")
+
+	saved, err := apps.Get("limited")
+	require.NoError(t, err)
+	require.Equal(t, common.AppStatusFailed, saved.Status)
+}
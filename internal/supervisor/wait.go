@@ -0,0 +1,81 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+const (
+	waitPollPeriod = 200 * time.Millisecond
+)
+
+// WaitFor is the state Wait blocks for
+type WaitFor string
+
+const (
+	// WaitForRunning the app process is started, its readiness probes may still be pending
+	WaitForRunning WaitFor = "running"
+	// WaitForReady the app process is started and its readiness probes passed
+	WaitForReady WaitFor = "ready"
+	// WaitForExit the app exited for good, a pending restart is waited for too
+	WaitForExit WaitFor = "exit"
+)
+
+var ValidWaitFor = []WaitFor{WaitForRunning, WaitForReady, WaitForExit}
+
+var (
+	// ErrExited is returned when the app exited before reaching the state that was waited for
+	ErrExited = errors.New("app exited")
+)
+
+// Wait polls the app until it reaches the given state, ctx bounds the wait (e.g. with a timeout).
+// The returned app is the last state read, it is set for ErrExited too
+func Wait(ctx context.Context, name string, waitFor WaitFor) (*apps.App, error) {
+	ticker := time.NewTicker(waitPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		app, err := apps.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		done, err := reached(app, waitFor)
+		if done || err != nil {
+			return app, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return app, fmt.Errorf("%s is %s: %w", app.Name, app.Status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func reached(app *apps.App, waitFor WaitFor) (bool, error) {
+	switch waitFor {
+	case WaitForExit:
+		return !app.IsRunning(), nil
+	case WaitForRunning:
+		if app.HasProcess() {
+			return true, nil
+		}
+	case WaitForReady:
+		if app.IsReady() {
+			return true, nil
+		}
+	default:
+		return false, fmt.Errorf("unknown state: %s", waitFor)
+	}
+
+	// a starting app or a pending restart can still reach the state
+	if !app.IsRunning() {
+		return false, fmt.Errorf("%w: %s is %s", ErrExited, app.Name, app.Status)
+	}
+	return false, nil
+}
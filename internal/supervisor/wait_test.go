@@ -0,0 +1,50 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+)
+
+func TestReached(t *testing.T) {
+	created := apps.App{Status: common.AppStatusStarting, PID: -1}
+	probing := apps.App{Status: common.AppStatusStarting, PID: 42}
+	running := apps.App{Status: common.AppStatusRunning, PID: 42}
+	unhealthy := apps.App{Status: common.AppStatusUnhealthy, PID: 42}
+	restarting := apps.App{Status: common.AppStatusRestarting}
+	failed := apps.App{Status: common.AppStatusFailed, ExitCode: new(1)}
+
+	tests := []struct {
+		name    string
+		app     apps.App
+		waitFor WaitFor
+		reached bool
+		exited  bool
+	}{
+		{name: "created is not running", app: created, waitFor: WaitForRunning},
+		{name: "probing is running", app: probing, waitFor: WaitForRunning, reached: true},
+		{name: "probing is not ready", app: probing, waitFor: WaitForReady},
+		{name: "running is ready", app: running, waitFor: WaitForReady, reached: true},
+		{name: "unhealthy is ready", app: unhealthy, waitFor: WaitForReady, reached: true},
+		{name: "restarting can still be ready", app: restarting, waitFor: WaitForReady},
+		{name: "restarting has not exited", app: restarting, waitFor: WaitForExit},
+		{name: "failed exited before ready", app: failed, waitFor: WaitForReady, exited: true},
+		{name: "failed exited before running", app: failed, waitFor: WaitForRunning, exited: true},
+		{name: "failed exited", app: failed, waitFor: WaitForExit, reached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached, err := reached(&tt.app, tt.waitFor)
+			require.Equal(t, tt.reached, reached)
+			if tt.exited {
+				require.ErrorIs(t, err, ErrExited)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
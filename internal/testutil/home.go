@@ -0,0 +1,22 @@
+// Package testutil has the helpers shared by the tests of several packages
+package testutil
+
+import (
+	"testing"
+
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// SetupHome points HOME to a temporary directory and clears the variables that take precedence over it
+// (RUNAPP_HOME, RUNAPP_PROJECT, XDG_CONFIG_HOME and XDG_STATE_HOME), so a test never touches the apps of the user.
+// It returns the temporary HOME
+func SetupHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, env := range []string{util.HomeEnv, util.ProjectEnv, "XDG_CONFIG_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(env, "")
+	}
+	return home
+}
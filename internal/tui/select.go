@@ -20,18 +20,7 @@ func NamePickerWithValidator(validate func([]apps.App, string) error) (*string,
 
 	options := make([]huh.Option[string], 0, len(list))
 	for _, app := range list {
-		var key string
-		switch app.Status {
-		case common.AppStatusFailed:
-			key = tml.Sprintf("<red>%s</red>", app.Name)
-		case common.AppStatusSuccess:
-			key = tml.Sprintf("<green>%s</green>", app.Name)
-		case common.AppStatusStarting:
-			key = tml.Sprintf("<yellow>%s</yellow>", app.Name)
-		case common.AppStatusRunning:
-			key = tml.Sprintf("<yellow>%s</yellow>", app.Name)
-		}
-		options = append(options, huh.NewOption(key, app.Name))
+		options = append(options, huh.NewOption(FormatName(app), app.Name))
 	}
 
 	var value string
@@ -52,3 +41,20 @@ func NamePickerWithValidator(validate func([]apps.App, string) error) (*string,
 	}
 	return &value, nil
 }
+
+// FormatName colors the app name by its status
+func FormatName(app apps.App) string {
+	switch app.Status {
+	case common.AppStatusFailed:
+		return tml.Sprintf("<red>%s</red>", app.Name)
+	case common.AppStatusSuccess:
+		return tml.Sprintf("<green>%s</green>", app.Name)
+	case common.AppStatusStarting, common.AppStatusRunning, common.AppStatusRestarting:
+		return tml.Sprintf("<yellow>%s</yellow>", app.Name)
+	case common.AppStatusUnhealthy:
+		return tml.Sprintf("<magenta>%s</magenta>", app.Name)
+	case common.AppStatusScheduled:
+		return tml.Sprintf("<blue>%s</blue>", app.Name)
+	}
+	return app.Name
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/liamg/tml"
 )
@@ -13,10 +14,15 @@ const (
 )
 
 var (
+	// debugMu guards cachedDebug, apps are listed from several goroutines
+	debugMu     sync.Mutex
 	cachedDebug *bool
 )
 
 func IsDebug() bool {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+
 	if cachedDebug != nil {
 		return *cachedDebug
 	}
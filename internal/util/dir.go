@@ -3,17 +3,73 @@ package util
 import (
 	"os"
 	"path"
+	"path/filepath"
 )
 
 const (
 	runAppDir = "runapp"
+
+	// HomeEnv replaces both the config and the state directory, set by --home
+	HomeEnv = "RUNAPP_HOME"
+	// ProjectEnv selects an isolated namespace of apps, set by --project
+	ProjectEnv = "RUNAPP_PROJECT"
 )
 
-// GetRunAppDir returns the path to the runapp directory in the user's home directory
+// Project returns the namespace of the apps, empty for the default one
+func Project() string {
+	return os.Getenv(ProjectEnv)
+}
+
+// projectDir is the name of the directory of the current project, runapp for the default one and runapp-<project> otherwise
+func projectDir() string {
+	if project := Project(); len(project) != 0 {
+		return runAppDir + "-" + project
+	}
+	return runAppDir
+}
+
+// HomeDirPath returns the config directory of the current project, it holds a directory with the config.json of every app.
+// It is $RUNAPP_HOME/runapp if set, otherwise $XDG_CONFIG_HOME/runapp (~/.config/runapp)
 func HomeDirPath() (string, error) {
+	root, err := xdgRoot("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", err
+	}
+	return path.Join(root, projectDir()), nil
+}
+
+// StateDirPath returns the state directory of the current project, it holds the logs and run history of every app
+// and the daemon socket and log. It is $RUNAPP_HOME/runapp if set, otherwise $XDG_STATE_HOME/runapp (~/.local/state/runapp)
+func StateDirPath() (string, error) {
+	root, err := xdgRoot("XDG_STATE_HOME", path.Join(".local", "state"))
+	if err != nil {
+		return "", err
+	}
+	return path.Join(root, projectDir()), nil
+}
+
+// LegacyDirPath returns the directory used before the state directory existed, config and logs lived next to each other
+func LegacyDirPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 	return path.Join(homeDir, ".config", runAppDir), nil
 }
+
+// xdgRoot returns $RUNAPP_HOME, the XDG base directory from env or its default relative to the user home directory,
+// relative XDG values are invalid according to the spec and ignored
+func xdgRoot(env string, fallback string) (string, error) {
+	if home := os.Getenv(HomeEnv); len(home) != 0 {
+		return filepath.Abs(home)
+	}
+	if value := os.Getenv(env); len(value) != 0 && filepath.IsAbs(value) {
+		return value, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(homeDir, fallback), nil
+}
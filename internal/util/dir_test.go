@@ -0,0 +1,66 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateDirs(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectedHome  string
+		expectedState string
+	}{
+		{
+			name:          "defaults",
+			expectedHome:  "/home/user/.config/runapp",
+			expectedState: "/home/user/.local/state/runapp",
+		},
+		{
+			name:          "xdg",
+			env:           map[string]string{"XDG_CONFIG_HOME": "/xdg/config", "XDG_STATE_HOME": "/xdg/state"},
+			expectedHome:  "/xdg/config/runapp",
+			expectedState: "/xdg/state/runapp",
+		},
+		{
+			name:          "relative xdg is ignored",
+			env:           map[string]string{"XDG_STATE_HOME": "state"},
+			expectedHome:  "/home/user/.config/runapp",
+			expectedState: "/home/user/.local/state/runapp",
+		},
+		{
+			name:          "project",
+			env:           map[string]string{ProjectEnv: "shop"},
+			expectedHome:  "/home/user/.config/runapp-shop",
+			expectedState: "/home/user/.local/state/runapp-shop",
+		},
+		{
+			name:          "runapp home wins over xdg",
+			env:           map[string]string{HomeEnv: "/srv/runapp", "XDG_CONFIG_HOME": "/xdg/config", ProjectEnv: "shop"},
+			expectedHome:  "/srv/runapp/runapp-shop",
+			expectedState: "/srv/runapp/runapp-shop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", "/home/user")
+			for _, env := range []string{HomeEnv, ProjectEnv, "XDG_CONFIG_HOME", "XDG_STATE_HOME"} {
+				t.Setenv(env, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			homeDir, err := HomeDirPath()
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedHome, homeDir)
+
+			stateDir, err := StateDirPath()
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedState, stateDir)
+		})
+	}
+}
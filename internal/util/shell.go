@@ -35,8 +35,13 @@ var posixShells = map[string]struct{}{
 }
 
 func GetShellArgs() []string {
-	for _, env := range os.Environ() {
-		parts := strings.Split(env, "=")
+	return ShellArgsFromEnv(os.Environ())
+}
+
+// ShellArgsFromEnv returns the arguments to run a command with the POSIX shell of SHELL in env, nil for other shells
+func ShellArgsFromEnv(env []string) []string {
+	for _, entry := range env {
+		parts := strings.Split(entry, "=")
 		if len(parts) > 1 && parts[0] == "SHELL" {
 			if _, ok := posixShells[parts[1]]; ok {
 				return []string{parts[1], "-c"}
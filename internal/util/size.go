@@ -0,0 +1,70 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1024 * 1024 * 1024},
+	{"MB", 1024 * 1024},
+	{"KB", 1024},
+	{"G", 1024 * 1024 * 1024},
+	{"M", 1024 * 1024},
+	{"K", 1024},
+	{"B", 1},
+}
+
+// ParseSize parses human readable sizes like 512, 100KB, 10MB or 1G (powers of 1024)
+func ParseSize(value string) (int64, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	if len(normalized) == 0 {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(normalized, unit.suffix) {
+			multiplier = unit.multiplier
+			normalized = strings.TrimSpace(strings.TrimSuffix(normalized, unit.suffix))
+			break
+		}
+	}
+
+	number, err := strconv.ParseInt(normalized, 10, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+	if number > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("size is too large: %q", value)
+	}
+	return number * multiplier, nil
+}
+
+// FormatSize formats a size in bytes using the largest unit that divides it without a remainder
+func FormatSize(size int64) string {
+	if size == 0 {
+		return "0B"
+	}
+	for _, unit := range sizeUnits[:3] {
+		if size%unit.multiplier == 0 {
+			return strconv.FormatInt(size/unit.multiplier, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
+
+// HumanSize formats a size in bytes rounded to one decimal, e.g. 12.5MB
+func HumanSize(size uint64) string {
+	for _, unit := range sizeUnits[:3] {
+		if size >= uint64(unit.multiplier) {
+			return strconv.FormatFloat(float64(size)/float64(unit.multiplier), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatUint(size, 10) + "B"
+}
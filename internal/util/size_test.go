@@ -0,0 +1,53 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{value: "512", expected: 512},
+		{value: "512B", expected: 512},
+		{value: "100KB", expected: 100 * 1024},
+		{value: "10mb", expected: 10 * 1024 * 1024},
+		{value: "1G", expected: 1024 * 1024 * 1024},
+		{value: " 2 M ", expected: 2 * 1024 * 1024},
+		{value: "8589934591G", expected: 8589934591 * 1024 * 1024 * 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := ParseSize(tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, size)
+		})
+	}
+
+	for _, value := range []string{"", "MB", "ten", "-1", "1.5MB", "9999999999GB", "9223372036854775807K"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			_, err := ParseSize(value)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	require.Equal(t, "0B", FormatSize(0))
+	require.Equal(t, "10MB", FormatSize(10*1024*1024))
+	require.Equal(t, "1GB", FormatSize(1024*1024*1024))
+	require.Equal(t, "1536KB", FormatSize(1536*1024))
+	require.Equal(t, "1000B", FormatSize(1000))
+}
+
+func TestHumanSize(t *testing.T) {
+	require.Equal(t, "0B", HumanSize(0))
+	require.Equal(t, "512B", HumanSize(512))
+	require.Equal(t, "1.5KB", HumanSize(1536))
+	require.Equal(t, "12.3MB", HumanSize(12*1024*1024+300*1024))
+	require.Equal(t, "2.0GB", HumanSize(2*1024*1024*1024))
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"os"
+	"os/exec"
 	"strings"
 	"sync"
 )
@@ -22,3 +23,19 @@ func isSystemdUsed() bool {
 
 	return false
 }
+
+// SystemdUnitProperties returns the given properties of a systemd user unit, e.g. ActiveState or UnitFileState
+func SystemdUnitProperties(unit string, properties ...string) (map[string]string, error) {
+	out, err := exec.Command("systemctl", "--user", "show", unit, "--property="+strings.Join(properties, ",")).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(properties))
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// ParseTimeOrDuration parses an absolute timestamp (RFC3339, "2006-01-02 15:04:05", "2006-01-02"...)
+// or a duration (10m, 1h30m) which is relative to now, e.g. 10m means 10 minutes ago
+func ParseTimeOrDuration(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q, expected a duration (e.g. 10m) or a timestamp (e.g. 2006-01-02T15:04:05Z)", value)
+}
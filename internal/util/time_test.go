@@ -0,0 +1,35 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeOrDuration(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{value: "10m", expected: now.Add(-10 * time.Minute)},
+		{value: "1h30m", expected: now.Add(-90 * time.Minute)},
+		{value: "2025-05-31T08:00:00Z", expected: time.Date(2025, 5, 31, 8, 0, 0, 0, time.UTC)},
+		{value: "2025-05-31T08:00:00+02:00", expected: time.Date(2025, 5, 31, 6, 0, 0, 0, time.UTC)},
+		{value: "2025-05-31 08:00:00", expected: time.Date(2025, 5, 31, 8, 0, 0, 0, time.UTC)},
+		{value: "2025-05-31", expected: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			res, err := ParseTimeOrDuration(tt.value, now)
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(res), "expected %s, got %s", tt.expected, res)
+		})
+	}
+
+	_, err := ParseTimeOrDuration("yesterday", now)
+	require.Error(t, err)
+}
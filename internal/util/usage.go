@@ -0,0 +1,110 @@
+package util
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Usage is the resource usage of a process and all of its descendants
+type Usage struct {
+	// CPUPercent is relative to a single core, a process tree using two cores fully shows 200
+	CPUPercent float64 `json:"cpu_percent" yaml:"cpu_percent"`
+	// RSS is the resident memory in bytes
+	RSS     uint64 `json:"rss" yaml:"rss"`
+	Threads int32  `json:"threads" yaml:"threads"`
+	// FDs is -1 when the amount of open file descriptors can't be read (e.g. processes of other users)
+	FDs       int32         `json:"fds" yaml:"fds"`
+	Processes int           `json:"processes" yaml:"processes"`
+	Uptime    time.Duration `json:"uptime" yaml:"uptime"`
+}
+
+type cpuSample struct {
+	total float64
+	at    time.Time
+}
+
+// UsageSampler measures the usage of process trees, the CPU usage is the average since the previous
+// sample of the same PID so the sampler has to be reused between refreshes
+type UsageSampler struct {
+	prev map[int]cpuSample
+	now  func() time.Time
+}
+
+func NewUsageSampler() *UsageSampler {
+	return &UsageSampler{
+		prev: make(map[int]cpuSample),
+		now:  time.Now,
+	}
+}
+
+// Sample returns the usage of the process and all of its descendants,
+// the first sample of a PID reports the average CPU usage since the process started
+func (s *UsageSampler) Sample(pid int) (Usage, error) {
+	root, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return Usage{}, err
+	}
+
+	children, err := collectChildren(root)
+	if err != nil {
+		DebugLog("failed to collect children of %d: %v", pid, err)
+	}
+
+	now := s.now()
+	var res Usage
+
+	createdAt, err := root.CreateTime()
+	if err != nil {
+		return Usage{}, err
+	}
+	startedAt := time.UnixMilli(createdAt)
+	res.Uptime = now.Sub(startedAt).Round(time.Second)
+
+	var cpuTotal float64
+	for _, proc := range append([]*process.Process{root}, children...) {
+		// processes can exit while they are being measured, count what can still be read
+		times, err := proc.Times()
+		if err != nil {
+			continue
+		}
+		res.Processes++
+		cpuTotal += times.User + times.System
+
+		if mem, err := proc.MemoryInfo(); err == nil {
+			res.RSS += mem.RSS
+		}
+		if threads, err := proc.NumThreads(); err == nil {
+			res.Threads += threads
+		}
+		if res.FDs != -1 {
+			if fds, err := proc.NumFDs(); err == nil {
+				res.FDs += fds
+			} else {
+				res.FDs = -1
+			}
+		}
+	}
+
+	prev, ok := s.prev[pid]
+	if !ok {
+		prev = cpuSample{at: startedAt}
+	}
+	if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 {
+		// descendants that exited since the previous sample take their CPU time with them
+		res.CPUPercent = max(cpuTotal-prev.total, 0) / elapsed * 100
+	}
+	s.prev[pid] = cpuSample{total: cpuTotal, at: now}
+
+	return res, nil
+}
+
+// SampleUsage measures the usage of the process tree over the given interval
+func SampleUsage(pid int, interval time.Duration) (Usage, error) {
+	sampler := NewUsageSampler()
+	if _, err := sampler.Sample(pid); err != nil {
+		return Usage{}, err
+	}
+	time.Sleep(interval)
+	return sampler.Sample(pid)
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageSampler(t *testing.T) {
+	// a shell with a busy child, the child has to be included in the usage
+	cmd := exec.Command("sh", "-c", "while :; do :; done & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		_ = cmd.Wait()
+	}()
+
+	sampler := NewUsageSampler()
+	require.Eventually(t, func() bool {
+		usage, err := sampler.Sample(cmd.Process.Pid)
+		require.NoError(t, err)
+		return usage.Processes == 2
+	}, 2*time.Second, 50*time.Millisecond, "expected the child to be counted")
+
+	time.Sleep(300 * time.Millisecond)
+
+	usage, err := sampler.Sample(cmd.Process.Pid)
+	require.NoError(t, err)
+	require.Greater(t, usage.CPUPercent, 10.0)
+	require.Positive(t, usage.RSS)
+	require.GreaterOrEqual(t, usage.Threads, int32(2))
+	require.NotZero(t, usage.FDs)
+}
+
+func TestUsageSampler_NotFound(t *testing.T) {
+	_, err := NewUsageSampler().Sample(999999)
+	require.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+// skippedDirs are never watched, they change on every commit or install and are never sources
+var skippedDirs = map[string]struct{}{
+	".git":         {},
+	"node_modules": {},
+}
+
+// Changes watches the files under root until ctx is done, the returned channel receives the first changed file
+// (relative to root) once no file matching the watch changed for the debounce window.
+// A change is dropped when nobody is receiving, e.g. while the app is restarting
+func Changes(ctx context.Context, root string, watch apps.Watch) (<-chan string, error) {
+	watch = watch.WithDefaults()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := addTree(watcher, root, root, watch); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	changes := make(chan string)
+	go func() {
+		defer func(watcher *fsnotify.Watcher) {
+			if err := watcher.Close(); err != nil {
+				util.DebugLog("failed to close file watcher: %v", err)
+			}
+		}(watcher)
+
+		// the timer only runs while a change is pending
+		debounce := time.NewTimer(watch.Debounce)
+		debounce.Stop()
+		var pending string
+
+		for {
+			select {
+			case <-ctx.Done():
+				debounce.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+
+				name, err := filepath.Rel(root, event.Name)
+				if err != nil {
+					continue
+				}
+				name = filepath.ToSlash(name)
+
+				// new directories are watched too, fsnotify is not recursive
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := addTree(watcher, root, event.Name, watch); err != nil {
+							util.DebugLog("failed to watch %s: %v", event.Name, err)
+						}
+					}
+				}
+
+				if !watch.Matches(name) {
+					continue
+				}
+				if len(pending) == 0 {
+					pending = name
+				}
+				debounce.Reset(watch.Debounce)
+			case <-debounce.C:
+				select {
+				case changes <- pending:
+				default:
+					util.DebugLog("dropping change of %s, nobody is waiting for it", pending)
+				}
+				pending = ""
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				util.DebugLog("file watcher error: %v", err)
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// addTree watches dir and every directory below it that is not ignored
+func addTree(watcher *fsnotify.Watcher, root string, dir string, watch apps.Watch) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// directories removed while walking are not watched
+			if path != dir {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if path != root {
+			if _, ok := skippedDirs[entry.Name()]; ok {
+				return filepath.SkipDir
+			}
+			name, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if watch.Ignored(filepath.ToSlash(name)) {
+				return filepath.SkipDir
+			}
+		}
+		return watcher.Add(path)
+	})
+}
@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+)
+
+func TestChanges(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "vendor", "lib"), os.ModePerm))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := Changes(ctx, root, apps.Watch{
+		Patterns: []string{"**/*.go"},
+		Ignore:   []string{"vendor/**"},
+		Debounce: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	write := func(name string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte("package main\n"), 0644))
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case name := <-changes:
+			t.Fatalf("unexpected change of %s", name)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+	expect := func(expected string) {
+		t.Helper()
+		select {
+		case name := <-changes:
+			require.Equal(t, expected, name)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected a change of %s", expected)
+		}
+	}
+
+	t.Run("ignored and unmatched files", func(t *testing.T) {
+		write("vendor/lib/lib.go")
+		write("README.md")
+		expectNone()
+	})
+
+	t.Run("burst of changes", func(t *testing.T) {
+		write("src/main.go")
+		write("src/util.go")
+		write("main.go")
+		expect("src/main.go")
+		expectNone()
+	})
+
+	t.Run("new directory", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "src", "api"), os.ModePerm))
+		// the directory is watched once its create event was handled
+		time.Sleep(200 * time.Millisecond)
+		write("src/api/api.go")
+		expect("src/api/api.go")
+	})
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// TokenEnv is the env variable runapp serve reads the token from, it is never passed to the apps
+	TokenEnv    = "RUNAPP_TOKEN"
+	tokenCookie = "runapp_token"
+	tokenQuery  = "token"
+	tokenBytes  = 24
+)
+
+// GenerateToken returns a random token for servers started without one
+func GenerateToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// withAuth accepts the token as a bearer token (API clients) or as a cookie (the dashboard),
+// opening any page with ?token=... stores the cookie and redirects to the same page without the token
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get(tokenQuery); len(token) != 0 {
+			if !s.validToken(token) {
+				writeError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			http.Redirect(w, r, loginTarget(r.URL), http.StatusSeeOther)
+			return
+		}
+
+		if !s.validToken(requestToken(r)) {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loginTarget is the page a login redirects to, only the path and the query without the token are kept,
+// the leading slashes are collapsed since a path like //evil.example is a URL of another host
+func loginTarget(u *url.URL) string {
+	target := "/" + strings.TrimLeft(u.Path, `/\`)
+
+	query := u.Query()
+	query.Del(tokenQuery)
+	if encoded := query.Encode(); len(encoded) != 0 {
+		target += "?" + encoded
+	}
+	return target
+}
+
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); len(header) != 0 {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return ""
+		}
+		return token
+	}
+
+	if cookie, err := r.Cookie(tokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (s *Server) validToken(token string) bool {
+	if len(token) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
@@ -0,0 +1,370 @@
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/runner"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const (
+	defaultLogTail    = 200
+	statusCheckPeriod = 500 * time.Millisecond
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+	// actionTimeout bounds starting, stopping and removing an app, starting includes waiting for its dependencies
+	actionTimeout = 2 * time.Minute
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Server exposes the apps over a token protected REST API with a dashboard on top of it
+type Server struct {
+	token string
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func NewServer(token string) *Server {
+	return &Server{token: token}
+}
+
+// Handler returns the dashboard and the API, every route requires the token
+func (s *Server) Handler() http.Handler {
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// the static dir is embedded at build time
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/apps", s.handleList)
+	mux.HandleFunc("POST /api/apps", s.handleRun)
+	mux.HandleFunc("GET /api/apps/{name}", withApp(s.handleGet))
+	mux.HandleFunc("DELETE /api/apps/{name}", withApp(s.handleRemove))
+	mux.HandleFunc("POST /api/apps/{name}/kill", withApp(s.handleKill))
+	mux.HandleFunc("POST /api/apps/{name}/restart", withApp(s.handleRestart))
+	mux.HandleFunc("GET /api/apps/{name}/logs", withApp(s.handleLogs))
+	return s.withAuth(mux)
+}
+
+// Serve serves on the listener until ctx is done
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		util.DebugLog("failed to shutdown the server gracefully: %v", err)
+		return server.Close()
+	}
+	return nil
+}
+
+func (s *Server) handleList(w http.ResponseWriter, _ *http.Request) {
+	list, err := apps.List()
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleRun creates an app from the template in the body (like runapp run) and starts it,
+// the working directory and environment default to the ones of the server (without the token)
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	var template apps.App
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := template.ValidateTemplate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(template.CWD) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+		template.CWD = cwd
+	}
+	if len(template.Env) == 0 {
+		template.Env = appEnv()
+	}
+
+	list, err := apps.List()
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	if err := apps.ValidateDependencies(list, template); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if existing, err := apps.Get(template.Name); err == nil && existing.IsRunning() {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s is already running", template.Name))
+		return
+	}
+
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	app, _, err := runner.Create(ctx, template)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, app)
+}
+
+// actionContext is detached from the request, a client that disconnects must not leave an app half stopped or started
+func actionContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(r.Context()), actionTimeout)
+}
+
+// appEnv is the environment of the server without the token, the apps and GET /api/apps must not expose it
+func appEnv() []string {
+	return slices.DeleteFunc(os.Environ(), func(entry string) bool {
+		return strings.HasPrefix(entry, TokenEnv+"=")
+	})
+}
+
+// withApp validates the name in the path, it is used to build the path of the app directory
+func withApp(handler func(w http.ResponseWriter, r *http.Request, app *apps.App)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := apps.ValidateName(name); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		app, err := apps.Get(name)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+		handler(w, r, app)
+	}
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, _ *http.Request, app *apps.App) {
+	writeJSON(w, http.StatusOK, app)
+}
+
+func (s *Server) handleKill(w http.ResponseWriter, r *http.Request, app *apps.App) {
+	if !app.IsRunning() {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s is not running", app.Name))
+		return
+	}
+
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	// dependents are stopped first, they would fail without the app anyway
+	if _, err := runner.StopDependents(ctx, app); err != nil {
+		writeAppError(w, err)
+		return
+	}
+	if err := runner.Stop(ctx, app); err != nil {
+		writeAppError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request, app *apps.App) {
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	if _, err := runner.Restart(ctx, app); err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	restarted, err := apps.Get(app.Name)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, restarted)
+}
+
+func (s *Server) handleRemove(w http.ResponseWriter, r *http.Request, app *apps.App) {
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	if err := runner.Remove(ctx, app); err != nil {
+		writeAppError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLogs streams the logs as Server-Sent Events, query: tail (number of lines, 200 by default) and type (all, stdout, stderr),
+// every line is a "log" event and an "exit" event with the app is sent once it is no longer running
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request, app *apps.App) {
+	opts, err := logOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, value any) error {
+		if err := writeEvent(w, event, value); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	sendLog := func(log logs.Log) error {
+		return send("log", log)
+	}
+
+	if !app.IsRunning() {
+		if err := logs.EachLine(*app, opts, sendLog); err != nil {
+			util.DebugLog("failed to write logs of %s: %v", app.Name, err)
+			return
+		}
+		if err := send("exit", app); err != nil {
+			util.DebugLog("failed to write exit of %s: %v", app.Name, err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	logStream, err := logs.Stream(ctx, *app, opts)
+	if err != nil {
+		util.DebugLog("failed to stream logs of %s: %v", app.Name, err)
+		return
+	}
+
+	ticker := time.NewTicker(statusCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case log := <-logStream:
+			if err := sendLog(log); err != nil {
+				return
+			}
+		case <-ticker.C:
+			current, err := apps.Get(app.Name)
+			if err != nil {
+				return
+			}
+			if !current.IsRunning() {
+				if err := send("exit", current); err != nil {
+					util.DebugLog("failed to write exit of %s: %v", app.Name, err)
+				}
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func logOptions(r *http.Request) (logs.Options, error) {
+	query := r.URL.Query()
+
+	opts := logs.DefaultOptions()
+	opts.Tail = defaultLogTail
+
+	if value := query.Get("type"); len(value) != 0 {
+		if !slices.Contains(logs.ValidTypes, value) {
+			return opts, fmt.Errorf("unknown log type: %s", value)
+		}
+		opts.Type = value
+	}
+
+	if value := query.Get("tail"); len(value) != 0 {
+		tail, err := strconv.Atoi(value)
+		if err != nil || tail < logs.TailAll {
+			return opts, fmt.Errorf("invalid tail: %s", value)
+		}
+		opts.Tail = tail
+	}
+	return opts, nil
+}
+
+func writeEvent(w http.ResponseWriter, event string, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		util.DebugLog("failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeAppError(w http.ResponseWriter, err error) {
+	if errors.Is(err, apps.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
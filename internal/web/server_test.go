@@ -0,0 +1,256 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xB1a60/runapp/internal/apps"
+	"github.com/0xB1a60/runapp/internal/common"
+	"github.com/0xB1a60/runapp/internal/logs"
+	"github.com/0xB1a60/runapp/internal/testutil"
+	"github.com/0xB1a60/runapp/internal/util"
+)
+
+const testToken = "secret"
+
+func startServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	testutil.SetupHome(t)
+
+	server := httptest.NewServer(NewServer(testToken).Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// createFinishedApp saves an app that already exited with its combined log
+func createFinishedApp(t *testing.T, name string, lines ...logs.Record) {
+	t.Helper()
+
+	homeDir, err := util.HomeDirPath()
+	require.NoError(t, err)
+	runDir := filepath.Join(homeDir, name)
+	require.NoError(t, os.MkdirAll(runDir, os.ModePerm))
+
+	var combined strings.Builder
+	for _, line := range lines {
+		b, err := json.Marshal(line)
+		require.NoError(t, err)
+		combined.Write(b)
+		combined.WriteString("\n")
+	}
+
+	app := apps.App{
+		Name:         name,
+		Mode:         common.RunModeOnce,
+		Status:       common.AppStatusSuccess,
+		Command:      "echo hello",
+		PID:          -1,
+		ConfigPath:   runDir,
+		StdoutPath:   filepath.Join(runDir, common.FileStdOut),
+		StderrPath:   filepath.Join(runDir, common.FileStdErr),
+		CombinedPath: filepath.Join(runDir, common.FileCombined),
+		ExitCode:     new(0),
+	}
+	require.NoError(t, os.WriteFile(app.StdoutPath, nil, 0644))
+	require.NoError(t, os.WriteFile(app.StderrPath, nil, 0644))
+	require.NoError(t, os.WriteFile(app.CombinedPath, []byte(combined.String()), 0644))
+	require.NoError(t, app.SaveToFile())
+}
+
+func request(t *testing.T, method string, url string, token string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	if len(token) != 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = res.Body.Close()
+	})
+	return res
+}
+
+func TestAuth(t *testing.T) {
+	server := startServer(t)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "missing token", token: "", status: http.StatusUnauthorized},
+		{name: "invalid token", token: "nope", status: http.StatusUnauthorized},
+		{name: "valid token", token: testToken, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.status, request(t, http.MethodGet, server.URL+"/api/apps", tt.token).StatusCode)
+			require.Equal(t, tt.status, request(t, http.MethodGet, server.URL+"/", tt.token).StatusCode)
+		})
+	}
+}
+
+func TestAuthCookie(t *testing.T) {
+	server := startServer(t)
+
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	res, err := client.Get(server.URL + "/?token=" + testToken)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusSeeOther, res.StatusCode)
+	require.Equal(t, "/", res.Header.Get("Location"))
+
+	cookies := res.Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, tokenCookie, cookies[0].Name)
+	require.True(t, cookies[0].HttpOnly)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+	require.NoError(t, err)
+	req.AddCookie(cookies[0])
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Contains(t, res.Header.Get("Content-Type"), "text/html")
+
+	// the login link must not redirect to another host
+	for _, loginPath := range []string{"//evil.example/", `/\evil.example/`} {
+		res, err = client.Get(server.URL + loginPath + "?token=" + testToken + "&tail=10")
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusSeeOther, res.StatusCode)
+		require.Equal(t, "/evil.example/?tail=10", res.Header.Get("Location"))
+	}
+
+	res, err = client.Get(server.URL + "/?token=nope")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestApps(t *testing.T) {
+	server := startServer(t)
+	createFinishedApp(t, "hello")
+
+	res := request(t, http.MethodGet, server.URL+"/api/apps", testToken)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var list []apps.App
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+	require.Len(t, list, 1)
+	require.Equal(t, "hello", list[0].Name)
+
+	res = request(t, http.MethodGet, server.URL+"/api/apps/hello", testToken)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = request(t, http.MethodGet, server.URL+"/api/apps/missing", testToken)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res = request(t, http.MethodPost, server.URL+"/api/apps/hello/kill", testToken)
+	require.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res = request(t, http.MethodDelete, server.URL+"/api/apps/hello", testToken)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res = request(t, http.MethodGet, server.URL+"/api/apps/hello", testToken)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestRunValidation(t *testing.T) {
+	server := startServer(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid json", body: "{"},
+		{name: "invalid name", body: `{"name":"a","command":"echo"}`},
+		{name: "missing command", body: `{"name":"hello"}`},
+		{name: "unknown mode", body: `{"name":"hello","command":"echo","mode":"sometimes"}`},
+		{name: "unknown restart policy", body: `{"name":"hello","command":"echo","restart":{"policy":"sometimes"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/api/apps", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+testToken)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func TestLogsEvents(t *testing.T) {
+	server := startServer(t)
+	now := time.Now()
+	createFinishedApp(t, "hello",
+		logs.Record{Time: now, Stream: logs.OutLogs, Line: "first"},
+		logs.Record{Time: now, Stream: logs.ErrLogs, Line: "second"},
+		logs.Record{Time: now, Stream: logs.OutLogs, Line: "third"},
+	)
+
+	res := request(t, http.MethodGet, server.URL+"/api/apps/hello/logs?tail=2", testToken)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	var events []string
+	var lines []logs.Log
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		event, ok := strings.CutPrefix(scanner.Text(), "event: ")
+		if !ok {
+			continue
+		}
+		events = append(events, event)
+
+		require.True(t, scanner.Scan())
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		require.True(t, ok)
+		if event == "log" {
+			var log logs.Log
+			require.NoError(t, json.Unmarshal([]byte(data), &log))
+			lines = append(lines, log)
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Equal(t, []string{"log", "log", "exit"}, events)
+	require.Equal(t, "second", lines[0].Value)
+	require.True(t, lines[0].IsErr)
+	require.Equal(t, "third", lines[1].Value)
+	require.False(t, lines[1].IsErr)
+
+	res = request(t, http.MethodGet, server.URL+"/api/apps/hello/logs?tail=x", testToken)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestAppEnv(t *testing.T) {
+	t.Setenv(TokenEnv, testToken)
+	t.Setenv("GREETING", "hello")
+
+	env := appEnv()
+	require.Contains(t, env, "GREETING=hello")
+	require.NotContains(t, env, TokenEnv+"="+testToken)
+}